@@ -20,31 +20,43 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
-	"net/url"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
+
+	"github.com/sorenjohanson/specgate/pkg/specgate"
 )
 
 func main() {
-	var (
-		specPath = flag.String("spec", "openapi.yaml", "Path to OpenAPI spec")
-		upstream = flag.String("upstream", "http://localhost:3000", "Upstream API URL")
-		port     = flag.String("port", "8080", "Proxy port")
-		mode     = flag.String("mode", "warn", "Mode: strict|warn|report")
-	)
-	flag.Parse()
-
-	// Show help if no arguments provided
-	if len(os.Args) == 1 {
+	cfg, err := parseFlags(flag.CommandLine, os.Args[1:])
+
+	// Show help if no arguments or environment variables provided
+	if len(os.Args) == 1 && !hasEnvConfig() {
 		flag.Usage()
 		return
 	}
 
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatal("Invalid configuration:", err)
+	}
+
+	if code, ok := specgate.RunCheck(os.Stdout, cfg); ok {
+		os.Exit(code)
+	}
+
 	// GPL required copyright notice
 	fmt.Println("SpecGate Copyright (C) 2025 Søren Johanson")
 	fmt.Println("This program comes with ABSOLUTELY NO WARRANTY.")
@@ -52,63 +64,340 @@ func main() {
 	fmt.Println("under certain conditions; see LICENSE file for details.")
 	fmt.Println()
 
-	if strings.HasPrefix(*specPath, "http://") || strings.HasPrefix(*specPath, "https://") {
-		if err := validateSpecUpstreamMatch(*specPath, *upstream); err != nil {
-			fmt.Printf("WARNING: %s\n", err.Error())
-			fmt.Print("Do you want to continue? (y/N): ")
+	confirmRemoteSpec(cfg)
 
-			reader := bufio.NewReader(os.Stdin)
-			response, err := reader.ReadString('\n')
-			if err != nil {
-				log.Fatal("Failed to read user input:", err)
-			}
+	proxy, err := specgate.New(cfg)
+	if err != nil {
+		log.Fatal("Failed to create proxy:", err)
+	}
 
-			response = strings.TrimSpace(strings.ToLower(response))
-			if response != "y" && response != "yes" {
-				fmt.Println("Aborted.")
-				os.Exit(1)
-			}
-		}
+	shutdownTracing, err := specgate.SetupTracing(context.Background(), cfg)
+	if err != nil {
+		log.Fatal("Failed to set up tracing:", err)
 	}
 
-	proxy, err := NewValidatingProxy(*specPath, *upstream, *mode)
+	server, err := specgate.NewServer(cfg, proxy)
 	if err != nil {
-		log.Fatal("Failed to create proxy:", err)
+		log.Fatal("Failed to set up TLS:", err)
+	}
+	printStartupInfo(cfg)
+
+	servers := []listenedServer{{Server: server, proxyProtocol: cfg.ProxyProtocol}}
+	if cfg.MetricsPort != "" {
+		fmt.Printf("Serving metrics on port: %s\n", cfg.MetricsPort)
+		servers = append(servers, listenedServer{Server: newMetricsServer(cfg.MetricsPort, proxy.MetricsHandler())})
 	}
 
-	fmt.Printf("Starting validation proxy on port: %s\n", *port)
-	fmt.Printf("Proxying to: %s\n", *upstream)
-	fmt.Printf("Mode: %s\n", *mode)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	server := &http.Server{
-		Addr:         ":" + *port,
-		Handler:      proxy,
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		IdleTimeout:  120 * time.Second,
+	startSpecUpdates(ctx, cfg, proxy)
+
+	serveErr := runServers(ctx, cfg.ShutdownTimeout, servers...)
+	fmt.Println()
+	if err := proxy.Stats().WriteSummary(os.Stdout); err != nil {
+		log.Println("Failed to print validation summary:", err)
 	}
 
-	if err := server.ListenAndServe(); err != nil {
+	if err := errors.Join(serveErr, proxy.Close(), shutdownTracing(context.Background())); err != nil {
 		log.Fatal(err)
 	}
+	if failed := proxy.Stats().Failed(); cfg.FailOnError && failed > 0 {
+		fmt.Printf("Exiting with status 1: %d response(s) failed validation\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("Shut down cleanly.")
 }
 
-func validateSpecUpstreamMatch(specURL, upstreamURL string) error {
-	specParsed, err := url.Parse(specURL)
+func printStartupInfo(cfg *specgate.Config) {
+	if path, ok := strings.CutPrefix(cfg.Port, "unix:"); ok {
+		fmt.Printf("Starting validation proxy on socket: %s\n", path)
+	} else {
+		fmt.Printf("Starting validation proxy on port: %s\n", cfg.Port)
+	}
+	if cfg.TLSCert != "" {
+		fmt.Printf("Serving HTTPS with certificate: %s\n", cfg.TLSCert)
+	}
+	if cfg.ProxyProtocol {
+		fmt.Println("Expecting a PROXY protocol header on each connection")
+	}
+	if cfg.Replay != "" {
+		fmt.Printf("Replaying fixtures from: %s\n", cfg.Replay)
+	}
+	fmt.Printf("Proxying to: %s\n", cfg.Upstream)
+	if cfg.UpstreamInsecure {
+		fmt.Println("WARNING: TLS certificate verification for the upstream is disabled")
+	}
+	fmt.Printf("Mode: %s\n", cfg.Mode)
+	if cfg.OtelEndpoint != "" {
+		fmt.Printf("Exporting traces to: %s\n", cfg.OtelEndpoint)
+	}
+	fmt.Printf("Sample rate: %g\n", cfg.SampleRate)
+}
+
+// startSpecUpdates starts watching or refreshing the spec, as configured.
+func startSpecUpdates(ctx context.Context, cfg *specgate.Config, proxy *specgate.ValidatingProxy) {
+	if cfg.Watch {
+		if err := proxy.WatchSpec(ctx); err != nil {
+			log.Fatal("Failed to watch spec:", err)
+		}
+		fmt.Printf("Watching %s for changes\n", cfg.Spec)
+	}
+
+	if cfg.SpecRefreshInterval > 0 {
+		if err := proxy.RefreshSpec(ctx, cfg.SpecRefreshInterval); err != nil {
+			log.Fatal("Failed to refresh spec:", err)
+		}
+		fmt.Printf("Refreshing %s every %s\n", cfg.Spec, cfg.SpecRefreshInterval)
+	}
+}
+
+func confirmRemoteSpec(cfg *specgate.Config) {
+	for _, err := range specgate.RemoteSpecMismatches(cfg) {
+		fmt.Printf("WARNING: %s\n", err.Error())
+		confirmContinue()
+	}
+}
+
+// confirmContinue asks whether to continue, exiting unless the user agrees.
+func confirmContinue() {
+	fmt.Print("Do you want to continue? (y/N): ")
+
+	reader := bufio.NewReader(os.Stdin)
+	response, err := reader.ReadString('\n')
 	if err != nil {
-		return fmt.Errorf("invalid spec URL: %s", specURL)
+		log.Fatal("Failed to read user input:", err)
+	}
+
+	response = strings.TrimSpace(strings.ToLower(response))
+	if response != "y" && response != "yes" {
+		fmt.Println("Aborted.")
+		os.Exit(1)
 	}
+}
+
+// listenedServer is a server to run, and whether its connections start with a
+// PROXY protocol header.
+type listenedServer struct {
+	*http.Server
+	proxyProtocol bool
+}
 
-	upstreamParsed, err := url.Parse(upstreamURL)
+// runServers serves until ctx is cancelled or any server fails, then gives
+// in-flight requests up to drainTimeout to complete before returning.
+func runServers(ctx context.Context, drainTimeout time.Duration, servers ...listenedServer) error {
+	errCh := make(chan error, len(servers))
+	for _, server := range servers {
+		go func() {
+			if err := listenAndServe(server); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errCh <- fmt.Errorf("server on %s failed: %w", server.Addr, err)
+			}
+		}()
+	}
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		fmt.Println("Shutting down, waiting for in-flight requests...")
+	case serveErr = <-errCh:
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	var shutdownErrs []error
+	for _, server := range servers {
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			shutdownErrs = append(shutdownErrs, fmt.Errorf("failed to shut down server on %s: %w", server.Addr, err))
+		}
+	}
+
+	return errors.Join(serveErr, errors.Join(shutdownErrs...))
+}
+
+// listenAndServe serves HTTPS when the server has a TLS config, taking the
+// certificate from it, and plain HTTP otherwise.
+func listenAndServe(server listenedServer) error {
+	listener, err := specgate.Listen(server.Addr)
 	if err != nil {
-		return fmt.Errorf("invalid upstream URL: %s", upstreamURL)
+		return err
+	}
+	if server.proxyProtocol {
+		listener = specgate.NewProxyProtocolListener(listener)
+	}
+	if server.TLSConfig != nil {
+		return server.ServeTLS(listener, "", "")
+	}
+	return server.Serve(listener)
+}
+
+func registerFlags(fs *flag.FlagSet, cfg *specgate.Config, configPath *string) {
+	fs.StringVar(configPath, "config", "", "Path to a YAML or JSON config file")
+	fs.StringVar(&cfg.Spec, "spec", cfg.Spec, "Path or URL of the OpenAPI spec, or a comma-separated list of specs to merge")
+	fs.StringVar(&cfg.Upstream, "upstream", cfg.Upstream, "Upstream API URL, or a comma-separated list of replicas and prefix=URL mappings to route by path, e.g. /users=http://users:8080,/orders=http://orders:8080")
+	fs.StringVar(&cfg.UpstreamCA, "upstream-ca", cfg.UpstreamCA, "PEM bundle of extra CAs to trust for an HTTPS upstream")
+	fs.BoolVar(&cfg.UpstreamInsecure, "upstream-insecure", cfg.UpstreamInsecure, "Skip TLS certificate verification for the upstream (insecure)")
+	fs.StringVar(&cfg.UpstreamClientCert, "upstream-client-cert", cfg.UpstreamClientCert, "Client certificate to present to the upstream for mutual TLS (requires -upstream-client-key)")
+	fs.StringVar(&cfg.UpstreamClientKey, "upstream-client-key", cfg.UpstreamClientKey, "Private key for -upstream-client-cert")
+	fs.DurationVar(&cfg.UpstreamDialTimeout, "upstream-dial-timeout", cfg.UpstreamDialTimeout, "Time allowed to connect to the upstream (0 means no limit)")
+	fs.DurationVar(&cfg.UpstreamTLSHandshakeTimeout, "upstream-tls-handshake-timeout", cfg.UpstreamTLSHandshakeTimeout, "Time allowed for the TLS handshake with the upstream (0 means no limit)")
+	fs.DurationVar(&cfg.UpstreamResponseHeaderTimeout, "upstream-response-header-timeout", cfg.UpstreamResponseHeaderTimeout, "Time to wait for the upstream's response headers (0 means no limit)")
+	fs.IntVar(&cfg.UpstreamMaxIdleConns, "upstream-max-idle-conns", cfg.UpstreamMaxIdleConns, "Idle keep-alive connections to keep open to the upstream (0 means no limit)")
+	fs.DurationVar(&cfg.UpstreamIdleTimeout, "upstream-idle-timeout", cfg.UpstreamIdleTimeout, "How long an idle upstream connection is kept open (0 means no limit)")
+	fs.DurationVar(&cfg.UpstreamFailTimeout, "upstream-fail-timeout", cfg.UpstreamFailTimeout, "How long an upstream replica that failed to respond is taken out of rotation (0 disables health tracking)")
+	fs.IntVar(&cfg.Retries, "retries", cfg.Retries, "Times to retry idempotent requests when the upstream can't be reached")
+	fs.DurationVar(&cfg.RetryBackoff, "retry-backoff", cfg.RetryBackoff, "Wait before the first retry, doubled for each further retry")
+	fs.StringVar(&cfg.Port, "port", cfg.Port, "Proxy port, or unix:/path/to.sock to listen on a Unix domain socket")
+	fs.StringVar(&cfg.TLSCert, "tls-cert", cfg.TLSCert, "Certificate file for serving HTTPS (requires -tls-key)")
+	fs.StringVar(&cfg.TLSKey, "tls-key", cfg.TLSKey, "Private key file for serving HTTPS (requires -tls-cert)")
+	fs.BoolVar(&cfg.ProxyProtocol, "proxy-protocol", cfg.ProxyProtocol, "Require a PROXY protocol v1 or v2 header on proxy connections and take the client address from it")
+	fs.StringVar(&cfg.Mode, "mode", cfg.Mode, "Mode: strict|warn|report|mock")
+	fs.BoolVar(&cfg.MockFallback, "mock-fallback", cfg.MockFallback, "Answer documented operations from the spec's examples when the upstream can't be reached")
+	fs.StringVar(&cfg.Router, "router", cfg.Router, "Path matching backend: gorillamux|legacy")
+	fs.Float64Var(&cfg.SampleRate, "sample-rate", cfg.SampleRate, "Fraction of responses to validate, 0.0-1.0 (strict mode always validates)")
+	fs.Var(&cfg.SkipStatus, "skip-status", "Status codes to skip validation for, e.g. 404,5xx,500-503")
+	fs.Var(&cfg.IncludePaths, "include-paths", "Comma-separated path template globs to validate, e.g. /users/**; others are proxied without validation")
+	fs.Var(&cfg.ExcludePaths, "exclude-paths", "Comma-separated path template globs not to validate, e.g. /internal/**")
+	fs.Var(&cfg.IgnorePaths, "ignore-paths", "Comma-separated request path globs to proxy without validation or undocumented warnings, e.g. /healthz")
+	fs.BoolVar(&cfg.NoAdditionalProperties, "no-additional-properties", cfg.NoAdditionalProperties, "Fail responses with object properties the schema doesn't document, unless it allows additionalProperties")
+	fs.BoolVar(&cfg.StrictFormats, "strict-formats", cfg.StrictFormats, "Enforce the uuid, email, ipv4 and ipv6 string formats")
+	fs.BoolVar(&cfg.ExactIntegers, "exact-integers", cfg.ExactIntegers, "Check integers against the int32 and int64 formats exactly, instead of as float64")
+	fs.Var(&cfg.SkipContentTypes, "skip-content-types", "Comma-separated response content types to stream without validation, e.g. text/event-stream,video/*")
+	fs.IntVar(&cfg.FailureStatus, "failure-status", cfg.FailureStatus, "Status code returned for invalid responses in strict mode, e.g. 502")
+	fs.Var(&cfg.ErrorUpstreamBody, "error-upstream-body", "Include up to this much of the invalid upstream body in strict-mode error responses, e.g. 1KB (disabled when 0)")
+	fs.Var(&cfg.StripErrorHeaders, "strip-error-headers", "Comma-separated upstream headers to remove when strict mode replaces a response, e.g. ETag,Cache-Control")
+	fs.StringVar(&cfg.ErrorFormat, "error-format", cfg.ErrorFormat, "Strict-mode error body format: json|problem")
+	fs.StringVar(&cfg.ErrorTemplate, "error-template", cfg.ErrorTemplate, "Go template file for strict-mode error bodies (overrides -error-format)")
+	fs.BoolVar(&cfg.ExposeErrors, "expose-errors", cfg.ExposeErrors, "Include validation error details in strict-mode error bodies (they are always logged)")
+	fs.BoolVar(&cfg.ValidationHeader, "validation-header", cfg.ValidationHeader, "Add an X-SpecGate-Validation: failed header to invalid responses that are passed through in warn and report modes")
+	fs.BoolVar(&cfg.AssumeJSON, "assume-json", cfg.AssumeJSON, "Validate response bodies sent without a Content-Type as JSON if they start with { or [, adding the header")
+	fs.BoolVar(&cfg.ValidateAccept, "validate-accept", cfg.ValidateAccept, "Fail responses whose content type the request's Accept header doesn't allow")
+	fs.BoolVar(&cfg.CompressResponses, "compress-responses", cfg.CompressResponses, "Gzip validated responses the upstream sent uncompressed, for clients that accept gzip")
+	fs.BoolVar(&cfg.LintSpec, "lint-spec", cfg.LintSpec, "Check the spec for problems and exit (non-zero if any are found)")
+	fs.BoolVar(&cfg.CheckExamples, "check-examples", cfg.CheckExamples, "Check every example in the spec against its schema and exit (non-zero if any don't match)")
+	fs.BoolVar(&cfg.Check, "check", cfg.Check, "Load the spec, check that every upstream is reachable, and exit (non-zero on failure)")
+	fs.BoolVar(&cfg.FailOnError, "fail-on-error", cfg.FailOnError, "Exit with status 1 on shutdown if any response failed validation")
+	fs.BoolVar(&cfg.PrintConfig, "print-config", cfg.PrintConfig, "Print the resolved configuration as YAML and exit")
+	fs.BoolVar(&cfg.Watch, "watch", cfg.Watch, "Reload the spec when the local spec file changes")
+	fs.DurationVar(&cfg.SpecRefreshInterval, "spec-refresh-interval", cfg.SpecRefreshInterval, "Re-fetch a remote spec at this interval (disabled when 0)")
+	fs.BoolVar(&cfg.KeepSpecBasePath, "keep-spec-base-path", cfg.KeepSpecBasePath, "Route requests against the base path of the spec's servers, e.g. /v2, instead of the upstream root")
+	fs.Var(&cfg.ServerVariables, "server-var", "Value for a variable in the spec's server URLs, as \"name=value\" (repeatable; needs -keep-spec-base-path)")
+	fs.BoolVar(&cfg.ValidateRequests, "validate-requests", cfg.ValidateRequests, "Validate incoming requests against the spec")
+	fs.BoolVar(&cfg.ValidateSecurity, "validate-security", cfg.ValidateSecurity, "Reject requests missing the credentials their security requirements declare (needs -validate-requests)")
+	fs.BoolVar(&cfg.CoerceQuery, "coerce-query", cfg.CoerceQuery, "Rewrite valid query parameters into canonical form and fill in spec defaults before forwarding (needs -validate-requests)")
+	fs.BoolVar(&cfg.XForwardedHeaders, "x-forwarded-headers", cfg.XForwardedHeaders, "Send X-Forwarded-For/-Host/-Proto to the upstream")
+	fs.BoolVar(&cfg.ForwardedHeader, "forwarded-header", cfg.ForwardedHeader, "Also send an RFC 7239 Forwarded header to the upstream")
+	fs.StringVar(&cfg.AuthToken, "auth-token", cfg.AuthToken, "Require clients to send this bearer token to use the proxy (${VAR} is expanded from the environment)")
+	fs.StringVar(&cfg.AuthBasic, "auth-basic", cfg.AuthBasic, "Require clients to send these HTTP Basic credentials, as user:password (${VAR} is expanded from the environment)")
+	fs.Var(&cfg.AllowCIDRs, "allow-cidr", "Comma-separated CIDR ranges allowed to use the proxy, e.g. 10.0.0.0/8,192.168.1.0/24 (default: any)")
+	fs.StringVar(&cfg.ClientIPHeader, "client-ip-header", cfg.ClientIPHeader, "Header a trusted reverse proxy in front of SpecGate puts the client address in, e.g. X-Forwarded-For")
+	fs.Var(&cfg.RateLimit, "rate-limit", "Per-client rate limit as requests/sec, optionally with a burst, e.g. 10 or 10:20 (default: unlimited)")
+	fs.StringVar(&cfg.RateLimitHeader, "rate-limit-header", cfg.RateLimitHeader, "Header holding an API key to rate-limit clients by instead of their address, e.g. X-API-Key")
+	fs.Var(&cfg.CORSAllowedOrigins, "cors-allowed-origins", "Comma-separated origins allowed to call the API from a browser, or * for any; enables CORS handling")
+	fs.Var(&cfg.CORSAllowedMethods, "cors-allowed-methods", "Comma-separated methods allowed in CORS requests")
+	fs.Var(&cfg.CORSAllowedHeaders, "cors-allowed-headers", "Comma-separated request headers allowed in CORS requests (default: any the browser asks for)")
+	fs.Var(&cfg.MaxBodySize, "max-body-size", "Largest body to buffer for validation, e.g. 25MB (larger bodies are skipped)")
+	fs.StringVar(&cfg.UnknownLength, "unknown-length", cfg.UnknownLength, "What to do with response bodies sent without a Content-Length: buffer or skip")
+	fs.StringVar(&cfg.LogFormat, "log-format", cfg.LogFormat, "Log format: color|json|text")
+	fs.StringVar(&cfg.LogLevel, "log-level", cfg.LogLevel, "Log level: debug|info|warn|error")
+	fs.StringVar(&cfg.FailuresOut, "failures-out", cfg.FailuresOut, "Append validation failures as NDJSON to this file")
+	fs.StringVar(&cfg.WebhookURL, "webhook-url", cfg.WebhookURL, "POST a JSON notification to this URL when validation fails")
+	fs.Var(&cfg.WebhookHeaders, "webhook-header", "Header to send with webhook notifications, as \"Name: value\" (repeatable; ${VAR} is expanded from the environment)")
+	fs.DurationVar(&cfg.WebhookInterval, "webhook-interval", cfg.WebhookInterval, "Minimum time between webhook notifications for the same operation")
+	fs.StringVar(&cfg.SlackWebhook, "slack-webhook", cfg.SlackWebhook, "Post validation failures to this Slack incoming webhook URL")
+	fs.DurationVar(&cfg.SlackBatchWindow, "slack-batch-window", cfg.SlackBatchWindow, "Collect validation failures for this long before posting them to Slack as one message")
+	fs.StringVar(&cfg.OtelEndpoint, "otel-endpoint", cfg.OtelEndpoint, "Export OpenTelemetry traces over OTLP/HTTP to this URL, e.g. http://localhost:4318")
+	fs.DurationVar(&cfg.SlowThreshold, "slow-threshold", cfg.SlowThreshold, "Warn when an operation takes longer than this to get a response from the upstream (0 disables)")
+	fs.StringVar(&cfg.Record, "record", cfg.Record, "Write each validated request/response pair to this directory as a JSON fixture")
+	fs.StringVar(&cfg.Replay, "replay", cfg.Replay, "Serve responses from fixtures recorded with -record instead of the upstream")
+	fs.StringVar(&cfg.ReplayFallback, "replay-fallback", cfg.ReplayFallback, "What to do with requests that have no fixture in replay mode: not_found or upstream")
+	fs.Var(&cfg.RedactFields, "redact-fields", "JSON fields whose values are masked in validation errors, e.g. password,user.ssn")
+	fs.StringVar(&cfg.MetricsPort, "metrics-port", cfg.MetricsPort, "Port for the Prometheus /metrics endpoint (disabled when empty)")
+	fs.DurationVar(&cfg.ReadTimeout, "read-timeout", cfg.ReadTimeout, "Time allowed to read a whole client request, including its body (0 means no limit)")
+	fs.DurationVar(&cfg.ReadHeaderTimeout, "read-header-timeout", cfg.ReadHeaderTimeout, "Time allowed to read a client request's headers (0 falls back to -read-timeout)")
+	fs.DurationVar(&cfg.WriteTimeout, "write-timeout", cfg.WriteTimeout, "Time allowed to write a response, from the end of the request headers (0 means no limit)")
+	fs.DurationVar(&cfg.IdleTimeout, "idle-timeout", cfg.IdleTimeout, "How long an idle keep-alive client connection is kept open (0 falls back to -read-timeout)")
+	fs.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", cfg.ShutdownTimeout, "Time to wait for in-flight requests on shutdown")
+}
+
+func newMetricsServer(port string, metrics http.Handler) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+
+	return &http.Server{
+		Addr:              ":" + port,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
 	}
+}
 
-	if specParsed.Host != upstreamParsed.Host || specParsed.Scheme != upstreamParsed.Scheme {
-		return fmt.Errorf("spec URL (%s) does not match upstream URL (%s)",
-			specParsed.Scheme+"://"+specParsed.Host,
-			upstreamParsed.Scheme+"://"+upstreamParsed.Host)
+// envPrefix prefixes the environment variable for each flag, named after
+// the flag in upper case with dashes as underscores, e.g. SPECGATE_MAX_BODY_SIZE.
+const envPrefix = "SPECGATE_"
+
+// parseFlags resolves the effective configuration from the config file (if
+// any), SPECGATE_ environment variables and the command line, in increasing
+// order of precedence.
+func parseFlags(fs *flag.FlagSet, args []string) (*specgate.Config, error) {
+	var configPath string
+	cfg := specgate.DefaultConfig()
+	registerFlags(fs, cfg, &configPath)
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if configPath == "" {
+		configPath = os.Getenv(envPrefix + "CONFIG")
+	}
+	if configPath != "" {
+		fileCfg, err := specgate.LoadConfig(configPath)
+		if err != nil {
+			return nil, err
+		}
+		cfg = fileCfg
+	}
+
+	// Parsing the same arguments again only overwrites the values of flags that were actually set
+	overrides := flag.NewFlagSet(fs.Name(), flag.ContinueOnError)
+	overrides.SetOutput(io.Discard)
+	registerFlags(overrides, cfg, &configPath)
+	if err := applyEnv(overrides); err != nil {
+		return nil, err
+	}
+	if err := overrides.Parse(args); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return cfg, nil
+}
+
+// applyEnv sets each flag in fs whose environment variable is set.
+func applyEnv(fs *flag.FlagSet) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		name := envName(f.Name)
+		value, ok := os.LookupEnv(name)
+		if !ok || err != nil {
+			return
+		}
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid value %q for %s: %w", value, name, setErr)
+		}
+	})
+	return err
+}
+
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// hasEnvConfig reports whether any SPECGATE_ environment variable is set, in
+// which case SpecGate runs without command-line arguments.
+func hasEnvConfig() bool {
+	for _, entry := range os.Environ() {
+		if strings.HasPrefix(entry, envPrefix) {
+			return true
+		}
+	}
+	return false
 }
@@ -2,73 +2,17 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"flag"
+	"io"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
-func TestValidateSpecUpstreamMatch(t *testing.T) {
-	tests := []struct {
-		name        string
-		specURL     string
-		upstreamURL string
-		expectError bool
-	}{
-		{
-			name:        "matching URLs",
-			specURL:     "https://api.example.com/spec.yaml",
-			upstreamURL: "https://api.example.com",
-			expectError: false,
-		},
-		{
-			name:        "matching URLs with different paths",
-			specURL:     "https://api.example.com/v1/spec.yaml",
-			upstreamURL: "https://api.example.com/v2",
-			expectError: false,
-		},
-		{
-			name:        "different schemes",
-			specURL:     "http://api.example.com/spec.yaml",
-			upstreamURL: "https://api.example.com",
-			expectError: true,
-		},
-		{
-			name:        "different hosts",
-			specURL:     "https://api1.example.com/spec.yaml",
-			upstreamURL: "https://api2.example.com",
-			expectError: true,
-		},
-		{
-			name:        "different ports",
-			specURL:     "https://api.example.com:8080/spec.yaml",
-			upstreamURL: "https://api.example.com:9090",
-			expectError: true,
-		},
-		{
-			name:        "invalid spec URL",
-			specURL:     "://invalid-url",
-			upstreamURL: "https://api.example.com",
-			expectError: true,
-		},
-		{
-			name:        "invalid upstream URL",
-			specURL:     "https://api.example.com/spec.yaml",
-			upstreamURL: "://invalid-url",
-			expectError: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := validateSpecUpstreamMatch(tt.specURL, tt.upstreamURL)
-			if (err != nil) != tt.expectError {
-				t.Errorf("validateSpecUpstreamMatch() error = %v, expectError %v", err, tt.expectError)
-			}
-		})
-	}
-}
-
 func TestMainShowsUsageWithNoArgs(t *testing.T) {
 	oldArgs := os.Args
 
@@ -86,12 +30,7 @@ func TestMainShowsUsageWithNoArgs(t *testing.T) {
 }
 
 func testMainLogicWithFlagSet(t *testing.T, fs *flag.FlagSet, buf *bytes.Buffer) {
-	fs.String("spec", "openapi.yaml", "Path to OpenAPI spec")
-	fs.String("upstream", "http://localhost:3000", "Upstream API URL")
-	fs.String("port", "8080", "Proxy port")
-	fs.String("mode", "warn", "Mode: strict|warn|report")
-
-	err := fs.Parse([]string{})
+	_, err := parseFlags(fs, []string{})
 	if err != nil {
 		t.Fatalf("Failed to parse flags: %v", err)
 	}
@@ -111,3 +50,171 @@ func testMainLogicWithFlagSet(t *testing.T, fs *flag.FlagSet, buf *bytes.Buffer)
 		t.Errorf("Expected -upstream flag in usage output, got: %q", output)
 	}
 }
+
+func TestParseFlags(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "specgate.yaml")
+	configFile := "spec: from-file.yaml\nupstream: http://file.example.com\nmode: strict\n"
+	if err := os.WriteFile(configPath, []byte(configFile), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	tests := []struct {
+		name             string
+		args             []string
+		env              map[string]string
+		expectedSpec     string
+		expectedUpstream string
+		expectedMode     string
+		expectError      bool
+	}{
+		{
+			name:             "defaults",
+			args:             []string{},
+			expectedSpec:     "openapi.yaml",
+			expectedUpstream: "http://localhost:3000",
+			expectedMode:     "warn",
+		},
+		{
+			name:             "flags only",
+			args:             []string{"-spec", "api.yaml", "-mode", "report"},
+			expectedSpec:     "api.yaml",
+			expectedUpstream: "http://localhost:3000",
+			expectedMode:     "report",
+		},
+		{
+			name:             "config file only",
+			args:             []string{"-config", configPath},
+			expectedSpec:     "from-file.yaml",
+			expectedUpstream: "http://file.example.com",
+			expectedMode:     "strict",
+		},
+		{
+			name:             "flags override config file",
+			args:             []string{"-mode", "warn", "-config", configPath},
+			expectedSpec:     "from-file.yaml",
+			expectedUpstream: "http://file.example.com",
+			expectedMode:     "warn",
+		},
+		{
+			name:             "environment only",
+			env:              map[string]string{"SPECGATE_SPEC": "from-env.yaml", "SPECGATE_MODE": "report"},
+			expectedSpec:     "from-env.yaml",
+			expectedUpstream: "http://localhost:3000",
+			expectedMode:     "report",
+		},
+		{
+			name:             "environment overrides config file",
+			args:             []string{"-config", configPath},
+			env:              map[string]string{"SPECGATE_UPSTREAM": "http://env.example.com"},
+			expectedSpec:     "from-file.yaml",
+			expectedUpstream: "http://env.example.com",
+			expectedMode:     "strict",
+		},
+		{
+			name:             "flags override environment",
+			args:             []string{"-mode", "warn"},
+			env:              map[string]string{"SPECGATE_MODE": "report"},
+			expectedSpec:     "openapi.yaml",
+			expectedUpstream: "http://localhost:3000",
+			expectedMode:     "warn",
+		},
+		{
+			name:             "config file from environment",
+			env:              map[string]string{"SPECGATE_CONFIG": configPath},
+			expectedSpec:     "from-file.yaml",
+			expectedUpstream: "http://file.example.com",
+			expectedMode:     "strict",
+		},
+		{
+			name:        "invalid environment value",
+			env:         map[string]string{"SPECGATE_MAX_BODY_SIZE": "lots"},
+			expectError: true,
+		},
+		{
+			name:        "missing config file",
+			args:        []string{"-config", filepath.Join(t.TempDir(), "missing.yaml")},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			fs := flag.NewFlagSet("specgate", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+
+			cfg, err := parseFlags(fs, tt.args)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("parseFlags() error = %v, expectError %v", err, tt.expectError)
+			}
+			if tt.expectError {
+				return
+			}
+
+			if cfg.Spec != tt.expectedSpec {
+				t.Errorf("parseFlags() spec = %q, expected %q", cfg.Spec, tt.expectedSpec)
+			}
+			if cfg.Upstream != tt.expectedUpstream {
+				t.Errorf("parseFlags() upstream = %q, expected %q", cfg.Upstream, tt.expectedUpstream)
+			}
+			if cfg.Mode != tt.expectedMode {
+				t.Errorf("parseFlags() mode = %q, expected %q", cfg.Mode, tt.expectedMode)
+			}
+		})
+	}
+}
+
+func TestParseFlags_CompletesConfigFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "specgate.yaml")
+	if err := os.WriteFile(configPath, []byte("tls_cert: cert.pem\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Setenv("SPECGATE_TLS_KEY", "key.pem")
+
+	fs := flag.NewFlagSet("specgate", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	cfg, err := parseFlags(fs, []string{"-config", configPath})
+	if err != nil {
+		t.Fatalf("parseFlags() unexpected error: %v", err)
+	}
+	if cfg.TLSCert != "cert.pem" || cfg.TLSKey != "key.pem" {
+		t.Errorf("parseFlags() tls_cert = %q, tls_key = %q, expected the file's cert and the environment's key", cfg.TLSCert, cfg.TLSKey)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() of the merged config unexpected error: %v", err)
+	}
+}
+
+func TestRunServers(t *testing.T) {
+	t.Run("returns after context cancellation", func(t *testing.T) {
+		server := &http.Server{Addr: "127.0.0.1:0", ReadHeaderTimeout: time.Second}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- runServers(ctx, time.Second, listenedServer{Server: server})
+		}()
+
+		cancel()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("runServers() unexpected error: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("runServers() did not return after cancellation")
+		}
+	})
+
+	t.Run("returns server errors", func(t *testing.T) {
+		server := &http.Server{Addr: "invalid-address", ReadHeaderTimeout: time.Second}
+
+		err := runServers(context.Background(), time.Second, listenedServer{Server: server})
+		if err == nil {
+			t.Error("runServers() expected an error for an invalid address")
+		}
+	})
+}
@@ -0,0 +1,120 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// accessControl decides whether a request may use the proxy at all, before
+// it is validated or forwarded.
+type accessControl struct {
+	allowedNetworks []netip.Prefix
+	clientIPHeader  string
+	rateLimiter     *rateLimiter
+	cors            *corsPolicy
+	auth            *proxyAuth
+}
+
+// newAccessControl expects cfg to have been validated; invalid CIDR ranges
+// are skipped, which only narrows the allowlist.
+func newAccessControl(cfg *Config) accessControl {
+	access := accessControl{
+		clientIPHeader: cfg.ClientIPHeader,
+		rateLimiter:    newRateLimiter(cfg),
+		cors:           newCORSPolicy(cfg),
+		auth:           newProxyAuth(cfg),
+	}
+	for _, cidr := range cfg.AllowCIDRs {
+		if prefix, err := netip.ParsePrefix(cidr); err == nil {
+			access.allowedNetworks = append(access.allowedNetworks, prefix.Masked())
+		}
+	}
+	return access
+}
+
+// admit reports whether r may go on to be validated and proxied. Otherwise
+// it has already been answered.
+func (vp *ValidatingProxy) admit(w http.ResponseWriter, r *http.Request) bool {
+	if len(vp.access.allowedNetworks) > 0 && !vp.allowClient(w, r) {
+		return false
+	}
+	if vp.access.rateLimiter != nil && !vp.allowRate(w, r) {
+		return false
+	}
+	if vp.access.cors != nil && vp.access.cors.handle(w, r) {
+		return false
+	}
+	if vp.access.auth != nil && !vp.authenticate(w, r) {
+		return false
+	}
+	return true
+}
+
+func (vp *ValidatingProxy) allowClient(w http.ResponseWriter, r *http.Request) bool {
+	ip, ok := clientIP(r, vp.access.clientIPHeader)
+	if ok {
+		for _, network := range vp.access.allowedNetworks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+	}
+
+	vp.log(r.Context()).Warn("Rejected request from outside the allowed networks",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"client_ip", ip)
+	vp.writeError(w, ErrorDetails{
+		Status: http.StatusForbidden,
+		Title:  "Forbidden",
+		Detail: "Your address is not allowed to use this proxy",
+		Method: r.Method,
+		Path:   r.URL.Path,
+	})
+	return false
+}
+
+// clientIP returns the address of the client that sent r. When SpecGate sits
+// behind a trusted proxy, header names the header that proxy puts the
+// client address in; for lists such as X-Forwarded-For, the last entry is
+// the one the trusted proxy added.
+func clientIP(r *http.Request, header string) (netip.Addr, bool) {
+	if header != "" {
+		if values := r.Header.Values(header); len(values) > 0 {
+			entries := strings.Split(values[len(values)-1], ",")
+			if ip, err := netip.ParseAddr(strings.TrimSpace(entries[len(entries)-1])); err == nil {
+				return ip.Unmap(), true
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
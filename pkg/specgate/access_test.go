@@ -0,0 +1,161 @@
+package specgate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidatingProxy_AllowCIDRs(t *testing.T) {
+	tests := []struct {
+		name           string
+		cidrs          FieldList
+		header         string
+		remoteAddr     string
+		forwardedFor   string
+		expectedStatus int
+	}{
+		{
+			name:           "client inside range",
+			cidrs:          FieldList{"10.0.0.0/8"},
+			remoteAddr:     "10.1.2.3:54321",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "client outside range",
+			cidrs:          FieldList{"10.0.0.0/8"},
+			remoteAddr:     "192.168.1.10:54321",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "client in second range",
+			cidrs:          FieldList{"10.0.0.0/8", "192.168.1.0/24"},
+			remoteAddr:     "192.168.1.10:54321",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "ipv6 client",
+			cidrs:          FieldList{"fd00::/8"},
+			remoteAddr:     "[fd12::1]:54321",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "forwarded header is ignored without trusted header",
+			cidrs:          FieldList{"10.0.0.0/8"},
+			remoteAddr:     "192.168.1.10:54321",
+			forwardedFor:   "10.1.2.3",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "trusted header names the client",
+			cidrs:          FieldList{"10.0.0.0/8"},
+			header:         "X-Forwarded-For",
+			remoteAddr:     "192.168.1.10:54321",
+			forwardedFor:   "10.1.2.3",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "spoofed entries before the trusted proxy's are ignored",
+			cidrs:          FieldList{"10.0.0.0/8"},
+			header:         "X-Forwarded-For",
+			remoteAddr:     "192.168.1.10:54321",
+			forwardedFor:   "10.1.2.3, 203.0.113.7",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "no allowlist",
+			remoteAddr:     "203.0.113.7:54321",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id": 1, "name": "test"}`))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.AllowCIDRs = tt.cidrs
+			cfg.ClientIPHeader = tt.header
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d", rec.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+		expectOK   bool
+	}{
+		{
+			name:       "remote address",
+			remoteAddr: "10.1.2.3:54321",
+			expected:   "10.1.2.3",
+			expectOK:   true,
+		},
+		{
+			name:       "ipv4-mapped ipv6 remote address",
+			remoteAddr: "[::ffff:10.1.2.3]:54321",
+			expected:   "10.1.2.3",
+			expectOK:   true,
+		},
+		{
+			name:       "single-value header",
+			header:     "X-Real-Ip",
+			remoteAddr: "192.168.1.10:54321",
+			headers:    map[string]string{"X-Real-Ip": "10.1.2.3"},
+			expected:   "10.1.2.3",
+			expectOK:   true,
+		},
+		{
+			name:       "unparseable header falls back to remote address",
+			header:     "X-Real-Ip",
+			remoteAddr: "192.168.1.10:54321",
+			headers:    map[string]string{"X-Real-Ip": "unknown"},
+			expected:   "192.168.1.10",
+			expectOK:   true,
+		},
+		{
+			name:       "unparseable remote address",
+			remoteAddr: "pipe",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+
+			ip, ok := clientIP(req, tt.header)
+			if ok != tt.expectOK {
+				t.Fatalf("clientIP() ok = %v, expected %v", ok, tt.expectOK)
+			}
+			if ok && ip.String() != tt.expected {
+				t.Errorf("clientIP() = %s, expected %s", ip, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+)
+
+// assertionValidators turns each assertion into a validator for its
+// operation. Config validation rejects assertions that don't compile, so any
+// left are skipped.
+func assertionValidators(assertions []Assertion) map[string][]func(body any) error {
+	validators := make(map[string][]func(body any) error)
+	for _, assertion := range assertions {
+		program, err := compileAssertion(assertion.Expression)
+		if err != nil {
+			continue
+		}
+		validators[assertion.OperationID] = append(validators[assertion.OperationID], func(body any) error {
+			return evalAssertion(program, assertion.Expression, body)
+		})
+	}
+	return validators
+}
+
+// compileAssertion compiles a CEL expression over response.body, which must
+// evaluate to a bool. sum(list) adds up a list of numbers, and approx(a, b)
+// compares two numbers with a tolerance for floating-point rounding.
+func compileAssertion(expression string) (cel.Program, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("response", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Function("sum",
+			cel.Overload("sum_list", []*cel.Type{cel.ListType(cel.DynType)}, cel.DoubleType,
+				cel.UnaryBinding(sumList))),
+		cel.Function("approx",
+			cel.Overload("approx_dyn_dyn", []*cel.Type{cel.DynType, cel.DynType}, cel.BoolType,
+				cel.BinaryBinding(approxEqual))),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("expression must evaluate to a bool, not %s", ast.OutputType())
+	}
+	return env.Program(ast)
+}
+
+func sumList(value ref.Val) ref.Val {
+	list, ok := value.(traits.Lister)
+	if !ok {
+		return types.NewErr("sum needs a list, got %s", value.Type())
+	}
+
+	var sum float64
+	for it := list.Iterator(); it.HasNext() == types.True; {
+		item := it.Next()
+		n, ok := celNumber(item)
+		if !ok {
+			return types.NewErr("sum needs a list of numbers, got %s", item.Type())
+		}
+		sum += n
+	}
+	return types.Double(sum)
+}
+
+// approxEqual reports whether two numbers differ by no more than a relative
+// tolerance of 1e-9, so that sums such as 0.1 + 0.2 equal 0.3.
+func approxEqual(a, b ref.Val) ref.Val {
+	x, ok := celNumber(a)
+	if !ok {
+		return types.NewErr("approx needs numbers, got %s", a.Type())
+	}
+	y, ok := celNumber(b)
+	if !ok {
+		return types.NewErr("approx needs numbers, got %s", b.Type())
+	}
+	scale := math.Max(1, math.Max(math.Abs(x), math.Abs(y)))
+	return types.Bool(math.Abs(x-y) <= 1e-9*scale)
+}
+
+func celNumber(value ref.Val) (float64, bool) {
+	switch n := value.(type) {
+	case types.Double:
+		return float64(n), true
+	case types.Int:
+		return float64(n), true
+	case types.Uint:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func evalAssertion(program cel.Program, expression string, body any) error {
+	out, _, err := program.Eval(map[string]any{
+		"response": map[string]any{"body": celValue(body)},
+	})
+	if err != nil {
+		return fmt.Errorf("assertion %q failed: %w", expression, err)
+	}
+	if out != types.True {
+		return fmt.Errorf("assertion %q is false", expression)
+	}
+	return nil
+}
+
+// celValue converts the json.Numbers in a decoded body, which CEL doesn't
+// understand, to doubles. Converting whole numbers to ints instead would make
+// the same expression fail to evaluate whenever a field such as 30.5 comes
+// back as 30, since CEL has no arithmetic between ints and doubles.
+func celValue(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		converted := make(map[string]any, len(v))
+		for key, inner := range v {
+			converted[key] = celValue(inner)
+		}
+		return converted
+	case []any:
+		converted := make([]any, len(v))
+		for i, inner := range v {
+			converted[i] = celValue(inner)
+		}
+		return converted
+	default:
+		return value
+	}
+}
@@ -0,0 +1,125 @@
+package specgate
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidatingProxy_Assertions(t *testing.T) {
+	tests := []struct {
+		name           string
+		assertion      Assertion
+		path           string
+		body           string
+		expectedStatus int
+		expectedLog    string
+	}{
+		{
+			name:           "holds",
+			assertion:      Assertion{OperationID: "getUser", Expression: `response.body.name.startsWith("user-")`},
+			path:           "/users/1",
+			body:           `{"id": 1, "name": "user-1"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "is false",
+			assertion:      Assertion{OperationID: "getUser", Expression: `response.body.name.startsWith("user-")`},
+			path:           "/users/1",
+			body:           `{"id": 1, "name": "Ann"}`,
+			expectedStatus: http.StatusInternalServerError,
+			expectedLog:    `custom validation of getUser failed: assertion \"response.body.name.startsWith(\\\"user-\\\")\" is false`,
+		},
+		{
+			name:           "integer comparison",
+			assertion:      Assertion{OperationID: "getUser", Expression: "response.body.id > 0"},
+			path:           "/users/1",
+			body:           `{"id": 0, "name": "Ann"}`,
+			expectedStatus: http.StatusInternalServerError,
+			expectedLog:    "is false",
+		},
+		{
+			name:           "arithmetic on a whole number",
+			assertion:      Assertion{OperationID: "getUser", Expression: "response.body.id + 1.0 > 0.0"},
+			path:           "/users/1",
+			body:           `{"id": 30, "name": "Ann"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "sum holds",
+			assertion:      Assertion{OperationID: "listUsers", Expression: "sum(response.body.map(u, u.balance)) == 4.5"},
+			path:           "/users",
+			body:           `[{"balance": 1.5}, {"balance": 3}]`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "sum is false",
+			assertion:      Assertion{OperationID: "listUsers", Expression: "sum(response.body.map(u, u.balance)) == 4.5"},
+			path:           "/users",
+			body:           `[{"balance": 1.5}, {"balance": 2}]`,
+			expectedStatus: http.StatusInternalServerError,
+			expectedLog:    "is false",
+		},
+		{
+			name:           "approx sum holds",
+			assertion:      Assertion{OperationID: "listUsers", Expression: "approx(sum(response.body.map(u, u.balance)), 0.3)"},
+			path:           "/users",
+			body:           `[{"balance": 0.1}, {"balance": 0.2}]`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "approx sum is false",
+			assertion:      Assertion{OperationID: "listUsers", Expression: "approx(sum(response.body.map(u, u.balance)), 0.3)"},
+			path:           "/users",
+			body:           `[{"balance": 0.1}, {"balance": 0.3}]`,
+			expectedStatus: http.StatusInternalServerError,
+			expectedLog:    "is false",
+		},
+		{
+			name:           "missing field",
+			assertion:      Assertion{OperationID: "listUsers", Expression: "sum(response.body.map(u, u.balance)) == 4.5"},
+			path:           "/users",
+			body:           `[{"balance": 1.5}, {}]`,
+			expectedStatus: http.StatusInternalServerError,
+			expectedLog:    "no such key: balance",
+		},
+		{
+			name:           "other operation",
+			assertion:      Assertion{OperationID: "listUsers", Expression: "false"},
+			path:           "/users/1",
+			body:           `{"id": 1, "name": "Ann"}`,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			cfg.Assertions = []Assertion{tt.assertion}
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d, logs: %s", rec.Code, tt.expectedStatus, logs.String())
+			}
+			if !strings.Contains(logs.String(), tt.expectedLog) {
+				t.Errorf("logs should contain %q, got: %s", tt.expectedLog, logs.String())
+			}
+		})
+	}
+}
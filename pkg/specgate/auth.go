@@ -0,0 +1,98 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// proxyAuth protects SpecGate itself with a static bearer token and/or HTTP
+// Basic credentials. Either is accepted when both are configured.
+type proxyAuth struct {
+	token    string
+	user     string
+	password string
+}
+
+// newProxyAuth returns nil when no credentials are configured. ${VAR}
+// references are expanded so secrets can be kept out of the config file.
+func newProxyAuth(cfg *Config) *proxyAuth {
+	if cfg.AuthToken == "" && cfg.AuthBasic == "" {
+		return nil
+	}
+
+	auth := &proxyAuth{token: os.ExpandEnv(cfg.AuthToken)}
+	if cfg.AuthBasic != "" {
+		auth.user, auth.password, _ = strings.Cut(os.ExpandEnv(cfg.AuthBasic), ":")
+	}
+	return auth
+}
+
+func (a *proxyAuth) authorized(r *http.Request) bool {
+	if a.token != "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && secureEqual(token, a.token) {
+			return true
+		}
+	}
+	if a.user != "" {
+		if user, password, ok := r.BasicAuth(); ok && secureEqual(user, a.user) && secureEqual(password, a.password) {
+			return true
+		}
+	}
+	return false
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// authenticate rejects requests without valid credentials with a 401.
+func (vp *ValidatingProxy) authenticate(w http.ResponseWriter, r *http.Request) bool {
+	if vp.access.auth.authorized(r) {
+		return true
+	}
+
+	challenge := `Bearer realm="SpecGate"`
+	if vp.access.auth.user != "" {
+		challenge = `Basic realm="SpecGate", charset="UTF-8"`
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+
+	vp.log(r.Context()).Warn("Rejected unauthenticated request", "method", r.Method, "path", r.URL.Path, "remote_addr", r.RemoteAddr)
+	vp.writeError(w, ErrorDetails{
+		Status: http.StatusUnauthorized,
+		Title:  "Authentication required",
+		Detail: "Valid credentials for SpecGate are required",
+		Method: r.Method,
+		Path:   r.URL.Path,
+	})
+	return false
+}
+
+// stripCredentials removes SpecGate's credentials before the request is
+// forwarded. It runs after request validation, so that they still satisfy a
+// security requirement the operation declares for the same header.
+func (vp *ValidatingProxy) stripCredentials(r *http.Request) {
+	if vp.access.auth != nil {
+		r.Header.Del("Authorization")
+	}
+}
@@ -0,0 +1,151 @@
+package specgate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidatingProxy_Auth(t *testing.T) {
+	tests := []struct {
+		name             string
+		token            string
+		basic            string
+		setAuth          func(r *http.Request)
+		expectedStatus   int
+		expectedUpstream bool
+	}{
+		{
+			name:             "valid token",
+			token:            "s3cret",
+			setAuth:          func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cret") },
+			expectedStatus:   http.StatusOK,
+			expectedUpstream: true,
+		},
+		{
+			name:           "wrong token",
+			token:          "s3cret",
+			setAuth:        func(r *http.Request) { r.Header.Set("Authorization", "Bearer guess") },
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "missing credentials",
+			token:          "s3cret",
+			setAuth:        func(r *http.Request) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:             "valid basic credentials",
+			basic:            "admin:hunter2",
+			setAuth:          func(r *http.Request) { r.SetBasicAuth("admin", "hunter2") },
+			expectedStatus:   http.StatusOK,
+			expectedUpstream: true,
+		},
+		{
+			name:           "wrong basic password",
+			basic:          "admin:hunter2",
+			setAuth:        func(r *http.Request) { r.SetBasicAuth("admin", "hunter3") },
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:             "basic credentials when both are configured",
+			token:            "s3cret",
+			basic:            "admin:hunter2",
+			setAuth:          func(r *http.Request) { r.SetBasicAuth("admin", "hunter2") },
+			expectedStatus:   http.StatusOK,
+			expectedUpstream: true,
+		},
+		{
+			name:             "token from environment",
+			token:            "${SPECGATE_TEST_AUTH_TOKEN}",
+			setAuth:          func(r *http.Request) { r.Header.Set("Authorization", "Bearer from-env") },
+			expectedStatus:   http.StatusOK,
+			expectedUpstream: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SPECGATE_TEST_AUTH_TOKEN", "from-env")
+
+			var upstreamAuth string
+			upstreamCalled := false
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				upstreamCalled = true
+				upstreamAuth = r.Header.Get("Authorization")
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id": 1, "name": "test"}`))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.AuthToken = tt.token
+			cfg.AuthBasic = tt.basic
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			tt.setAuth(req)
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d", rec.Code, tt.expectedStatus)
+			}
+			if upstreamCalled != tt.expectedUpstream {
+				t.Errorf("upstream called = %v, expected %v", upstreamCalled, tt.expectedUpstream)
+			}
+			if upstreamAuth != "" {
+				t.Errorf("upstream received Authorization %q, expected SpecGate's credentials to be removed", upstreamAuth)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("expected a WWW-Authenticate challenge")
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_AuthWithValidateSecurity(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		authorization  string
+		expectedStatus int
+	}{
+		{name: "token satisfies bearer requirement", path: "/users", authorization: "Bearer s3cret", expectedStatus: http.StatusOK},
+		{name: "wrong token", path: "/users", authorization: "Bearer guess", expectedStatus: http.StatusUnauthorized},
+		{name: "missing api key", path: "/reports", authorization: "Bearer s3cret", expectedStatus: http.StatusUnauthorized},
+		{name: "no security required", path: "/health", authorization: "Bearer s3cret", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var upstreamAuth string
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				upstreamAuth = r.Header.Get("Authorization")
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			cfg.AuthToken = "s3cret"
+			cfg.ValidateRequests = true
+			cfg.ValidateSecurity = true
+			vp := newTestProxyWithConfig(t, testSecuritySpec, cfg)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", tt.authorization)
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d, body: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+			if upstreamAuth != "" {
+				t.Errorf("upstream received Authorization %q, expected SpecGate's credentials to be removed", upstreamAuth)
+			}
+		})
+	}
+}
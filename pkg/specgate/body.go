@@ -0,0 +1,170 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+// kin-openapi validates bodies with decoders from its global registry, so
+// SpecGate asks it to skip them and validates them here instead, with the
+// proxy's own decoders. The errors match kin-openapi's so that they're
+// described and redacted the same way.
+
+// validateRequestBody validates body against the request body the input's
+// operation documents.
+func (vp *ValidatingProxy) validateRequestBody(input *openapi3filter.RequestValidationInput, body []byte) error {
+	requestBody := input.Route.Operation.RequestBody
+	if requestBody == nil || requestBody.Value == nil {
+		return nil
+	}
+	if len(body) == 0 {
+		if requestBody.Value.Required {
+			return &openapi3filter.RequestError{Input: input, RequestBody: requestBody.Value, Err: openapi3filter.ErrInvalidRequired}
+		}
+		return nil
+	}
+	if len(requestBody.Value.Content) == 0 {
+		return nil
+	}
+
+	inputMIME := input.Request.Header.Get("Content-Type")
+	content := requestBody.Value.Content.Get(inputMIME)
+	if content == nil {
+		return &openapi3filter.RequestError{
+			Input:       input,
+			RequestBody: requestBody.Value,
+			Reason:      fmt.Sprintf("header Content-Type has unexpected value %q", inputMIME),
+		}
+	}
+	if content.Schema == nil || content.Schema.Value == nil {
+		return nil
+	}
+
+	value, err := decodeBody(vp.decoders.forRequest, body, input.Request.Header, content)
+	if err != nil {
+		return &openapi3filter.RequestError{Input: input, RequestBody: requestBody.Value, Reason: "failed to decode request body", Err: err}
+	}
+
+	opts := append([]openapi3.SchemaValidationOption{openapi3.VisitAsRequest()}, input.Options.SchemaValidationOptions...)
+	if input.Route.Spec.IsOpenAPI31OrLater() {
+		opts = append(opts, openapi3.EnableJSONSchema2020())
+	}
+	if err := content.Schema.Value.VisitJSON(value, opts...); err != nil {
+		return &openapi3filter.RequestError{
+			Input:       input,
+			RequestBody: requestBody.Value,
+			Reason:      "doesn't match schema" + schemaIdentifier(content.Schema),
+			Err:         err,
+		}
+	}
+	return nil
+}
+
+// validateResponseBody validates body against the response the input's
+// operation documents for its status.
+func (vp *ValidatingProxy) validateResponseBody(input *openapi3filter.ResponseValidationInput, body []byte) error {
+	switch input.Status {
+	case http.StatusNotModified, http.StatusPermanentRedirect, http.StatusTemporaryRedirect, http.StatusMovedPermanently:
+		return nil
+	}
+	route := input.RequestValidationInput.Route
+	if route.Operation.Responses == nil {
+		return nil
+	}
+	response := route.Operation.Responses.Status(input.Status)
+	if response == nil {
+		response = route.Operation.Responses.Default()
+	}
+	if response == nil || response.Value == nil || len(response.Value.Content) == 0 {
+		return nil
+	}
+
+	inputMIME := input.Header.Get("Content-Type")
+	content := response.Value.Content.Get(inputMIME)
+	if content == nil {
+		return &openapi3filter.ResponseError{
+			Input:  input,
+			Reason: fmt.Sprintf("response header Content-Type has unexpected value: %q", inputMIME),
+		}
+	}
+	if content.Schema == nil || content.Schema.Value == nil {
+		return nil
+	}
+
+	value, err := decodeBody(vp.decoders.lookup, body, input.Header, content)
+	if err != nil {
+		return &openapi3filter.ResponseError{Input: input, Reason: "failed to decode response body", Err: err}
+	}
+
+	opts := append([]openapi3.SchemaValidationOption{openapi3.VisitAsResponse()}, input.Options.SchemaValidationOptions...)
+	if route.Spec.IsOpenAPI31OrLater() {
+		opts = append(opts, openapi3.EnableJSONSchema2020())
+	}
+	if err := content.Schema.Value.VisitJSON(value, opts...); err != nil {
+		return &openapi3filter.ResponseError{
+			Input:  input,
+			Reason: "response body doesn't match schema" + schemaIdentifier(content.Schema),
+			Err:    err,
+		}
+	}
+	return nil
+}
+
+// decodeBody decodes body with the decoder lookup returns for its media type.
+// Like kin-openapi, it reads binary string schemas as raw bytes whatever
+// their media type.
+func decodeBody(lookup func(string) openapi3filter.BodyDecoder, body []byte, header http.Header, content *openapi3.MediaType) (any, error) {
+	contentType := header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, _, _ = strings.Cut(contentType, ";")
+	}
+	decoder := lookup(mediaType)
+	if schema := content.Schema.Value; schema.Type.Is("string") && schema.Format == "binary" {
+		decoder = openapi3filter.FileBodyDecoder
+	}
+	if decoder == nil {
+		return nil, &openapi3filter.ParseError{
+			Kind:   openapi3filter.KindUnsupportedFormat,
+			Reason: fmt.Sprintf("unsupported content type %q", mediaType),
+		}
+	}
+	encFn := func(name string) *openapi3.Encoding { return content.Encoding[name] }
+	return decoder(bytes.NewReader(body), header, content.Schema, encFn)
+}
+
+// schemaIdentifier names schema in validation errors the way kin-openapi does.
+func schemaIdentifier(schema *openapi3.SchemaRef) string {
+	id := strings.TrimSpace(schema.Ref)
+	if id == "" && schema.Value != nil {
+		id = strings.TrimSpace(schema.Value.Title)
+	}
+	if id == "" {
+		return ""
+	}
+	return " " + id
+}
@@ -0,0 +1,143 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// RunCheck runs the modes that inspect the configuration or spec instead of
+// proxying, writing their output to w. It returns the exit code, and whether
+// any such mode was requested.
+func RunCheck(w io.Writer, cfg *Config) (int, bool) {
+	switch {
+	case cfg.PrintConfig:
+		if err := cfg.Print(w); err != nil {
+			fmt.Fprintln(w, "Failed to print config:", err)
+			return 1, true
+		}
+		return 0, true
+	case cfg.LintSpec:
+		return runLint(w, cfg), true
+	case cfg.CheckExamples:
+		return runCheckExamples(w, cfg), true
+	case cfg.Check:
+		return runPreflight(w, cfg), true
+	default:
+		return 0, false
+	}
+}
+
+// runLint loads and checks the spec, returning the process exit code.
+func runLint(w io.Writer, cfg *Config) int {
+	spec, err := loadSpecOnly(w, cfg)
+	if err == nil {
+		err = lintSpec(spec)
+	}
+	if err != nil {
+		fmt.Fprintf(w, "%s: %v\n", cfg.Spec, err)
+		return 1
+	}
+
+	fmt.Fprintf(w, "%s: OK\n", cfg.Spec)
+	return 0
+}
+
+// runCheckExamples reports every example in the spec that doesn't match its
+// schema, and returns the process exit code.
+func runCheckExamples(w io.Writer, cfg *Config) int {
+	spec, err := loadSpecOnly(w, cfg)
+	if err != nil {
+		fmt.Fprintf(w, "%s: %v\n", cfg.Spec, err)
+		return 1
+	}
+
+	problems := checkExamples(spec, schemaOptions(cfg))
+	for _, problem := range problems {
+		fmt.Fprintf(w, "%s: %s\n", cfg.Spec, problem)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(w, "%s: %d example(s) don't match their schema\n", cfg.Spec, len(problems))
+		return 1
+	}
+
+	fmt.Fprintf(w, "%s: OK\n", cfg.Spec)
+	return 0
+}
+
+// loadSpecOnly loads the spec for the modes that check it without proxying,
+// logging to w.
+func loadSpecOnly(w io.Writer, cfg *Config) (*openapi3.T, error) {
+	loader, err := newSpecLoader(cfg, slog.New(slog.NewTextHandler(w, nil)))
+	if err != nil {
+		return nil, err
+	}
+	spec, _, err := loader.load(cfg.Spec)
+	return spec, err
+}
+
+// RemoteSpecMismatches returns an error for each remote spec in cfg.Spec
+// that none of the upstreams serves, as a guard against validating against
+// the wrong API.
+func RemoteSpecMismatches(cfg *Config) []error {
+	var errs []error
+	for _, spec := range splitSpecPaths(cfg.Spec) {
+		if !isRemoteSpec(spec) {
+			continue
+		}
+		if err := validateSpecUpstreamMatch(spec, cfg.Upstream); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// validateSpecUpstreamMatch checks that a remote spec is served by one of
+// the upstreams.
+func validateSpecUpstreamMatch(specURL, upstream string) error {
+	specParsed, err := url.Parse(specURL)
+	if err != nil {
+		return fmt.Errorf("invalid spec URL: %s", specURL)
+	}
+
+	routes, err := parseUpstreams(upstream)
+	if err != nil {
+		return fmt.Errorf("invalid upstream URL: %s", upstream)
+	}
+
+	var origins []string
+	for _, route := range routes {
+		for _, replica := range route.replicas {
+			if specParsed.Host == replica.target.Host && specParsed.Scheme == replica.target.Scheme {
+				return nil
+			}
+			origins = append(origins, replica.target.Scheme+"://"+replica.target.Host)
+		}
+	}
+
+	return fmt.Errorf("spec URL (%s) does not match upstream URL (%s)",
+		specParsed.Scheme+"://"+specParsed.Host,
+		strings.Join(origins, ", "))
+}
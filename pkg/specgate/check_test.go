@@ -0,0 +1,170 @@
+package specgate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestValidateSpecUpstreamMatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		specURL     string
+		upstreamURL string
+		expectError bool
+	}{
+		{
+			name:        "matching URLs",
+			specURL:     "https://api.example.com/spec.yaml",
+			upstreamURL: "https://api.example.com",
+			expectError: false,
+		},
+		{
+			name:        "matching URLs with different paths",
+			specURL:     "https://api.example.com/v1/spec.yaml",
+			upstreamURL: "https://api.example.com/v2",
+			expectError: false,
+		},
+		{
+			name:        "different schemes",
+			specURL:     "http://api.example.com/spec.yaml",
+			upstreamURL: "https://api.example.com",
+			expectError: true,
+		},
+		{
+			name:        "different hosts",
+			specURL:     "https://api1.example.com/spec.yaml",
+			upstreamURL: "https://api2.example.com",
+			expectError: true,
+		},
+		{
+			name:        "different ports",
+			specURL:     "https://api.example.com:8080/spec.yaml",
+			upstreamURL: "https://api.example.com:9090",
+			expectError: true,
+		},
+		{
+			name:        "spec served by one of several upstreams",
+			specURL:     "https://orders.example.com/openapi.yaml",
+			upstreamURL: "/users=https://users.example.com,/orders=https://orders.example.com",
+			expectError: false,
+		},
+		{
+			name:        "spec served by none of several upstreams",
+			specURL:     "https://api.example.com/openapi.yaml",
+			upstreamURL: "/users=https://users.example.com,/orders=https://orders.example.com",
+			expectError: true,
+		},
+		{
+			name:        "invalid spec URL",
+			specURL:     "://invalid-url",
+			upstreamURL: "https://api.example.com",
+			expectError: true,
+		},
+		{
+			name:        "invalid upstream URL",
+			specURL:     "https://api.example.com/spec.yaml",
+			upstreamURL: "://invalid-url",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSpecUpstreamMatch(tt.specURL, tt.upstreamURL)
+			if (err != nil) != tt.expectError {
+				t.Errorf("validateSpecUpstreamMatch() error = %v, expectError %v", err, tt.expectError)
+			}
+		})
+	}
+}
+
+func TestRunLint(t *testing.T) {
+	tests := []struct {
+		name         string
+		spec         string
+		expectedCode int
+		expectedOut  string
+	}{
+		{
+			name:         "valid spec",
+			spec:         testSpec,
+			expectedCode: 0,
+			expectedOut:  "OK",
+		},
+		{
+			name: "invalid schema type",
+			spec: `openapi: 3.0.0
+info:
+  title: Test API
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      responses:
+        "200":
+          description: Users
+          content:
+            application/json:
+              schema:
+                type: strin
+`,
+			expectedCode: 1,
+			expectedOut:  "spec has problems",
+		},
+		{
+			name:         "unloadable spec",
+			spec:         "openapi: [not valid",
+			expectedCode: 1,
+			expectedOut:  "failed to load spec",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cfg := DefaultConfig()
+			cfg.Spec = writeTestSpec(t, tt.spec)
+			code := runLint(&out, cfg)
+
+			if code != tt.expectedCode {
+				t.Errorf("runLint() = %d, expected %d", code, tt.expectedCode)
+			}
+			if !strings.Contains(out.String(), tt.expectedOut) {
+				t.Errorf("runLint() output %q should contain %q", out.String(), tt.expectedOut)
+			}
+		})
+	}
+}
+
+func TestRunCheck(t *testing.T) {
+	tests := []struct {
+		name         string
+		modify       func(*Config)
+		expectedRan  bool
+		expectedCode int
+		expectedOut  string
+	}{
+		{name: "proxy mode", modify: func(*Config) {}, expectedRan: false},
+		{name: "print config", modify: func(c *Config) { c.PrintConfig = true }, expectedRan: true, expectedOut: "mode: warn"},
+		{name: "lint spec", modify: func(c *Config) { c.LintSpec = true }, expectedRan: true, expectedOut: "OK"},
+		{name: "check examples", modify: func(c *Config) { c.CheckExamples = true }, expectedRan: true, expectedOut: "OK"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cfg := DefaultConfig()
+			cfg.Spec = writeTestSpec(t, testSpec)
+			tt.modify(cfg)
+
+			code, ran := RunCheck(&out, cfg)
+			if ran != tt.expectedRan || code != tt.expectedCode {
+				t.Errorf("RunCheck() = %d, %v, expected %d, %v", code, ran, tt.expectedCode, tt.expectedRan)
+			}
+			if !strings.Contains(out.String(), tt.expectedOut) {
+				t.Errorf("RunCheck() output %q should contain %q", out.String(), tt.expectedOut)
+			}
+		})
+	}
+}
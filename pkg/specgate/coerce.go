@@ -0,0 +1,140 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// coerceQuery rewrites the query parameters of a request that passed
+// validation into the canonical form of their schema types, so ?limit=010
+// or ?active=TRUE reach the upstream as ?limit=10 and ?active=true, and adds
+// documented defaults for parameters the client left out. Only form-style
+// parameters with scalar or array-of-scalar schemas are touched.
+func coerceQuery(r *http.Request, route *routers.Route) {
+	query := r.URL.Query()
+	changed := false
+	for _, param := range queryParameters(route) {
+		if param.Schema == nil || param.Schema.Value == nil || (param.Style != "" && param.Style != openapi3.SerializationForm) {
+			continue
+		}
+		schema := param.Schema.Value
+
+		values, ok := query[param.Name]
+		if !ok {
+			if schema.Default != nil && !schema.Type.Is(openapi3.TypeArray) && !schema.Type.Is(openapi3.TypeObject) {
+				query.Set(param.Name, canonicalScalar(schema, fmt.Sprint(schema.Default)))
+				changed = true
+			}
+			continue
+		}
+
+		itemSchema, explode := schema, true
+		if schema.Type.Is(openapi3.TypeArray) {
+			if schema.Items == nil || schema.Items.Value == nil {
+				continue
+			}
+			itemSchema = schema.Items.Value
+			explode = param.Explode == nil || *param.Explode
+		}
+
+		for i, value := range values {
+			canonical := canonicalQueryValue(itemSchema, value, explode)
+			changed = changed || canonical != value
+			values[i] = canonical
+		}
+	}
+
+	if changed {
+		r.URL.RawQuery = query.Encode()
+	}
+}
+
+// queryParameters returns the query parameters of an operation, including
+// those declared on its path unless the operation overrides them.
+func queryParameters(route *routers.Route) []*openapi3.Parameter {
+	var params []*openapi3.Parameter
+	if route.Operation != nil {
+		for _, ref := range route.Operation.Parameters {
+			if ref.Value != nil && ref.Value.In == openapi3.ParameterInQuery {
+				params = append(params, ref.Value)
+			}
+		}
+	}
+	if route.PathItem != nil {
+		for _, ref := range route.PathItem.Parameters {
+			if ref.Value != nil && ref.Value.In == openapi3.ParameterInQuery && !hasParameter(params, ref.Value.Name) {
+				params = append(params, ref.Value)
+			}
+		}
+	}
+	return params
+}
+
+func hasParameter(params []*openapi3.Parameter, name string) bool {
+	for _, param := range params {
+		if param.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalQueryValue canonicalizes one query value, which holds a
+// comma-separated list when an array parameter isn't exploded.
+func canonicalQueryValue(schema *openapi3.Schema, value string, explode bool) string {
+	if explode {
+		return canonicalScalar(schema, value)
+	}
+	items := strings.Split(value, ",")
+	for i, item := range items {
+		items[i] = canonicalScalar(schema, item)
+	}
+	return strings.Join(items, ",")
+}
+
+// canonicalScalar returns value written the canonical way for schema's type,
+// or value itself when it can't be parsed as that type.
+func canonicalScalar(schema *openapi3.Schema, value string) string {
+	switch {
+	case schema.Type.Is(openapi3.TypeInteger):
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return strconv.FormatInt(n, 10)
+		}
+		if f, err := strconv.ParseFloat(value, 64); err == nil && f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			return strconv.FormatInt(int64(f), 10)
+		}
+	case schema.Type.Is(openapi3.TypeNumber):
+		if f, err := strconv.ParseFloat(value, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	case schema.Type.Is(openapi3.TypeBoolean):
+		if b, err := strconv.ParseBool(value); err == nil {
+			return strconv.FormatBool(b)
+		}
+	}
+	return value
+}
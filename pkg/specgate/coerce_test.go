@@ -0,0 +1,149 @@
+package specgate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const coerceSpec = `openapi: 3.0.3
+info:
+  title: Search API
+  version: 1.0.0
+paths:
+  /items:
+    parameters:
+      - name: page
+        in: query
+        schema:
+          type: integer
+          default: 1
+    get:
+      operationId: listItems
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+        - name: min_price
+          in: query
+          schema:
+            type: number
+        - name: active
+          in: query
+          schema:
+            type: boolean
+        - name: ids
+          in: query
+          schema:
+            type: array
+            items:
+              type: integer
+        - name: tags
+          in: query
+          explode: false
+          schema:
+            type: array
+            items:
+              type: boolean
+        - name: q
+          in: query
+          schema:
+            type: string
+      responses:
+        "200":
+          description: Items
+`
+
+func TestValidatingProxy_CoerceQuery(t *testing.T) {
+	tests := []struct {
+		name           string
+		coerce         bool
+		query          string
+		expectedQuery  string
+		expectedStatus int
+	}{
+		{
+			name:           "integer",
+			coerce:         true,
+			query:          "limit=010&page=2",
+			expectedQuery:  "limit=10&page=2",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "number and boolean",
+			coerce:         true,
+			query:          "min_price=1e2&active=TRUE&page=1",
+			expectedQuery:  "active=true&min_price=100&page=1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "default added",
+			coerce:         true,
+			query:          "q=Hello+World",
+			expectedQuery:  "page=1&q=Hello+World",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "exploded array",
+			coerce:         true,
+			query:          "ids=01&ids=2&page=1",
+			expectedQuery:  "ids=1&ids=2&page=1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "delimited array",
+			coerce:         true,
+			query:          "tags=1,False&page=1",
+			expectedQuery:  "page=1&tags=true%2Cfalse",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "canonical query is forwarded as sent",
+			coerce:         true,
+			query:          "q=a%20b&page=3",
+			expectedQuery:  "q=a%20b&page=3",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid query is rejected",
+			coerce:         true,
+			query:          "limit=abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "disabled",
+			query:          "limit=010",
+			expectedQuery:  "limit=010",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var receivedQuery string
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				receivedQuery = r.URL.RawQuery
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			cfg.ValidateRequests = true
+			cfg.CoerceQuery = tt.coerce
+			vp := newTestProxyWithConfig(t, coerceSpec, cfg)
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items?"+tt.query, nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, expected %d: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+			if receivedQuery != tt.expectedQuery {
+				t.Errorf("upstream received query %q, expected %q", receivedQuery, tt.expectedQuery)
+			}
+		})
+	}
+}
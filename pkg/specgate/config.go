@@ -0,0 +1,523 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Spec                          string         `yaml:"spec"`
+	Upstream                      string         `yaml:"upstream"`
+	UpstreamCA                    string         `yaml:"upstream_ca"`
+	UpstreamInsecure              bool           `yaml:"upstream_insecure"`
+	UpstreamClientCert            string         `yaml:"upstream_client_cert"`
+	UpstreamClientKey             string         `yaml:"upstream_client_key"`
+	UpstreamDialTimeout           time.Duration  `yaml:"upstream_dial_timeout"`
+	UpstreamTLSHandshakeTimeout   time.Duration  `yaml:"upstream_tls_handshake_timeout"`
+	UpstreamResponseHeaderTimeout time.Duration  `yaml:"upstream_response_header_timeout"`
+	UpstreamMaxIdleConns          int            `yaml:"upstream_max_idle_conns"`
+	UpstreamIdleTimeout           time.Duration  `yaml:"upstream_idle_timeout"`
+	UpstreamFailTimeout           time.Duration  `yaml:"upstream_fail_timeout"`
+	Retries                       int            `yaml:"retries"`
+	RetryBackoff                  time.Duration  `yaml:"retry_backoff"`
+	Port                          string         `yaml:"port"`
+	TLSCert                       string         `yaml:"tls_cert"`
+	TLSKey                        string         `yaml:"tls_key"`
+	ProxyProtocol                 bool           `yaml:"proxy_protocol"`
+	Mode                          string         `yaml:"mode"`
+	SampleRate                    float64        `yaml:"sample_rate"`
+	SkipStatus                    StatusSet      `yaml:"skip_status"`
+	SkipContentTypes              FieldList      `yaml:"skip_content_types"`
+	UnknownLength                 string         `yaml:"unknown_length"`
+	Router                        string         `yaml:"router"`
+	ModeOverrides                 []ModeOverride `yaml:"mode_overrides"`
+	MockFallback                  bool           `yaml:"mock_fallback"`
+	IncludePaths                  FieldList      `yaml:"include_paths"`
+	ExcludePaths                  FieldList      `yaml:"exclude_paths"`
+	IgnorePaths                   FieldList      `yaml:"ignore_paths"`
+	NoAdditionalProperties        bool           `yaml:"no_additional_properties"`
+	StrictFormats                 bool           `yaml:"strict_formats"`
+	ExactIntegers                 bool           `yaml:"exact_integers"`
+	StringFormats                 FormatPatterns `yaml:"string_formats"`
+	Assertions                    []Assertion    `yaml:"assertions"`
+	Faults                        []Fault        `yaml:"faults"`
+	FailureStatus                 int            `yaml:"failure_status"`
+	StripErrorHeaders             FieldList      `yaml:"strip_error_headers"`
+	ErrorUpstreamBody             ByteSize       `yaml:"error_upstream_body"`
+	ErrorFormat                   string         `yaml:"error_format"`
+	ErrorTemplate                 string         `yaml:"error_template"`
+	ExposeErrors                  bool           `yaml:"expose_errors"`
+	ValidationHeader              bool           `yaml:"validation_header"`
+	CompressResponses             bool           `yaml:"compress_responses"`
+	ValidateAccept                bool           `yaml:"validate_accept"`
+	AssumeJSON                    bool           `yaml:"assume_json"`
+	LintSpec                      bool           `yaml:"-"`
+	CheckExamples                 bool           `yaml:"-"`
+	Check                         bool           `yaml:"-"`
+	PrintConfig                   bool           `yaml:"-"`
+	Watch                         bool           `yaml:"watch"`
+	SpecRefreshInterval           time.Duration  `yaml:"spec_refresh_interval"`
+	KeepSpecBasePath              bool           `yaml:"keep_spec_base_path"`
+	ServerVariables               ServerVars     `yaml:"server_variables"`
+	XForwardedHeaders             bool           `yaml:"x_forwarded_headers"`
+	ForwardedHeader               bool           `yaml:"forwarded_header"`
+	CORSAllowedOrigins            FieldList      `yaml:"cors_allowed_origins"`
+	CORSAllowedMethods            FieldList      `yaml:"cors_allowed_methods"`
+	CORSAllowedHeaders            FieldList      `yaml:"cors_allowed_headers"`
+	AuthToken                     string         `yaml:"auth_token"`
+	AuthBasic                     string         `yaml:"auth_basic"`
+	AllowCIDRs                    FieldList      `yaml:"allow_cidrs"`
+	ClientIPHeader                string         `yaml:"client_ip_header"`
+	RateLimit                     RateLimit      `yaml:"rate_limit"`
+	RateLimitHeader               string         `yaml:"rate_limit_header"`
+	ValidateRequests              bool           `yaml:"validate_requests"`
+	ValidateSecurity              bool           `yaml:"validate_security"`
+	CoerceQuery                   bool           `yaml:"coerce_query"`
+	MaxBodySize                   ByteSize       `yaml:"max_body_size"`
+	LogFormat                     string         `yaml:"log_format"`
+	LogLevel                      string         `yaml:"log_level"`
+	Logger                        *slog.Logger   `yaml:"-"`
+	FailuresOut                   string         `yaml:"failures_out"`
+	WebhookURL                    string         `yaml:"webhook_url"`
+	WebhookHeaders                HeaderMap      `yaml:"webhook_headers"`
+	WebhookInterval               time.Duration  `yaml:"webhook_interval"`
+	SlackWebhook                  string         `yaml:"slack_webhook"`
+	SlackBatchWindow              time.Duration  `yaml:"slack_batch_window"`
+	SlowThreshold                 time.Duration  `yaml:"slow_threshold"`
+	OtelEndpoint                  string         `yaml:"otel_endpoint"`
+	Record                        string         `yaml:"record"`
+	Replay                        string         `yaml:"replay"`
+	ReplayFallback                string         `yaml:"replay_fallback"`
+	RedactFields                  FieldList      `yaml:"redact_fields"`
+	MetricsPort                   string         `yaml:"metrics_port"`
+	ReadTimeout                   time.Duration  `yaml:"read_timeout"`
+	ReadHeaderTimeout             time.Duration  `yaml:"read_header_timeout"`
+	WriteTimeout                  time.Duration  `yaml:"write_timeout"`
+	IdleTimeout                   time.Duration  `yaml:"idle_timeout"`
+	ShutdownTimeout               time.Duration  `yaml:"shutdown_timeout"`
+	FailOnError                   bool           `yaml:"fail_on_error"`
+}
+
+// ModeOverride applies Mode to every operation whose path template matches
+// Pattern. Overrides are evaluated in order and the first match wins.
+type ModeOverride struct {
+	Pattern string `yaml:"pattern"`
+	Mode    string `yaml:"mode"`
+}
+
+// Assertion is a CEL expression that must hold for every response of the
+// operation with OperationID that passes schema validation.
+type Assertion struct {
+	OperationID string `yaml:"operation_id"`
+	Expression  string `yaml:"expression"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Spec:                        "openapi.yaml",
+		Upstream:                    "http://localhost:3000",
+		UpstreamDialTimeout:         30 * time.Second,
+		UpstreamTLSHandshakeTimeout: 10 * time.Second,
+		UpstreamMaxIdleConns:        100,
+		UpstreamIdleTimeout:         90 * time.Second,
+		UpstreamFailTimeout:         10 * time.Second,
+		RetryBackoff:                100 * time.Millisecond,
+		ReplayFallback:              string(ReplayFallbackNotFound),
+		Port:                        "8080",
+		Mode:                        string(ModeWarn),
+		SampleRate:                  1,
+		SkipContentTypes:            FieldList{"text/event-stream"},
+		UnknownLength:               string(UnknownLengthBuffer),
+		Router:                      string(RouterGorillaMux),
+		FailureStatus:               http.StatusInternalServerError,
+		StripErrorHeaders:           FieldList{"ETag", "Last-Modified", "Cache-Control", "Expires", "Age", "Vary", "Content-Range", "Accept-Ranges", "Content-Disposition", "Content-Language", "Content-Location", "Digest"},
+		ErrorFormat:                 string(ErrorFormatJSON),
+		XForwardedHeaders:           true,
+		CORSAllowedMethods:          FieldList{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"},
+		MaxBodySize:                 defaultMaxBodySize,
+		LogFormat:                   string(LogFormatColor),
+		LogLevel:                    "info",
+		WebhookInterval:             time.Minute,
+		SlackBatchWindow:            10 * time.Second,
+		ReadTimeout:                 30 * time.Second,
+		ReadHeaderTimeout:           10 * time.Second,
+		WriteTimeout:                30 * time.Second,
+		IdleTimeout:                 120 * time.Second,
+		ShutdownTimeout:             15 * time.Second,
+	}
+}
+
+// LoadConfig reads a YAML (or JSON) config file on top of DefaultConfig. The
+// result isn't validated, since flags and environment variables may still
+// complete it, so call Validate once they've been applied.
+func LoadConfig(path string) (*Config, error) {
+	file, err := os.Open(path) // #nosec G304 -- path is supplied by the operator
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config: %w", err)
+	}
+	defer file.Close()
+
+	cfg := DefaultConfig()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Print writes c as a YAML config file, with credentials masked.
+func (c *Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.redacted()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func (c *Config) redacted() *Config {
+	redacted := *c
+	if redacted.AuthToken != "" {
+		redacted.AuthToken = redactedValue
+	}
+	if user, _, ok := strings.Cut(redacted.AuthBasic, ":"); ok {
+		redacted.AuthBasic = user + ":" + redactedValue
+	}
+	if redacted.SlackWebhook != "" {
+		redacted.SlackWebhook = redactedValue
+	}
+	if len(redacted.WebhookHeaders) > 0 {
+		redacted.WebhookHeaders = make(HeaderMap, len(c.WebhookHeaders))
+		for name := range c.WebhookHeaders {
+			redacted.WebhookHeaders[name] = redactedValue
+		}
+	}
+	return &redacted
+}
+
+func (c *Config) Validate() error {
+	for _, validate := range []func() error{
+		c.validateSpec,
+		c.validateUpstream,
+		c.validateListeners,
+		c.validateValidation,
+		c.validateSchemas,
+		c.validateReporting,
+	} {
+		if err := validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateSpec() error {
+	specs := splitSpecPaths(c.Spec)
+	if len(specs) == 0 {
+		return fmt.Errorf("%q must not be empty", "spec")
+	}
+
+	if c.Watch && slices.ContainsFunc(specs, isRemoteSpec) {
+		return fmt.Errorf("%q is only supported for local spec files", "watch")
+	}
+
+	if c.SpecRefreshInterval < 0 {
+		return fmt.Errorf("%q must not be negative", "spec_refresh_interval")
+	}
+	if c.SpecRefreshInterval > 0 && (len(specs) > 1 || !isRemoteSpec(specs[0])) {
+		return fmt.Errorf("%q is only supported for a single remote spec", "spec_refresh_interval")
+	}
+
+	if len(c.ServerVariables) > 0 && !c.KeepSpecBasePath {
+		return fmt.Errorf("%q requires %q", "server_variables", "keep_spec_base_path")
+	}
+
+	return nil
+}
+
+func (c *Config) validateUpstream() error {
+	if _, err := parseUpstreams(c.Upstream); err != nil {
+		return fmt.Errorf("%q: %w", "upstream", err)
+	}
+
+	if (c.UpstreamClientCert == "") != (c.UpstreamClientKey == "") {
+		return fmt.Errorf("%q and %q must be set together", "upstream_client_cert", "upstream_client_key")
+	}
+
+	timeouts := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"upstream_dial_timeout", c.UpstreamDialTimeout},
+		{"upstream_tls_handshake_timeout", c.UpstreamTLSHandshakeTimeout},
+		{"upstream_response_header_timeout", c.UpstreamResponseHeaderTimeout},
+		{"upstream_idle_timeout", c.UpstreamIdleTimeout},
+		{"upstream_fail_timeout", c.UpstreamFailTimeout},
+		{"retry_backoff", c.RetryBackoff},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value < 0 {
+			return fmt.Errorf("%q must not be negative", timeout.key)
+		}
+	}
+
+	if c.UpstreamMaxIdleConns < 0 {
+		return fmt.Errorf("%q must not be negative", "upstream_max_idle_conns")
+	}
+	if c.Retries < 0 {
+		return fmt.Errorf("%q must not be negative", "retries")
+	}
+
+	if _, err := parseReplayFallback(c.ReplayFallback); err != nil {
+		return fmt.Errorf("%q: %w", "replay_fallback", err)
+	}
+
+	return nil
+}
+
+func (c *Config) validateListeners() error {
+	if path, ok := strings.CutPrefix(c.Port, unixPrefix); ok {
+		if path == "" {
+			return fmt.Errorf("%q must name a socket file after %q", "port", unixPrefix)
+		}
+	} else if err := validatePort("port", c.Port); err != nil {
+		return err
+	}
+
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		return fmt.Errorf("%q and %q must be set together", "tls_cert", "tls_key")
+	}
+
+	if len(c.CORSAllowedOrigins) > 0 && len(c.CORSAllowedMethods) == 0 {
+		return fmt.Errorf("%q must not be empty when %q is set", "cors_allowed_methods", "cors_allowed_origins")
+	}
+
+	if c.AuthBasic != "" {
+		if user, _, ok := strings.Cut(c.AuthBasic, ":"); !ok || user == "" {
+			return fmt.Errorf("%q must be \"user:password\"", "auth_basic")
+		}
+	}
+
+	for i, cidr := range c.AllowCIDRs {
+		if _, err := netip.ParsePrefix(cidr); err != nil {
+			return fmt.Errorf("%q: %w", fmt.Sprintf("allow_cidrs[%d]", i), err)
+		}
+	}
+
+	if c.MetricsPort != "" {
+		if err := validatePort("metrics_port", c.MetricsPort); err != nil {
+			return err
+		}
+		if c.MetricsPort == c.Port {
+			return fmt.Errorf("%q must differ from %q", "metrics_port", "port")
+		}
+	}
+
+	timeouts := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"read_timeout", c.ReadTimeout},
+		{"read_header_timeout", c.ReadHeaderTimeout},
+		{"write_timeout", c.WriteTimeout},
+		{"idle_timeout", c.IdleTimeout},
+		{"shutdown_timeout", c.ShutdownTimeout},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value < 0 {
+			return fmt.Errorf("%q must not be negative", timeout.key)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) validateValidation() error {
+	if _, err := parseMode(c.Mode); err != nil {
+		return fmt.Errorf("%q: %w", "mode", err)
+	}
+
+	for i, override := range c.ModeOverrides {
+		if err := validatePathPattern(override.Pattern); err != nil {
+			return fmt.Errorf("%q: %w", fmt.Sprintf("mode_overrides[%d].pattern", i), err)
+		}
+		if _, err := parseMode(override.Mode); err != nil {
+			return fmt.Errorf("%q: %w", fmt.Sprintf("mode_overrides[%d].mode", i), err)
+		}
+	}
+
+	patterns := []struct {
+		key  string
+		list FieldList
+	}{
+		{"include_paths", c.IncludePaths},
+		{"exclude_paths", c.ExcludePaths},
+		{"ignore_paths", c.IgnorePaths},
+	}
+	for _, p := range patterns {
+		for i, pattern := range p.list {
+			if err := validatePathPattern(pattern); err != nil {
+				return fmt.Errorf("%q: %w", fmt.Sprintf("%s[%d]", p.key, i), err)
+			}
+		}
+	}
+
+	for i, fault := range c.Faults {
+		if err := fault.validate(); err != nil {
+			return fmt.Errorf("%q: %w", fmt.Sprintf("faults[%d]", i), err)
+		}
+	}
+
+	if _, err := parseRouterBackend(c.Router); err != nil {
+		return fmt.Errorf("%q: %w", "router", err)
+	}
+
+	for i, contentType := range c.SkipContentTypes {
+		if _, _, err := mime.ParseMediaType(contentType); err != nil || !strings.Contains(contentType, "/") {
+			return fmt.Errorf("%q must be a media type such as text/event-stream, got %q", fmt.Sprintf("skip_content_types[%d]", i), contentType)
+		}
+	}
+
+	if _, err := parseUnknownLength(c.UnknownLength); err != nil {
+		return fmt.Errorf("%q: %w", "unknown_length", err)
+	}
+
+	if c.SampleRate < 0 || c.SampleRate > 1 {
+		return fmt.Errorf("%q must be between 0 and 1, got %g", "sample_rate", c.SampleRate)
+	}
+
+	if c.ValidateSecurity && !c.ValidateRequests {
+		return fmt.Errorf("%q requires %q", "validate_security", "validate_requests")
+	}
+	if c.CoerceQuery && !c.ValidateRequests {
+		return fmt.Errorf("%q requires %q", "coerce_query", "validate_requests")
+	}
+
+	if c.MaxBodySize <= 0 {
+		return fmt.Errorf("%q must be greater than zero", "max_body_size")
+	}
+
+	return nil
+}
+
+func (c *Config) validateSchemas() error {
+	for _, name := range componentNames(c.StringFormats) {
+		if _, err := regexp.Compile(c.StringFormats[name]); err != nil {
+			return fmt.Errorf("%q: %w", "string_formats."+name, err)
+		}
+	}
+
+	for i, assertion := range c.Assertions {
+		if assertion.OperationID == "" {
+			return fmt.Errorf("%q is required", fmt.Sprintf("assertions[%d].operation_id", i))
+		}
+		if _, err := compileAssertion(assertion.Expression); err != nil {
+			return fmt.Errorf("%q: %w", fmt.Sprintf("assertions[%d].expression", i), err)
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateReporting() error {
+	if c.FailureStatus < 400 || c.FailureStatus > 599 {
+		return fmt.Errorf("%q must be a 4xx or 5xx status code, got %d", "failure_status", c.FailureStatus)
+	}
+
+	if _, err := parseErrorFormat(c.ErrorFormat); err != nil {
+		return fmt.Errorf("%q: %w", "error_format", err)
+	}
+
+	if _, err := parseLogFormat(c.LogFormat); err != nil {
+		return fmt.Errorf("%q: %w", "log_format", err)
+	}
+	if _, err := parseLogLevel(c.LogLevel); err != nil {
+		return fmt.Errorf("%q: %w", "log_level", err)
+	}
+
+	if c.WebhookURL != "" {
+		if err := validateHTTPURL("webhook_url", c.WebhookURL); err != nil {
+			return err
+		}
+	}
+	if c.WebhookInterval < 0 {
+		return fmt.Errorf("%q must not be negative", "webhook_interval")
+	}
+
+	if c.SlackWebhook != "" {
+		if err := validateHTTPURL("slack_webhook", c.SlackWebhook); err != nil {
+			return err
+		}
+	}
+	if c.SlackBatchWindow <= 0 {
+		return fmt.Errorf("%q must be greater than zero", "slack_batch_window")
+	}
+
+	if c.OtelEndpoint != "" {
+		if err := validateHTTPURL("otel_endpoint", c.OtelEndpoint); err != nil {
+			return err
+		}
+	}
+
+	if c.SlowThreshold < 0 {
+		return fmt.Errorf("%q must not be negative", "slow_threshold")
+	}
+
+	for i, field := range c.RedactFields {
+		if err := validateFieldPattern(field); err != nil {
+			return fmt.Errorf("%q: %w", fmt.Sprintf("redact_fields[%d]", i), err)
+		}
+	}
+
+	return nil
+}
+
+func validateHTTPURL(key, value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q must be an http or https URL, got %q", key, value)
+	}
+	return nil
+}
+
+func validatePort(key, value string) error {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("%q must be a number between 1 and 65535, got %q", key, value)
+	}
+	return nil
+}
@@ -0,0 +1,441 @@
+package specgate
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func withDefaults(modify func(*Config)) *Config {
+	cfg := DefaultConfig()
+	modify(cfg)
+	return cfg
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expected      *Config
+		expectedError string
+	}{
+		{
+			name: "full yaml config",
+			content: `spec: api.yaml
+upstream: https://api.example.com
+port: "9090"
+mode: strict
+mode_overrides:
+  - pattern: /legacy/**
+    mode: warn
+validate_requests: true
+max_body_size: 25MB
+log_format: json
+shutdown_timeout: 5s
+skip_status: [404, 5xx]
+`,
+			expected: withDefaults(func(c *Config) {
+				c.Spec = "api.yaml"
+				c.Upstream = "https://api.example.com"
+				c.Port = "9090"
+				c.Mode = "strict"
+				c.ModeOverrides = []ModeOverride{
+					{Pattern: "/legacy/**", Mode: "warn"},
+				}
+				c.ValidateRequests = true
+				c.MaxBodySize = 25 * 1024 * 1024
+				c.LogFormat = "json"
+				c.ShutdownTimeout = 5 * time.Second
+				c.SkipStatus = StatusSet{{min: 404, max: 404}, {min: 500, max: 599}}
+			}),
+		},
+		{
+			name:    "json config",
+			content: `{"spec": "api.json", "mode": "report"}`,
+			expected: withDefaults(func(c *Config) {
+				c.Spec = "api.json"
+				c.Mode = "report"
+			}),
+		},
+		{
+			name:     "empty file uses defaults",
+			content:  "",
+			expected: DefaultConfig(),
+		},
+		{
+			name:          "unknown key",
+			content:       "spec: api.yaml\nmdoe: strict\n",
+			expectedError: "field mdoe not found",
+		},
+		{
+			name:          "invalid mode",
+			content:       "mode: loud\n",
+			expectedError: `"mode"`,
+		},
+		{
+			name:          "invalid upstream",
+			content:       "upstream: localhost\n",
+			expectedError: `"upstream"`,
+		},
+		{
+			name:          "invalid port",
+			content:       "port: \"99999\"\n",
+			expectedError: `"port"`,
+		},
+		{
+			name:     "unix socket port",
+			content:  "port: unix:/run/specgate.sock\n",
+			expected: withDefaults(func(c *Config) { c.Port = "unix:/run/specgate.sock" }),
+		},
+		{
+			name:          "unix socket port without a path",
+			content:       "port: \"unix:\"\n",
+			expectedError: `"port" must name a socket file`,
+		},
+		{
+			name:          "invalid override mode",
+			content:       "mode_overrides:\n  - pattern: /users/*\n    mode: loud\n",
+			expectedError: `"mode_overrides[0].mode"`,
+		},
+		{
+			name:          "invalid override pattern",
+			content:       "mode_overrides:\n  - pattern: users\n    mode: strict\n",
+			expectedError: `"mode_overrides[0].pattern"`,
+		},
+		{
+			name:          "invalid max body size",
+			content:       "max_body_size: lots\n",
+			expectedError: "invalid size",
+		},
+		{
+			name:          "zero max body size",
+			content:       "max_body_size: 0\n",
+			expectedError: `"max_body_size"`,
+		},
+		{
+			name:          "watch with remote spec",
+			content:       "spec: https://api.example.com/openapi.yaml\nwatch: true\n",
+			expectedError: `"watch"`,
+		},
+		{
+			name:          "refresh with local spec",
+			content:       "spec: api.yaml\nspec_refresh_interval: 5m\n",
+			expectedError: `"spec_refresh_interval"`,
+		},
+		{
+			name:          "watch with a remote spec among several",
+			content:       "spec: api.yaml, https://api.example.com/openapi.yaml\nwatch: true\n",
+			expectedError: `"watch"`,
+		},
+		{
+			name:          "refresh with several specs",
+			content:       "spec: https://api.example.com/users.yaml, https://api.example.com/orders.yaml\nspec_refresh_interval: 5m\n",
+			expectedError: `"spec_refresh_interval"`,
+		},
+		{
+			name:          "negative refresh interval",
+			content:       "spec: https://api.example.com/openapi.yaml\nspec_refresh_interval: -1m\n",
+			expectedError: `"spec_refresh_interval"`,
+		},
+		{
+			name:          "server variables without keeping the base path",
+			content:       "server_variables:\n  region: eu\n",
+			expectedError: `"server_variables"`,
+		},
+		{
+			name:     "server variables",
+			content:  "keep_spec_base_path: true\nserver_variables:\n  version: v2\n",
+			expected: withDefaults(func(c *Config) { c.KeepSpecBasePath = true; c.ServerVariables = ServerVars{"version": "v2"} }),
+		},
+		{
+			name:          "security validation without request validation",
+			content:       "validate_security: true\n",
+			expectedError: `"validate_security"`,
+		},
+		{
+			name:          "query coercion without request validation",
+			content:       "coerce_query: true\n",
+			expectedError: `"coerce_query"`,
+		},
+		{
+			name:          "invalid redact field",
+			content:       "redact_fields: [user..ssn]\n",
+			expectedError: `"redact_fields[0]"`,
+		},
+		{
+			name:          "tls cert without key",
+			content:       "tls_cert: cert.pem\n",
+			expectedError: `"tls_cert"`,
+		},
+		{
+			name:          "upstream client key without cert",
+			content:       "upstream_client_key: key.pem\n",
+			expectedError: `"upstream_client_cert"`,
+		},
+		{
+			name:          "negative upstream timeout",
+			content:       "upstream_response_header_timeout: -1s\n",
+			expectedError: `"upstream_response_header_timeout"`,
+		},
+		{
+			name:          "invalid include path",
+			content:       "include_paths: [/users, users/*]\n",
+			expectedError: `"include_paths[1]"`,
+		},
+		{
+			name:          "invalid exclude path",
+			content:       "exclude_paths: [\"/users/[\"]\n",
+			expectedError: `"exclude_paths[0]"`,
+		},
+		{
+			name:     "ignore paths",
+			content:  "ignore_paths: [/healthz, /favicon.ico]\n",
+			expected: withDefaults(func(c *Config) { c.IgnorePaths = FieldList{"/healthz", "/favicon.ico"} }),
+		},
+		{
+			name:          "invalid ignore path",
+			content:       "ignore_paths: [healthz]\n",
+			expectedError: `"ignore_paths[0]"`,
+		},
+		{
+			name:     "string formats",
+			content:  "string_formats:\n  phone-e164: '^\\+[1-9][0-9]{1,14}$'\n",
+			expected: withDefaults(func(c *Config) { c.StringFormats = FormatPatterns{"phone-e164": `^\+[1-9][0-9]{1,14}$`} }),
+		},
+		{
+			name:          "invalid string format pattern",
+			content:       "string_formats:\n  sku: '[A-Z'\n",
+			expectedError: `"string_formats.sku"`,
+		},
+		{
+			name:    "assertions",
+			content: "assertions:\n  - operation_id: listUsers\n    expression: size(response.body) <= 100\n",
+			expected: withDefaults(func(c *Config) {
+				c.Assertions = []Assertion{{OperationID: "listUsers", Expression: "size(response.body) <= 100"}}
+			}),
+		},
+		{
+			name:          "assertion without operation id",
+			content:       "assertions:\n  - expression: 'true'\n",
+			expectedError: `"assertions[0].operation_id" is required`,
+		},
+		{
+			name:          "assertion that doesn't compile",
+			content:       "assertions:\n  - operation_id: listUsers\n    expression: size(response.body) <=\n",
+			expectedError: `"assertions[0].expression"`,
+		},
+		{
+			name:          "assertion that isn't a bool",
+			content:       "assertions:\n  - operation_id: listUsers\n    expression: \"'yes'\"\n",
+			expectedError: "must evaluate to a bool",
+		},
+		{
+			name:          "invalid fault",
+			content:       "faults:\n  - pattern: /users/*\n    probability: 0.5\n",
+			expectedError: `"faults[0]"`,
+		},
+		{
+			name:          "invalid replay fallback",
+			content:       "replay_fallback: mock\n",
+			expectedError: `"replay_fallback"`,
+		},
+		{
+			name:          "negative slow threshold",
+			content:       "slow_threshold: -1s\n",
+			expectedError: `"slow_threshold"`,
+		},
+		{
+			name:          "invalid otel endpoint",
+			content:       "otel_endpoint: localhost:4318\n",
+			expectedError: `"otel_endpoint"`,
+		},
+		{
+			name:          "invalid webhook url",
+			content:       "webhook_url: hooks.example.com\n",
+			expectedError: `"webhook_url"`,
+		},
+		{
+			name:          "zero slack batch window",
+			content:       "slack_batch_window: 0s\n",
+			expectedError: `"slack_batch_window"`,
+		},
+		{
+			name:          "cors without methods",
+			content:       "cors_allowed_origins: [\"http://localhost:5173\"]\ncors_allowed_methods: []\n",
+			expectedError: `"cors_allowed_methods"`,
+		},
+		{
+			name:          "basic auth without password",
+			content:       "auth_basic: admin\n",
+			expectedError: `"auth_basic"`,
+		},
+		{
+			name:          "invalid allowed cidr",
+			content:       "allow_cidrs: [10.0.0.0/8, 192.168.1.300/24]\n",
+			expectedError: `"allow_cidrs[1]"`,
+		},
+		{
+			name:          "invalid rate limit",
+			content:       "rate_limit: fast\n",
+			expectedError: "invalid rate limit",
+		},
+		{
+			name:          "zero rate limit burst",
+			content:       "rate_limit: \"10:0\"\n",
+			expectedError: "invalid rate limit burst",
+		},
+		{
+			name:     "rate limit with burst",
+			content:  "rate_limit: \"2.5:5\"\n",
+			expected: withDefaults(func(c *Config) { c.RateLimit = RateLimit{Rate: 2.5, Burst: 5} }),
+		},
+		{
+			name:          "invalid router",
+			content:       "router: chi\n",
+			expectedError: `"router"`,
+		},
+		{
+			name:          "sample rate above one",
+			content:       "sample_rate: 1.5\n",
+			expectedError: `"sample_rate"`,
+		},
+		{
+			name:          "negative sample rate",
+			content:       "sample_rate: -0.1\n",
+			expectedError: `"sample_rate"`,
+		},
+		{
+			name:     "skip status as string",
+			content:  "skip_status: \"401-403, 404\"\n",
+			expected: withDefaults(func(c *Config) { c.SkipStatus = StatusSet{{min: 401, max: 403}, {min: 404, max: 404}} }),
+		},
+		{
+			name:          "invalid skip status",
+			content:       "skip_status: [9xx]\n",
+			expectedError: "invalid status class",
+		},
+		{
+			name:          "invalid skip content type",
+			content:       "skip_content_types: [text/event-stream, events]\n",
+			expectedError: `"skip_content_types[1]"`,
+		},
+		{
+			name:          "invalid unknown length handling",
+			content:       "unknown_length: stream\n",
+			expectedError: `"unknown_length"`,
+		},
+		{
+			name:          "non-error failure status",
+			content:       "failure_status: 200\n",
+			expectedError: `"failure_status"`,
+		},
+		{
+			name:     "strip error headers",
+			content:  "strip_error_headers: [ETag, Cache-Control, Surrogate-Key]\n",
+			expected: withDefaults(func(c *Config) { c.StripErrorHeaders = FieldList{"ETag", "Cache-Control", "Surrogate-Key"} }),
+		},
+		{
+			name:          "invalid error format",
+			content:       "error_format: html\n",
+			expectedError: `"error_format"`,
+		},
+		{
+			name:          "invalid log format",
+			content:       "log_format: xml\n",
+			expectedError: `"log_format"`,
+		},
+		{
+			name:          "invalid log level",
+			content:       "log_level: verbose\n",
+			expectedError: `"log_level"`,
+		},
+		{
+			name:          "negative write timeout",
+			content:       "write_timeout: -1s\n",
+			expectedError: `"write_timeout"`,
+		},
+		{
+			name:          "negative shutdown timeout",
+			content:       "shutdown_timeout: -1s\n",
+			expectedError: `"shutdown_timeout"`,
+		},
+		{
+			name:          "empty spec",
+			content:       "spec: \"\"\n",
+			expectedError: `"spec"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "specgate.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			cfg, err := LoadConfig(path)
+			if err == nil {
+				err = cfg.Validate()
+			}
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("LoadConfig() and Validate() error = %v, expected error containing %q", err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(cfg, tt.expected) {
+				t.Errorf("LoadConfig() = %+v, expected %+v", cfg, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConfig_Print(t *testing.T) {
+	cfg := withDefaults(func(c *Config) {
+		c.Spec = "api.yaml"
+		c.Mode = "strict"
+		c.ModeOverrides = []ModeOverride{{Pattern: "/legacy/**", Mode: "warn"}}
+		c.SkipStatus = StatusSet{{min: 500, max: 599}}
+		c.MaxBodySize = 25 * 1024 * 1024
+		c.RateLimit = RateLimit{Rate: 2.5, Burst: 5}
+		c.AuthToken = "s3cret"
+		c.AuthBasic = "admin:hunter2"
+		c.WebhookHeaders = HeaderMap{"Authorization": "Bearer s3cret"}
+	})
+
+	var out strings.Builder
+	if err := cfg.Print(&out); err != nil {
+		t.Fatalf("Print() unexpected error: %v", err)
+	}
+	printed := out.String()
+
+	for _, secret := range []string{"s3cret", "hunter2"} {
+		if strings.Contains(printed, secret) {
+			t.Errorf("Print() output contains secret %q:\n%s", secret, printed)
+		}
+	}
+
+	// The output is itself a valid config file
+	path := filepath.Join(t.TempDir(), "specgate.yaml")
+	if err := os.WriteFile(path, []byte(printed), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() of printed config unexpected error: %v\n%s", err, printed)
+	}
+
+	var reprinted strings.Builder
+	if err := loaded.Print(&reprinted); err != nil {
+		t.Fatalf("Print() unexpected error: %v", err)
+	}
+	if reprinted.String() != printed {
+		t.Errorf("printed config changed after loading:\n%s\nexpected:\n%s", reprinted.String(), printed)
+	}
+}
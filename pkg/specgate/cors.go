@@ -0,0 +1,98 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const corsMaxAge = 10 * 60 // seconds browsers may cache a preflight response
+
+// corsExposedHeaders are the headers SpecGate adds that browser scripts may read.
+const corsExposedHeaders = requestIDHeader + ", " + validationHeader + ", " + validationErrorHeader
+
+// corsPolicy answers CORS preflight requests itself and adds CORS headers to
+// proxied responses, for APIs called from browsers on another origin.
+type corsPolicy struct {
+	origins []string
+	methods string
+	headers string
+}
+
+func newCORSPolicy(cfg *Config) *corsPolicy {
+	if len(cfg.CORSAllowedOrigins) == 0 {
+		return nil
+	}
+	return &corsPolicy{
+		origins: cfg.CORSAllowedOrigins,
+		methods: strings.Join(cfg.CORSAllowedMethods, ", "),
+		headers: strings.Join(cfg.CORSAllowedHeaders, ", "),
+	}
+}
+
+func (p *corsPolicy) allowOrigin(origin string) bool {
+	return slices.Contains(p.origins, "*") || slices.Contains(p.origins, origin)
+}
+
+// handle sets the CORS headers for r and reports whether r was a preflight
+// request, which is answered without reaching the upstream.
+func (p *corsPolicy) handle(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	preflight := r.Method == http.MethodOptions && origin != "" && r.Header.Get("Access-Control-Request-Method") != ""
+
+	w.Header().Add("Vary", "Origin")
+	if origin == "" || !p.allowOrigin(origin) {
+		if preflight {
+			w.WriteHeader(http.StatusForbidden)
+		}
+		return preflight
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if !preflight {
+		w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+		return false
+	}
+
+	headers := p.headers
+	if headers == "" {
+		// Without a configured list, allow whatever the client asks for
+		headers = r.Header.Get("Access-Control-Request-Headers")
+	}
+	w.Header().Set("Access-Control-Allow-Methods", p.methods)
+	if headers != "" {
+		w.Header().Set("Access-Control-Allow-Headers", headers)
+	}
+	w.Header().Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// stripUpstreamCORSHeaders removes the upstream's own CORS headers, which
+// would otherwise be sent alongside SpecGate's.
+func stripUpstreamCORSHeaders(header http.Header) {
+	for name := range header {
+		if strings.HasPrefix(name, "Access-Control-") {
+			header.Del(name)
+		}
+	}
+}
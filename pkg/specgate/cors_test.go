@@ -0,0 +1,145 @@
+package specgate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidatingProxy_CORS(t *testing.T) {
+	tests := []struct {
+		name                 string
+		origins              FieldList
+		allowedHeaders       FieldList
+		method               string
+		origin               string
+		requestHeaders       string
+		expectedStatus       int
+		expectedAllowOrigin  string
+		expectedAllowHeaders string
+		expectUpstream       bool
+	}{
+		{
+			name:                 "preflight from allowed origin",
+			origins:              FieldList{"http://localhost:5173"},
+			method:               http.MethodOptions,
+			origin:               "http://localhost:5173",
+			requestHeaders:       "Content-Type, X-Custom",
+			expectedStatus:       http.StatusNoContent,
+			expectedAllowOrigin:  "http://localhost:5173",
+			expectedAllowHeaders: "Content-Type, X-Custom",
+		},
+		{
+			name:                 "preflight with configured headers",
+			origins:              FieldList{"*"},
+			allowedHeaders:       FieldList{"Content-Type", "Authorization"},
+			method:               http.MethodOptions,
+			origin:               "https://app.example.com",
+			requestHeaders:       "X-Custom",
+			expectedStatus:       http.StatusNoContent,
+			expectedAllowOrigin:  "https://app.example.com",
+			expectedAllowHeaders: "Content-Type, Authorization",
+		},
+		{
+			name:           "preflight from other origin",
+			origins:        FieldList{"http://localhost:5173"},
+			method:         http.MethodOptions,
+			origin:         "https://evil.example.com",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:                "request from allowed origin",
+			origins:             FieldList{"http://localhost:5173"},
+			method:              http.MethodGet,
+			origin:              "http://localhost:5173",
+			expectedStatus:      http.StatusOK,
+			expectedAllowOrigin: "http://localhost:5173",
+			expectUpstream:      true,
+		},
+		{
+			name:           "request from other origin",
+			origins:        FieldList{"http://localhost:5173"},
+			method:         http.MethodGet,
+			origin:         "https://evil.example.com",
+			expectedStatus: http.StatusOK,
+			expectUpstream: true,
+		},
+		{
+			name:           "same-origin request",
+			origins:        FieldList{"http://localhost:5173"},
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectUpstream: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstreamCalled := false
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				upstreamCalled = true
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id": 1, "name": "test"}`))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.CORSAllowedOrigins = tt.origins
+			cfg.CORSAllowedHeaders = tt.allowedHeaders
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			req := httptest.NewRequest(tt.method, "/users/1", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.method == http.MethodOptions {
+				req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			}
+			if tt.requestHeaders != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.requestHeaders)
+			}
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d", rec.Code, tt.expectedStatus)
+			}
+			if upstreamCalled != tt.expectUpstream {
+				t.Errorf("upstream called = %v, expected %v", upstreamCalled, tt.expectUpstream)
+			}
+
+			allowOrigin := rec.Header().Values("Access-Control-Allow-Origin")
+			switch {
+			case tt.expectedAllowOrigin == "" && len(allowOrigin) != 0:
+				t.Errorf("Access-Control-Allow-Origin = %q, expected none", allowOrigin)
+			case tt.expectedAllowOrigin != "" && (len(allowOrigin) != 1 || allowOrigin[0] != tt.expectedAllowOrigin):
+				t.Errorf("Access-Control-Allow-Origin = %q, expected only %q", allowOrigin, tt.expectedAllowOrigin)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Headers"); got != tt.expectedAllowHeaders {
+				t.Errorf("Access-Control-Allow-Headers = %q, expected %q", got, tt.expectedAllowHeaders)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_CORSDisabled(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": 1, "name": "test"}`))
+	}))
+	defer upstream.Close()
+
+	vp := newTestProxy(t, testSpec, upstream.URL, "warn")
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("Origin", "http://localhost:5173")
+	rec := httptest.NewRecorder()
+	vp.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, expected the upstream's header to pass through", got)
+	}
+}
@@ -0,0 +1,327 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+var (
+	defaultBodyDecodersMu sync.RWMutex
+	defaultBodyDecoders   = map[string]openapi3filter.BodyDecoder{
+		"application/json": openapi3filter.JSONBodyDecoder,
+		"application/xml":  XMLBodyDecoder,
+		"text/xml":         XMLBodyDecoder,
+	}
+)
+
+// RegisterBodyDecoder enables validation of bodies with the given media type
+// by proxies created afterwards, using decoder to turn the body into the value
+// the schema is checked against.
+func RegisterBodyDecoder(contentType string, decoder openapi3filter.BodyDecoder) {
+	defaultBodyDecodersMu.Lock()
+	defer defaultBodyDecodersMu.Unlock()
+
+	defaultBodyDecoders[contentType] = decoder
+}
+
+// RegisterBodyDecoder enables validation of bodies with the given media type
+// by vp, using decoder to turn the body into the value the schema is checked
+// against.
+func (vp *ValidatingProxy) RegisterBodyDecoder(contentType string, decoder openapi3filter.BodyDecoder) {
+	vp.decoders.register(contentType, decoder)
+}
+
+// bodyDecoders looks up a proxy's decoders by media type. Bodies are decoded
+// through it rather than kin-openapi's registry, which is global and can't be
+// changed safely while other requests are validated.
+type bodyDecoders struct {
+	mu       sync.RWMutex
+	decoders map[string]openapi3filter.BodyDecoder
+}
+
+func newBodyDecoders() *bodyDecoders {
+	defaultBodyDecodersMu.RLock()
+	defer defaultBodyDecodersMu.RUnlock()
+
+	return &bodyDecoders{decoders: maps.Clone(defaultBodyDecoders)}
+}
+
+func (d *bodyDecoders) register(contentType string, decoder openapi3filter.BodyDecoder) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.decoders[contentType] = decoder
+}
+
+func (d *bodyDecoders) has(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return d.lookup(mediaType) != nil
+}
+
+// lookup returns the decoder for mediaType, falling back to the one for its
+// structured syntax suffix so that vendor types such as
+// application/vnd.myapi.v1+json decode as JSON.
+func (d *bodyDecoders) lookup(mediaType string) openapi3filter.BodyDecoder {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if decoder, ok := d.decoders[mediaType]; ok {
+		return decoder
+	}
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
+		return d.decoders["application/json"]
+	case strings.HasSuffix(mediaType, "+xml"):
+		return d.decoders["application/xml"]
+	default:
+		return nil
+	}
+}
+
+// forRequest returns the decoder for a request body of mediaType. Requests
+// are also decoded as forms, and as any type kin-openapi has a built-in
+// decoder for, such as multipart forms.
+func (d *bodyDecoders) forRequest(mediaType string) openapi3filter.BodyDecoder {
+	if decoder := d.lookup(mediaType); decoder != nil {
+		return decoder
+	}
+	if decoder, ok := requestBodyDecoders[mediaType]; ok {
+		return decoder
+	}
+	return openapi3filter.RegisteredBodyDecoder(mediaType)
+}
+
+type xmlNode struct {
+	name     string
+	attrs    map[string]string
+	children []*xmlNode
+	text     strings.Builder
+}
+
+// XMLBodyDecoder converts an XML document into the JSON-like structure the
+// schema validator expects, using the schema (and its xml metadata) to decide
+// which elements are arrays and how scalar values should be typed.
+func XMLBodyDecoder(body io.Reader, _ http.Header, schema *openapi3.SchemaRef, _ openapi3filter.EncodingFn) (any, error) {
+	root, err := parseXML(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if schema == nil || schema.Value == nil {
+		return xmlToValue(root, nil), nil
+	}
+	if schema.Value.Type.Is("array") {
+		return xmlArray(root.children, schema.Value.Items), nil
+	}
+	return xmlToValue(root, schema.Value), nil
+}
+
+func parseXML(body io.Reader) (*xmlNode, error) {
+	decoder := xml.NewDecoder(body)
+
+	var stack []*xmlNode
+	var root *xmlNode
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid XML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, attr := range t.Attr {
+				node.attrs[attr.Name.Local] = attr.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("invalid XML: no root element")
+	}
+	return root, nil
+}
+
+func xmlName(name string, schema *openapi3.SchemaRef) string {
+	if schema != nil && schema.Value != nil && schema.Value.XML != nil && schema.Value.XML.Name != "" {
+		return schema.Value.XML.Name
+	}
+	return name
+}
+
+func xmlToValue(node *xmlNode, schema *openapi3.Schema) any {
+	if schema == nil {
+		if len(node.children) == 0 && len(node.attrs) == 0 {
+			return strings.TrimSpace(node.text.String())
+		}
+		return xmlObject(node, nil)
+	}
+
+	switch {
+	case schema.Type.Is("object") || len(schema.Properties) > 0:
+		return xmlObject(node, schema)
+	case schema.Type.Is("array"):
+		return xmlArray(node.children, schema.Items)
+	default:
+		return xmlScalar(strings.TrimSpace(node.text.String()), schema)
+	}
+}
+
+func xmlObject(node *xmlNode, schema *openapi3.Schema) map[string]any {
+	object := make(map[string]any)
+	consumed := make(map[*xmlNode]bool)
+
+	if schema != nil {
+		for name, property := range schema.Properties {
+			if value, ok := xmlProperty(node, name, property, consumed); ok {
+				object[name] = value
+			}
+		}
+	}
+
+	// Undocumented attributes and elements are kept so additionalProperties rules still apply
+	for name, value := range node.attrs {
+		if _, ok := object[name]; !ok && (schema == nil || !isXMLAttribute(schema, name)) {
+			object[name] = value
+		}
+	}
+	for _, child := range node.children {
+		if !consumed[child] {
+			if _, ok := object[child.name]; !ok {
+				object[child.name] = xmlToValue(child, nil)
+			}
+		}
+	}
+
+	return object
+}
+
+func xmlProperty(node *xmlNode, name string, property *openapi3.SchemaRef, consumed map[*xmlNode]bool) (any, bool) {
+	elementName := xmlName(name, property)
+	propertySchema := property.Value
+
+	if propertySchema.XML != nil && propertySchema.XML.Attribute {
+		value, ok := node.attrs[elementName]
+		return xmlScalar(value, propertySchema), ok
+	}
+
+	if propertySchema.Type.Is("array") {
+		if propertySchema.XML != nil && propertySchema.XML.Wrapped {
+			for _, child := range node.children {
+				if child.name == elementName {
+					consumed[child] = true
+					return xmlArray(child.children, propertySchema.Items), true
+				}
+			}
+			return nil, false
+		}
+
+		itemName := xmlName(elementName, propertySchema.Items)
+		var items []*xmlNode
+		for _, child := range node.children {
+			if child.name == itemName {
+				consumed[child] = true
+				items = append(items, child)
+			}
+		}
+		return xmlArray(items, propertySchema.Items), len(items) > 0
+	}
+
+	for _, child := range node.children {
+		if child.name == elementName {
+			consumed[child] = true
+			return xmlToValue(child, propertySchema), true
+		}
+	}
+	return nil, false
+}
+
+func isXMLAttribute(schema *openapi3.Schema, attrName string) bool {
+	for name, property := range schema.Properties {
+		if property.Value.XML != nil && property.Value.XML.Attribute && xmlName(name, property) == attrName {
+			return true
+		}
+	}
+	return false
+}
+
+func xmlArray(nodes []*xmlNode, items *openapi3.SchemaRef) []any {
+	var itemSchema *openapi3.Schema
+	if items != nil {
+		itemSchema = items.Value
+	}
+
+	values := make([]any, 0, len(nodes))
+	for _, node := range nodes {
+		values = append(values, xmlToValue(node, itemSchema))
+	}
+	return values
+}
+
+// xmlScalar types text according to the schema. Values that don't parse are
+// returned as strings so the validator reports the type mismatch.
+func xmlScalar(text string, schema *openapi3.Schema) any {
+	switch {
+	case schema.Type.Is("integer"):
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return n
+		}
+	case schema.Type.Is("number"):
+		if n, err := strconv.ParseFloat(text, 64); err == nil {
+			return n
+		}
+	case schema.Type.Is("boolean"):
+		if b, err := strconv.ParseBool(text); err == nil {
+			return b
+		}
+	}
+	return text
+}
@@ -0,0 +1,379 @@
+package specgate
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+const xmlTestSpec = `openapi: 3.0.3
+info:
+  title: XML API
+  version: 1.0.0
+paths:
+  /books/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: A book
+          content:
+            application/xml:
+              schema:
+                $ref: "#/components/schemas/Book"
+components:
+  schemas:
+    Book:
+      type: object
+      required: [id, title]
+      xml:
+        name: book
+      properties:
+        id:
+          type: integer
+          xml:
+            attribute: true
+        title:
+          type: string
+        price:
+          type: number
+        available:
+          type: boolean
+        tags:
+          type: array
+          xml:
+            wrapped: true
+          items:
+            type: string
+            xml:
+              name: tag
+        authors:
+          type: array
+          items:
+            type: string
+            xml:
+              name: author
+`
+
+func loadXMLTestSchema(t *testing.T) *openapi3.SchemaRef {
+	t.Helper()
+
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(xmlTestSpec))
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+	return spec.Components.Schemas["Book"]
+}
+
+func TestXMLBodyDecoder(t *testing.T) {
+	schema := loadXMLTestSchema(t)
+
+	tests := []struct {
+		name        string
+		body        string
+		expected    any
+		expectError bool
+	}{
+		{
+			name: "typed scalars, attributes and arrays",
+			body: `<book id="7">
+  <title>Dune</title>
+  <price>9.99</price>
+  <available>true</available>
+  <tags><tag>scifi</tag><tag>classic</tag></tags>
+  <author>Frank Herbert</author>
+</book>`,
+			expected: map[string]any{
+				"id":        int64(7),
+				"title":     "Dune",
+				"price":     9.99,
+				"available": true,
+				"tags":      []any{"scifi", "classic"},
+				"authors":   []any{"Frank Herbert"},
+			},
+		},
+		{
+			name: "unparseable scalars stay strings",
+			body: `<book id="seven"><title>Dune</title></book>`,
+			expected: map[string]any{
+				"id":    "seven",
+				"title": "Dune",
+			},
+		},
+		{
+			name: "undocumented elements are kept",
+			body: `<book id="1"><title>Dune</title><isbn>123</isbn></book>`,
+			expected: map[string]any{
+				"id":    int64(1),
+				"title": "Dune",
+				"isbn":  "123",
+			},
+		},
+		{
+			name:        "malformed xml",
+			body:        `<book><title>Dune</book>`,
+			expectError: true,
+		},
+		{
+			name:        "empty body",
+			body:        ``,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := XMLBodyDecoder(strings.NewReader(tt.body), http.Header{}, schema, nil)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("XMLBodyDecoder() error = %v, expectError %v", err, tt.expectError)
+			}
+			if !tt.expectError && !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("XMLBodyDecoder() = %#v, expected %#v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHasBodyDecoder(t *testing.T) {
+	tests := []struct {
+		contentType string
+		expected    bool
+	}{
+		{contentType: "application/json", expected: true},
+		{contentType: "application/json; charset=utf-8", expected: true},
+		{contentType: "application/xml", expected: true},
+		{contentType: "text/xml; charset=utf-8", expected: true},
+		{contentType: "application/vnd.myapi.v1+json", expected: true},
+		{contentType: "application/problem+json; charset=utf-8", expected: true},
+		{contentType: "application/atom+xml", expected: true},
+		{contentType: "text/html", expected: false},
+		{contentType: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			if result := newBodyDecoders().has(tt.contentType); result != tt.expected {
+				t.Errorf("has(%q) = %v, expected %v", tt.contentType, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRegisterBodyDecoder(t *testing.T) {
+	const contentType = "application/x-specgate-test"
+	before := newBodyDecoders()
+
+	RegisterBodyDecoder(contentType, openapi3filter.PlainBodyDecoder)
+	defer func() {
+		defaultBodyDecodersMu.Lock()
+		delete(defaultBodyDecoders, contentType)
+		defaultBodyDecodersMu.Unlock()
+	}()
+
+	if before.has(contentType) {
+		t.Errorf("%s should not have a decoder in a lookup created before registration", contentType)
+	}
+	if !newBodyDecoders().has(contentType) {
+		t.Errorf("%s should have a decoder in a lookup created after registration", contentType)
+	}
+	if openapi3filter.RegisteredBodyDecoder(contentType) != nil {
+		t.Errorf("%s should not be registered with openapi3filter", contentType)
+	}
+}
+
+func TestBodyDecodersLeaveOpenapi3filterRegistry(t *testing.T) {
+	tests := []struct {
+		contentType string
+		decoder     openapi3filter.BodyDecoder
+	}{
+		{contentType: "application/xml", decoder: XMLBodyDecoder},
+		{contentType: "text/xml", decoder: XMLBodyDecoder},
+		{contentType: "application/x-www-form-urlencoded", decoder: formBodyDecoder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			registered := openapi3filter.RegisteredBodyDecoder(tt.contentType)
+			if registered != nil && reflect.ValueOf(registered).Pointer() == reflect.ValueOf(tt.decoder).Pointer() {
+				t.Errorf("%s should not be registered with openapi3filter", tt.contentType)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_RegisterBodyDecoder(t *testing.T) {
+	const contentType = "application/x-specgate-proxy-test"
+	vp := newTestProxy(t, testSpec, "http://localhost:1", "strict")
+	vp.RegisterBodyDecoder(contentType, openapi3filter.PlainBodyDecoder)
+	if !vp.validatesContentType(contentType) {
+		t.Errorf("%s should be validated after registration", contentType)
+	}
+	if newBodyDecoders().has(contentType) {
+		t.Errorf("%s should only be registered with the proxy", contentType)
+	}
+}
+
+func TestValidatingProxy_XMLResponses(t *testing.T) {
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "valid application/xml",
+			contentType:    "application/xml",
+			body:           `<book id="1"><title>Dune</title><price>9.99</price></book>`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "valid xml with charset",
+			contentType:    "application/xml; charset=utf-8",
+			body:           `<book id="1"><title>Dune</title></book>`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing required element",
+			contentType:    "application/xml",
+			body:           `<book id="1"><price>9.99</price></book>`,
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "wrong scalar type",
+			contentType:    "application/xml",
+			body:           `<book id="1"><title>Dune</title><price>cheap</price></book>`,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, xmlTestSpec, upstream.URL, "strict")
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books/1", nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("ServeHTTP() status = %d, expected %d, body: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+			if tt.expectedStatus == http.StatusOK {
+				body, _ := io.ReadAll(rec.Body)
+				if !bytes.Equal(body, []byte(tt.body)) {
+					t.Errorf("ServeHTTP() should pass XML bodies through unchanged")
+				}
+			}
+		})
+	}
+}
+
+const vendorTestSpec = `
+openapi: 3.0.0
+info:
+  title: Vendor API
+  version: 1.0.0
+paths:
+  /users/{id}:
+    get:
+      operationId: getUser
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: OK
+          content:
+            application/vnd.myapi.v1+json:
+              schema:
+                type: object
+                required: [id]
+                properties:
+                  id:
+                    type: integer
+            application/vnd.myapi.v2+json:
+              schema:
+                type: object
+                required: [uuid]
+                properties:
+                  uuid:
+                    type: string
+`
+
+func TestValidatingProxy_VendorMediaTypes(t *testing.T) {
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "valid v1",
+			contentType:    "application/vnd.myapi.v1+json",
+			body:           `{"id": 1}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "valid v2 with charset",
+			contentType:    "application/vnd.myapi.v2+json; charset=utf-8",
+			body:           `{"uuid": "8f14e45f"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 body labelled v1",
+			contentType:    "application/vnd.myapi.v1+json",
+			body:           `{"uuid": "8f14e45f"}`,
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "v1 body labelled v2",
+			contentType:    "application/vnd.myapi.v2+json",
+			body:           `{"id": 1}`,
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "undocumented vendor type",
+			contentType:    "application/vnd.myapi.v3+json",
+			body:           `{"id": 1}`,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, vendorTestSpec, upstream.URL, "strict")
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("ServeHTTP() status = %d, expected %d, body: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+		})
+	}
+}
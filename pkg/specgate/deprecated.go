@@ -0,0 +1,42 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"net/http"
+
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// reportDeprecated logs and counts a call to an operation the spec marks as
+// deprecated, with enough about the client to follow up with its owner.
+func (vp *ValidatingProxy) reportDeprecated(r *http.Request, route *routers.Route) {
+	vp.metrics.recordDeprecatedCall(route)
+
+	args := []any{
+		"operation", operationName(route),
+		"method", r.Method,
+		"path", r.URL.Path,
+		"user_agent", r.UserAgent(),
+	}
+	if ip, ok := clientIP(r, vp.access.clientIPHeader); ok {
+		args = append(args, "client_ip", ip)
+	}
+	vp.log(r.Context()).Warn("Deprecated operation called", args...)
+}
@@ -0,0 +1,86 @@
+package specgate
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const deprecatedSpec = `openapi: 3.0.3
+info:
+  title: Test API
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      deprecated: true
+      responses:
+        "200":
+          description: Users
+  /v2/users:
+    get:
+      operationId: listUsersV2
+      responses:
+        "200":
+          description: Users
+`
+
+func TestValidatingProxy_DeprecatedOperations(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		operationID string
+		expectWarn  bool
+	}{
+		{name: "deprecated operation", path: "/users", operationID: "listUsers", expectWarn: true},
+		{name: "current operation", path: "/v2/users", operationID: "listUsersV2"},
+		{name: "undocumented endpoint", path: "/orders"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, deprecatedSpec, upstream.URL, "warn")
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = "10.1.2.3:54321"
+			req.Header.Set("User-Agent", "legacy-client/1.0")
+			vp.ServeHTTP(httptest.NewRecorder(), req)
+
+			warned := strings.Contains(logs.String(), "Deprecated operation called")
+			if warned != tt.expectWarn {
+				t.Fatalf("deprecation warning logged = %v, expected %v: %s", warned, tt.expectWarn, logs.String())
+			}
+			if tt.expectWarn {
+				for _, expected := range []string{"operation=" + tt.operationID, "user_agent=legacy-client/1.0", "client_ip=10.1.2.3", "request_id="} {
+					if !strings.Contains(logs.String(), expected) {
+						t.Errorf("deprecation warning missing %q: %s", expected, logs.String())
+					}
+				}
+			}
+
+			if tt.operationID == "" {
+				return
+			}
+			expectedCalls := 0.0
+			if tt.expectWarn {
+				expectedCalls = 1
+			}
+			if got := testutil.ToFloat64(vp.metrics.deprecatedCalls.WithLabelValues(tt.operationID, tt.path)); got != expectedCalls {
+				t.Errorf("deprecated calls = %v, expected %v", got, expectedCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+func decodeContentEncoding(contentEncoding string, body []byte, maxSize int64) ([]byte, error) {
+	encodings := strings.Split(contentEncoding, ",")
+
+	decoded := body
+	// Encodings are listed in the order they were applied, so undo them in reverse
+	for i := len(encodings) - 1; i >= 0; i-- {
+		encoding := strings.ToLower(strings.TrimSpace(encodings[i]))
+
+		var err error
+		decoded, err = decodeSingleEncoding(encoding, decoded, maxSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return decoded, nil
+}
+
+func decodeSingleEncoding(encoding string, body []byte, maxSize int64) ([]byte, error) {
+	var reader io.Reader
+
+	switch encoding {
+	case "", "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	case "deflate":
+		// RFC 9110 specifies zlib-wrapped deflate, but some servers send raw deflate
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			fr := flate.NewReader(bytes.NewReader(body))
+			defer fr.Close()
+			reader = fr
+		} else {
+			defer zr.Close()
+			reader = zr
+		}
+	case "br":
+		reader = brotli.NewReader(bytes.NewReader(body))
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %s", encoding)
+	}
+
+	decoded, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s body: %w", encoding, err)
+	}
+	if int64(len(decoded)) > maxSize {
+		return nil, fmt.Errorf("decoded %s body exceeds %d bytes", encoding, maxSize)
+	}
+
+	return decoded, nil
+}
+
+// minCompressSize is the smallest body compressResponse gzips, since
+// smaller ones barely shrink or even grow.
+const minCompressSize = 1024
+
+// compressResponse gzips resp's buffered body for clients that accept gzip,
+// when the upstream sent it uncompressed.
+func compressResponse(resp *http.Response) error {
+	if resp.Header.Get("Content-Encoding") != "" || !acceptsGzip(resp.Request.Header.Get("Accept-Encoding")) {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body for compression: %w", err)
+	}
+	if len(body) < minCompressSize {
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return nil
+	}
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write(body); err != nil {
+		return fmt.Errorf("failed to compress response body: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to compress response body: %w", err)
+	}
+
+	resp.Body = io.NopCloser(&compressed)
+	resp.ContentLength = int64(compressed.Len())
+	resp.Header.Set("Content-Encoding", "gzip")
+	resp.Header.Set("Content-Length", strconv.Itoa(compressed.Len()))
+	resp.Header.Add("Vary", "Accept-Encoding")
+	// The compressed body is no longer byte-for-byte what a strong ETag identifies
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		resp.Header.Set("ETag", "W/"+etag)
+	}
+	return nil
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip, either
+// by name or through "*", without a q=0 that rules it out.
+func acceptsGzip(acceptEncoding string) bool {
+	accepted := false
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "gzip" && name != "x-gzip" && name != "*" {
+			continue
+		}
+
+		q := 1.0
+		if key, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.EqualFold(strings.TrimSpace(key), "q") {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = parsed
+			}
+		}
+		if name != "*" {
+			return q > 0
+		}
+		accepted = q > 0
+	}
+	return accepted
+}
@@ -0,0 +1,243 @@
+package specgate
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			t.Fatalf("failed to create flate writer: %v", err)
+		}
+		w = fw
+	case "br":
+		w = brotli.NewWriter(&buf)
+	default:
+		t.Fatalf("unknown test encoding %q", encoding)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close compressor: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeContentEncoding(t *testing.T) {
+	payload := []byte(`{"id": 1, "name": "test"}`)
+
+	tests := []struct {
+		name        string
+		encoding    string
+		body        []byte
+		maxSize     int64
+		expectError bool
+	}{
+		{
+			name:     "no encoding",
+			encoding: "",
+			body:     payload,
+			maxSize:  defaultMaxBodySize,
+		},
+		{
+			name:     "identity",
+			encoding: "identity",
+			body:     payload,
+			maxSize:  defaultMaxBodySize,
+		},
+		{
+			name:     "gzip",
+			encoding: "gzip",
+			body:     compress(t, "gzip", payload),
+			maxSize:  defaultMaxBodySize,
+		},
+		{
+			name:     "uppercase gzip",
+			encoding: "GZIP",
+			body:     compress(t, "gzip", payload),
+			maxSize:  defaultMaxBodySize,
+		},
+		{
+			name:     "zlib deflate",
+			encoding: "deflate",
+			body:     compress(t, "deflate", payload),
+			maxSize:  defaultMaxBodySize,
+		},
+		{
+			name:     "raw deflate",
+			encoding: "deflate",
+			body:     compress(t, "raw-deflate", payload),
+			maxSize:  defaultMaxBodySize,
+		},
+		{
+			name:     "brotli",
+			encoding: "br",
+			body:     compress(t, "br", payload),
+			maxSize:  defaultMaxBodySize,
+		},
+		{
+			name:     "stacked encodings",
+			encoding: "gzip, br",
+			body:     compress(t, "br", compress(t, "gzip", payload)),
+			maxSize:  defaultMaxBodySize,
+		},
+		{
+			name:        "gzip header but plain body",
+			encoding:    "gzip",
+			body:        payload,
+			maxSize:     defaultMaxBodySize,
+			expectError: true,
+		},
+		{
+			name:        "unsupported encoding",
+			encoding:    "compress",
+			body:        payload,
+			maxSize:     defaultMaxBodySize,
+			expectError: true,
+		},
+		{
+			name:        "decoded body exceeds limit",
+			encoding:    "gzip",
+			body:        compress(t, "gzip", payload),
+			maxSize:     5,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := decodeContentEncoding(tt.encoding, tt.body, tt.maxSize)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("decodeContentEncoding() error = %v, expectError %v", err, tt.expectError)
+			}
+			if !tt.expectError && !bytes.Equal(result, payload) {
+				t.Errorf("decodeContentEncoding() = %q, expected %q", result, payload)
+			}
+		})
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		expected       bool
+	}{
+		{acceptEncoding: "gzip", expected: true},
+		{acceptEncoding: "br, gzip;q=0.8", expected: true},
+		{acceptEncoding: "GZIP", expected: true},
+		{acceptEncoding: "*", expected: true},
+		{acceptEncoding: "gzip;q=0", expected: false},
+		{acceptEncoding: "*, gzip;q=0", expected: false},
+		{acceptEncoding: "br, deflate", expected: false},
+		{acceptEncoding: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			if result := acceptsGzip(tt.acceptEncoding); result != tt.expected {
+				t.Errorf("acceptsGzip(%q) = %v, expected %v", tt.acceptEncoding, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_CompressResponses(t *testing.T) {
+	largeBody := []byte(`{"id": 1, "name": "` + strings.Repeat("a", 2*minCompressSize) + `"}`)
+	smallBody := []byte(`{"id": 1, "name": "test"}`)
+
+	tests := []struct {
+		name             string
+		compress         bool
+		acceptEncoding   string
+		upstreamEncoding string
+		body             []byte
+		expectGzip       bool
+	}{
+		{name: "compressed", compress: true, acceptEncoding: "gzip", body: largeBody, expectGzip: true},
+		{name: "disabled", acceptEncoding: "gzip", body: largeBody},
+		{name: "client doesn't accept gzip", compress: true, body: largeBody},
+		{name: "small body", compress: true, acceptEncoding: "gzip", body: smallBody},
+		{
+			name:             "already compressed upstream",
+			compress:         true,
+			acceptEncoding:   "gzip",
+			upstreamEncoding: "gzip",
+			body:             compress(t, "gzip", largeBody),
+			expectGzip:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("ETag", `"v1"`)
+				if tt.upstreamEncoding != "" {
+					w.Header().Set("Content-Encoding", tt.upstreamEncoding)
+				}
+				_, _ = w.Write(tt.body)
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			cfg.CompressResponses = tt.compress
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("ServeHTTP() status = %d, body: %s", rec.Code, rec.Body.String())
+			}
+			if gzipped := rec.Header().Get("Content-Encoding") == "gzip"; gzipped != tt.expectGzip {
+				t.Fatalf("Content-Encoding = %q, expected gzip = %v", rec.Header().Get("Content-Encoding"), tt.expectGzip)
+			}
+			if !tt.expectGzip {
+				if !bytes.Equal(rec.Body.Bytes(), tt.body) {
+					t.Errorf("ServeHTTP() should pass the body through unchanged")
+				}
+				return
+			}
+
+			if length := rec.Header().Get("Content-Length"); length != strconv.Itoa(rec.Body.Len()) {
+				t.Errorf("Content-Length = %s, expected %d", length, rec.Body.Len())
+			}
+			decoded, err := decodeContentEncoding("gzip", rec.Body.Bytes(), int64(len(largeBody)))
+			if err != nil || !bytes.Equal(decoded, largeBody) {
+				t.Errorf("decoded body = %q (error %v), expected the upstream body", decoded, err)
+			}
+			if tt.upstreamEncoding == "" && rec.Header().Get("ETag") != `W/"v1"` {
+				t.Errorf("ETag = %q, expected it to be weakened", rec.Header().Get("ETag"))
+			}
+		})
+	}
+}
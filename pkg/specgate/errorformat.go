@@ -0,0 +1,178 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+type ErrorFormat string
+
+const (
+	ErrorFormatJSON    ErrorFormat = "json"
+	ErrorFormatProblem ErrorFormat = "problem"
+)
+
+// ErrorDetails describes a strict-mode validation failure. It is the data
+// passed to custom error templates.
+type ErrorDetails struct {
+	Status      int
+	Title       string
+	Detail      string
+	Method      string
+	Path        string
+	OperationID string
+	// Upstream is what the upstream sent, when -error-upstream-body is set
+	Upstream *UpstreamResponse
+}
+
+// UpstreamResponse is the start of an invalid upstream response that a
+// strict-mode error replaced.
+type UpstreamResponse struct {
+	Status    int    `json:"status"`
+	Body      string `json:"body,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+type errorRenderer func(details ErrorDetails) (body []byte, contentType string, err error)
+
+// errorResponses controls the error responses sent in place of invalid
+// responses in strict mode.
+type errorResponses struct {
+	renderer     errorRenderer
+	status       int
+	expose       bool
+	stripHeaders []string
+	upstreamBody int
+}
+
+func newErrorResponses(cfg *Config) (errorResponses, error) {
+	renderer, err := newErrorRenderer(cfg.ErrorFormat, cfg.ErrorTemplate)
+	if err != nil {
+		return errorResponses{}, err
+	}
+	return errorResponses{
+		renderer:     renderer,
+		status:       cfg.FailureStatus,
+		expose:       cfg.ExposeErrors,
+		stripHeaders: cfg.StripErrorHeaders,
+		upstreamBody: int(cfg.ErrorUpstreamBody),
+	}, nil
+}
+
+func parseErrorFormat(format string) (ErrorFormat, error) {
+	switch ErrorFormat(strings.ToLower(format)) {
+	case ErrorFormatJSON:
+		return ErrorFormatJSON, nil
+	case ErrorFormatProblem:
+		return ErrorFormatProblem, nil
+	default:
+		return "", fmt.Errorf("invalid error format '%s': must be one of 'json' or 'problem'", format)
+	}
+}
+
+// newErrorRenderer returns a renderer for the given format, or for the
+// template at templatePath when one is set.
+func newErrorRenderer(format, templatePath string) (errorRenderer, error) {
+	errorFormat, err := parseErrorFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	if templatePath != "" {
+		return loadErrorTemplate(templatePath)
+	}
+	if errorFormat == ErrorFormatProblem {
+		return renderProblemError, nil
+	}
+	return renderJSONError, nil
+}
+
+func renderJSONError(details ErrorDetails) ([]byte, string, error) {
+	fields := map[string]any{
+		"error":   details.Title,
+		"details": details.Detail,
+	}
+	if details.OperationID != "" {
+		fields["operation_id"] = details.OperationID
+	}
+	if details.Upstream != nil {
+		fields["upstream"] = details.Upstream
+	}
+	body, err := json.Marshal(fields)
+	return body, "application/json", err
+}
+
+// renderProblemError renders an RFC 7807 problem details object.
+func renderProblemError(details ErrorDetails) ([]byte, string, error) {
+	problem := map[string]any{
+		"type":     "about:blank",
+		"title":    details.Title,
+		"status":   details.Status,
+		"detail":   details.Detail,
+		"instance": details.Path,
+	}
+	if details.OperationID != "" {
+		problem["operation_id"] = details.OperationID
+	}
+	if details.Upstream != nil {
+		problem["upstream"] = details.Upstream
+	}
+	body, err := json.Marshal(problem)
+	return body, "application/problem+json", err
+}
+
+// loadErrorTemplate parses a text/template file. The response Content-Type
+// is derived from the file extension, e.g. .json or .xml.
+func loadErrorTemplate(path string) (errorRenderer, error) {
+	source, err := os.ReadFile(path) // #nosec G304 -- path is supplied by the operator
+	if err != nil {
+		return nil, fmt.Errorf("failed to read error template: %w", err)
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			encoded, err := json.Marshal(v)
+			return string(encoded), err
+		},
+	}).Parse(string(source))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse error template: %w", err)
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+
+	return func(details ErrorDetails) ([]byte, string, error) {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, details); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), contentType, nil
+	}, nil
+}
@@ -0,0 +1,212 @@
+package specgate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewErrorRenderer(t *testing.T) {
+	details := ErrorDetails{
+		Status: http.StatusInternalServerError,
+		Title:  "Response validation failed",
+		Detail: `property "id" is missing`,
+		Method: http.MethodGet,
+		Path:   "/users/1",
+	}
+
+	tests := []struct {
+		name                string
+		format              string
+		template            string
+		templateExt         string
+		expectedContentType string
+		expectedBody        string
+		expectError         bool
+	}{
+		{
+			name:                "json",
+			format:              "json",
+			expectedContentType: "application/json",
+			expectedBody:        `{"details":"property \"id\" is missing","error":"Response validation failed"}`,
+		},
+		{
+			name:                "problem details",
+			format:              "problem",
+			expectedContentType: "application/problem+json",
+			expectedBody:        `{"detail":"property \"id\" is missing","instance":"/users/1","status":500,"title":"Response validation failed","type":"about:blank"}`,
+		},
+		{
+			name:                "json template",
+			format:              "json",
+			template:            `{"code": {{.Status}}, "message": {{json .Detail}}, "route": "{{.Method}} {{.Path}}"}`,
+			templateExt:         ".json",
+			expectedContentType: "application/json",
+			expectedBody:        `{"code": 500, "message": "property \"id\" is missing", "route": "GET /users/1"}`,
+		},
+		{
+			name:                "template without known extension",
+			format:              "json",
+			template:            `{{.Title}}: {{.Detail}}`,
+			templateExt:         ".tmpl",
+			expectedContentType: "text/plain; charset=utf-8",
+			expectedBody:        `Response validation failed: property "id" is missing`,
+		},
+		{
+			name:        "invalid format",
+			format:      "html",
+			expectError: true,
+		},
+		{
+			name:        "invalid template",
+			format:      "json",
+			template:    `{{.Status`,
+			templateExt: ".json",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var templatePath string
+			if tt.template != "" {
+				templatePath = filepath.Join(t.TempDir(), "error"+tt.templateExt)
+				if err := os.WriteFile(templatePath, []byte(tt.template), 0o600); err != nil {
+					t.Fatalf("failed to write template: %v", err)
+				}
+			}
+
+			render, err := newErrorRenderer(tt.format, templatePath)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("newErrorRenderer() error = %v, expectError %v", err, tt.expectError)
+			}
+			if tt.expectError {
+				return
+			}
+
+			body, contentType, err := render(details)
+			if err != nil {
+				t.Fatalf("render() unexpected error: %v", err)
+			}
+			if contentType != tt.expectedContentType {
+				t.Errorf("content type = %q, expected %q", contentType, tt.expectedContentType)
+			}
+			if string(body) != tt.expectedBody {
+				t.Errorf("body = %s, expected %s", body, tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_ProblemErrorFormat(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": "not-a-number", "name": "test"}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultConfig()
+	cfg.Upstream = upstream.URL
+	cfg.Mode = "strict"
+	cfg.ErrorFormat = "problem"
+	vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+	rec := httptest.NewRecorder()
+	vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("Content-Type = %q, expected application/problem+json", contentType)
+	}
+
+	var problem map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("invalid problem body %q: %v", rec.Body.String(), err)
+	}
+	if problem["status"] != float64(http.StatusInternalServerError) || problem["instance"] != "/users/1" || problem["detail"] == "" {
+		t.Errorf("unexpected problem body %v", problem)
+	}
+}
+
+func TestValidatingProxy_ErrorUpstreamBody(t *testing.T) {
+	tests := []struct {
+		name             string
+		limit            ByteSize
+		redactFields     FieldList
+		contentType      string
+		body             string
+		expectedUpstream *UpstreamResponse
+	}{
+		{name: "disabled", contentType: "application/json", body: `{"id": "one"}`},
+		{
+			name:             "whole body",
+			limit:            1024,
+			contentType:      "application/json",
+			body:             `{"id": "one", "name": "Ann"}`,
+			expectedUpstream: &UpstreamResponse{Status: http.StatusOK, Body: `{"id": "one", "name": "Ann"}`},
+		},
+		{
+			name:             "truncated",
+			limit:            10,
+			contentType:      "application/json",
+			body:             `{"id": "one", "name": "Ann"}`,
+			expectedUpstream: &UpstreamResponse{Status: http.StatusOK, Body: `{"id": "on`, Truncated: true},
+		},
+		{
+			name:             "truncated within a character",
+			limit:            9,
+			contentType:      "application/json",
+			body:             `{"id": "één"}`,
+			expectedUpstream: &UpstreamResponse{Status: http.StatusOK, Body: `{"id": "`, Truncated: true},
+		},
+		{
+			name:             "redacted",
+			limit:            1024,
+			redactFields:     FieldList{"name"},
+			contentType:      "application/json",
+			body:             `{"id": "one", "name": "Ann"}`,
+			expectedUpstream: &UpstreamResponse{Status: http.StatusOK, Body: `{"id":"one","name":"***"}`},
+		},
+		{
+			name:             "xml that can't be redacted",
+			limit:            1024,
+			redactFields:     FieldList{"name"},
+			contentType:      "application/xml",
+			body:             "<user><name>Ann</name></user>",
+			expectedUpstream: &UpstreamResponse{Status: http.StatusOK},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			cfg.ErrorUpstreamBody = tt.limit
+			cfg.RedactFields = tt.redactFields
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+			var body struct {
+				Upstream *UpstreamResponse `json:"upstream"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid error body %q: %v", rec.Body.String(), err)
+			}
+			if (body.Upstream == nil) != (tt.expectedUpstream == nil) ||
+				(body.Upstream != nil && *body.Upstream != *tt.expectedUpstream) {
+				t.Errorf("upstream = %+v, expected %+v (body %s)", body.Upstream, tt.expectedUpstream, rec.Body.String())
+			}
+		})
+	}
+}
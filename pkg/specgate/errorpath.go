@@ -0,0 +1,117 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+// describeValidationError condenses err into one "location: reason" entry
+// per schema error, such as "response body .data.items[3].price: value must
+// be a number, got string". Errors without schema errors are returned as is.
+func describeValidationError(err error) string {
+	var problems []string
+	collectSchemaErrors(err, "", &problems)
+	if len(problems) == 0 {
+		return err.Error()
+	}
+	return strings.Join(problems, "; ")
+}
+
+func collectSchemaErrors(err error, where string, problems *[]string) {
+	switch e := err.(type) {
+	case nil:
+		return
+	case *openapi3.SchemaError:
+		*problems = append(*problems, describeSchemaError(e, where))
+		return
+	case openapi3.MultiError:
+		for _, inner := range e {
+			collectSchemaErrors(inner, where, problems)
+		}
+		return
+	case *openapi3filter.ResponseError:
+		// Reasons read "response body doesn't match schema" or the same for a header
+		where, _, _ = strings.Cut(e.Reason, " doesn't match schema")
+	case *openapi3filter.RequestError:
+		switch {
+		case e.Parameter != nil:
+			where = fmt.Sprintf("%s parameter %s", e.Parameter.In, e.Parameter.Name)
+		case e.RequestBody != nil:
+			where = "request body"
+		}
+	}
+
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		collectSchemaErrors(e.Unwrap(), where, problems)
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			collectSchemaErrors(inner, where, problems)
+		}
+	}
+}
+
+func describeSchemaError(e *openapi3.SchemaError, where string) string {
+	location := strings.TrimSpace(where + " " + jsonPath(e.JSONPointer()))
+	if location == "" {
+		location = "value"
+	}
+
+	reason := e.Reason
+	if e.SchemaField == "type" {
+		reason += ", got " + jsonType(e.Value)
+	}
+	return fmt.Sprintf("%s: %s", location, reason)
+}
+
+// jsonPath renders a JSON pointer as a path like .items[3].price.
+func jsonPath(pointer []string) string {
+	var path strings.Builder
+	for _, segment := range pointer {
+		if _, err := strconv.Atoi(segment); err == nil {
+			path.WriteString("[" + segment + "]")
+		} else {
+			path.WriteString("." + segment)
+		}
+	}
+	return path.String()
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return "number"
+	}
+}
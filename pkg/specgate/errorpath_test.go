@@ -0,0 +1,75 @@
+package specgate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+func TestDescribeValidationError(t *testing.T) {
+	schema := openapi3.NewObjectSchema().
+		WithProperty("data", openapi3.NewObjectSchema().
+			WithProperty("items", openapi3.NewArraySchema().
+				WithItems(openapi3.NewObjectSchema().WithProperty("price", openapi3.NewFloat64Schema())))).
+		WithProperty("name", openapi3.NewStringSchema().WithMaxLength(3))
+	body := map[string]any{
+		"data": map[string]any{"items": []any{
+			map[string]any{"price": 1.5},
+			map[string]any{"price": "cheap"},
+		}},
+		"name": "too long",
+	}
+	multiErr := schema.VisitJSON(body, openapi3.MultiErrors())
+	singleErr := schema.VisitJSON(map[string]any{"name": true})
+
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "response body",
+			err:      &openapi3filter.ResponseError{Reason: "response body doesn't match schema", Err: singleErr},
+			expected: "response body .name: value must be a string, got boolean",
+		},
+		{
+			name: "several problems",
+			err:  &openapi3filter.ResponseError{Reason: "response body doesn't match schema", Err: multiErr},
+			expected: "response body .data.items[1].price: value must be a number, got string; " +
+				"response body .name: maximum string length is 3",
+		},
+		{
+			name:     "response header",
+			err:      &openapi3filter.ResponseError{Reason: `response header "X-Rate-Limit" doesn't match schema`, Err: openapi3.NewIntegerSchema().VisitJSON("lots")},
+			expected: `response header "X-Rate-Limit": value must be an integer, got string`,
+		},
+		{
+			name: "query parameter",
+			err: &openapi3filter.RequestError{
+				Parameter: &openapi3.Parameter{Name: "limit", In: "query"},
+				Err:       openapi3.NewIntegerSchema().WithMax(100).VisitJSON(float64(500)),
+			},
+			expected: "query parameter limit: number must be at most 100",
+		},
+		{
+			name:     "without schema errors",
+			err:      &openapi3filter.ResponseError{Reason: "status is not supported"},
+			expected: "status is not supported",
+		},
+		{
+			name:     "wrapped",
+			err:      errors.Join(errors.New("validation failed"), singleErr),
+			expected: ".name: value must be a string, got boolean",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := describeValidationError(tt.err); result != tt.expected {
+				t.Errorf("describeValidationError() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
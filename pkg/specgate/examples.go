@@ -0,0 +1,162 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// exampleChecker collects the examples in a spec that don't match their own
+// schema. Components shared between operations are checked once.
+type exampleChecker struct {
+	visited  map[any]bool
+	options  []openapi3.SchemaValidationOption
+	problems []string
+}
+
+// checkExamples returns a description of each example in spec that fails
+// validation against its schema with options, sorted for stable output.
+func checkExamples(spec *openapi3.T, options []openapi3.SchemaValidationOption) []string {
+	c := &exampleChecker{visited: make(map[any]bool), options: options}
+	// Schemas are checked first so that problems in shared ones are reported
+	// under their component name
+	if spec.Components != nil {
+		for _, name := range componentNames(spec.Components.Schemas) {
+			c.schema("schema "+name, spec.Components.Schemas[name])
+		}
+	}
+
+	for _, path := range spec.Paths.Keys() {
+		item := spec.Paths.Value(path)
+		for method, operation := range item.Operations() {
+			where := method + " " + path
+			for _, param := range slices.Concat(item.Parameters, operation.Parameters) {
+				if param != nil && param.Value != nil {
+					c.parameter(fmt.Sprintf("%s parameter %q", where, param.Value.Name), param.Value, openapi3.VisitAsRequest())
+				}
+			}
+			if operation.RequestBody != nil && operation.RequestBody.Value != nil {
+				c.content(where+" request body", operation.RequestBody.Value.Content, openapi3.VisitAsRequest())
+			}
+			if operation.Responses == nil {
+				continue
+			}
+			for status, response := range operation.Responses.Map() {
+				if response == nil || response.Value == nil {
+					continue
+				}
+				where := fmt.Sprintf("%s response %s", where, status)
+				c.content(where, response.Value.Content, openapi3.VisitAsResponse())
+				for name, header := range response.Value.Headers {
+					if header != nil && header.Value != nil {
+						c.parameter(fmt.Sprintf("%s header %q", where, name), &header.Value.Parameter, openapi3.VisitAsResponse())
+					}
+				}
+			}
+		}
+	}
+	slices.Sort(c.problems)
+	return c.problems
+}
+
+func (c *exampleChecker) parameter(where string, param *openapi3.Parameter, opts ...openapi3.SchemaValidationOption) {
+	if c.visited[param] {
+		return
+	}
+	c.visited[param] = true
+	c.check(where, param.Schema, param.Example, param.Examples, opts...)
+	c.content(where, param.Content, opts...)
+}
+
+func (c *exampleChecker) content(where string, content openapi3.Content, opts ...openapi3.SchemaValidationOption) {
+	for _, contentType := range componentNames(content) {
+		mediaType := content[contentType]
+		if mediaType == nil || c.visited[mediaType] {
+			continue
+		}
+		c.visited[mediaType] = true
+		c.check(where+" "+contentType, mediaType.Schema, mediaType.Example, mediaType.Examples, opts...)
+	}
+}
+
+func (c *exampleChecker) check(where string, schema *openapi3.SchemaRef, example any, examples openapi3.Examples, opts ...openapi3.SchemaValidationOption) {
+	if schema == nil || schema.Value == nil {
+		return
+	}
+	if example != nil {
+		c.validate(where+" example", schema.Value, example, opts...)
+	}
+	for _, name := range componentNames(examples) {
+		if ex := examples[name]; ex != nil && ex.Value != nil && ex.Value.Value != nil {
+			c.validate(fmt.Sprintf("%s example %q", where, name), schema.Value, ex.Value.Value, opts...)
+		}
+	}
+	c.schema(where+" schema", schema)
+}
+
+// schema checks the example of a schema itself, and of the schemas nested in
+// its properties, items, additional properties and compositions.
+func (c *exampleChecker) schema(where string, ref *openapi3.SchemaRef) {
+	if ref == nil || ref.Value == nil || c.visited[ref.Value] {
+		return
+	}
+	schema := ref.Value
+	c.visited[schema] = true
+
+	if schema.Example != nil {
+		c.validate(where+" example", schema, schema.Example)
+	}
+	for _, name := range componentNames(schema.Properties) {
+		c.schema(where+"."+name, schema.Properties[name])
+	}
+	c.schema(where+"[]", schema.Items)
+	c.schema(where+".*", schema.AdditionalProperties.Schema)
+	c.schemas(where+".allOf", schema.AllOf)
+	c.schemas(where+".oneOf", schema.OneOf)
+	c.schemas(where+".anyOf", schema.AnyOf)
+}
+
+func (c *exampleChecker) schemas(where string, refs openapi3.SchemaRefs) {
+	for i, ref := range refs {
+		c.schema(fmt.Sprintf("%s[%d]", where, i), ref)
+	}
+}
+
+func (c *exampleChecker) validate(where string, schema *openapi3.Schema, value any, opts ...openapi3.SchemaValidationOption) {
+	err := schema.VisitJSON(value, slices.Concat(c.options, opts)...)
+	if err == nil {
+		return
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		reason := schemaErr.Reason
+		if pointer := schemaErr.JSONPointer(); len(pointer) > 0 {
+			reason = fmt.Sprintf("at /%s: %s", strings.Join(pointer, "/"), reason)
+		}
+		c.problems = append(c.problems, fmt.Sprintf("%s: %s", where, reason))
+		return
+	}
+	c.problems = append(c.problems, fmt.Sprintf("%s: %v", where, err))
+}
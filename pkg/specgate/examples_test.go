@@ -0,0 +1,201 @@
+package specgate
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const examplesSpec = `openapi: 3.0.3
+info:
+  title: Examples API
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+          example: ten
+      responses:
+        "200":
+          description: A list of users
+          headers:
+            X-Total:
+              schema:
+                type: integer
+              example: 3
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/User"
+              examples:
+                valid:
+                  value: [{id: 1, name: Ada}]
+                missingName:
+                  value: [{id: 2}]
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/User"
+            example: {name: Ada}
+      responses:
+        "201":
+          description: Created
+components:
+  schemas:
+    User:
+      type: object
+      required: [id, name]
+      properties:
+        id:
+          type: integer
+          readOnly: true
+        name:
+          type: string
+        email:
+          type: string
+          format: email
+          example: 42
+`
+
+func TestCheckExamples(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Spec = writeTestSpec(t, examplesSpec)
+	spec, err := loadSpecOnly(io.Discard, cfg)
+	if err != nil {
+		t.Fatalf("failed to load spec: %v", err)
+	}
+
+	problems := checkExamples(spec, nil)
+
+	expected := []string{
+		`GET /users parameter "limit" example: value must be an integer`,
+		`GET /users response 200 application/json example "missingName": at /0/name: property "name" is missing`,
+		`schema User.email example: value must be a string`,
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("checkExamples() = %q, expected %d problems", problems, len(expected))
+	}
+	for i, problem := range problems {
+		if problem != expected[i] {
+			t.Errorf("checkExamples()[%d] = %q, expected %q", i, problem, expected[i])
+		}
+	}
+}
+
+func TestCheckExamples_NestedSchemas(t *testing.T) {
+	const spec = `openapi: 3.0.3
+info:
+  title: Nested Examples API
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Pet:
+      allOf:
+        - type: object
+          properties:
+            age:
+              type: integer
+              example: old
+      oneOf:
+        - type: string
+          example: 7
+      anyOf:
+        - type: integer
+          example: seven
+    Labels:
+      type: object
+      additionalProperties:
+        type: string
+        example: 1
+    Contact:
+      type: object
+      properties:
+        phone:
+          type: string
+          format: phone-e164
+          example: "555-0100"
+`
+
+	tests := []struct {
+		name          string
+		stringFormats FormatPatterns
+		expected      []string
+	}{
+		{
+			name: "compositions and additional properties",
+			expected: []string{
+				`schema Labels.* example: value must be a string`,
+				`schema Pet.allOf[0].age example: value must be an integer`,
+				`schema Pet.anyOf[0] example: value must be an integer`,
+				`schema Pet.oneOf[0] example: value must be a string`,
+			},
+		},
+		{
+			name:          "custom string format",
+			stringFormats: FormatPatterns{"phone-e164": `^\+[1-9][0-9]{1,14}$`},
+			expected: []string{
+				`schema Contact.phone example: string doesn't match the format "phone-e164" (string doesn't match pattern "^\+[1-9][0-9]{1,14}$")`,
+				`schema Labels.* example: value must be a string`,
+				`schema Pet.allOf[0].age example: value must be an integer`,
+				`schema Pet.anyOf[0] example: value must be an integer`,
+				`schema Pet.oneOf[0] example: value must be a string`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Spec = writeTestSpec(t, spec)
+			cfg.StringFormats = tt.stringFormats
+			loaded, err := loadSpecOnly(io.Discard, cfg)
+			if err != nil {
+				t.Fatalf("failed to load spec: %v", err)
+			}
+
+			problems := checkExamples(loaded, schemaOptions(cfg))
+			if !slices.Equal(problems, tt.expected) {
+				t.Errorf("checkExamples() = %q, expected %q", problems, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunCheckExamples(t *testing.T) {
+	tests := []struct {
+		name         string
+		spec         string
+		expectedCode int
+		expectedOut  string
+	}{
+		{name: "valid examples", spec: testSpec, expectedCode: 0, expectedOut: "OK"},
+		{name: "invalid examples", spec: examplesSpec, expectedCode: 1, expectedOut: "3 example(s) don't match their schema"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cfg := DefaultConfig()
+			cfg.Spec = writeTestSpec(t, tt.spec)
+			code := runCheckExamples(&out, cfg)
+
+			if code != tt.expectedCode {
+				t.Errorf("runCheckExamples() = %d, expected %d", code, tt.expectedCode)
+			}
+			if !strings.Contains(out.String(), tt.expectedOut) {
+				t.Errorf("runCheckExamples() output %q should contain %q", out.String(), tt.expectedOut)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// FailureRecord is one line of the -failures-out NDJSON stream.
+type FailureRecord struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`
+	OperationID string    `json:"operation_id,omitempty"`
+	Status      int       `json:"status"`
+	Error       string    `json:"error"`
+	RequestID   string    `json:"request_id,omitempty"`
+}
+
+// FailureWriter appends validation failures to a file as newline-delimited
+// JSON. Writes are buffered until Close.
+type FailureWriter struct {
+	mu      sync.Mutex
+	file    *os.File
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+// openFailuresOut opens the failure stream, if one is configured.
+func openFailuresOut(path string) (*FailureWriter, error) {
+	if path == "" {
+		return nil, nil
+	}
+	return OpenFailureWriter(path)
+}
+
+func OpenFailureWriter(path string) (*FailureWriter, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600) // #nosec G304 -- path is supplied by the operator
+	if err != nil {
+		return nil, fmt.Errorf("failed to open failures file: %w", err)
+	}
+
+	buf := bufio.NewWriter(file)
+	return &FailureWriter{
+		file:    file,
+		buf:     buf,
+		encoder: json.NewEncoder(buf),
+	}, nil
+}
+
+func (w *FailureWriter) Write(record FailureRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.encoder.Encode(record)
+}
+
+func (w *FailureWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return errors.Join(w.buf.Flush(), w.file.Close())
+}
@@ -0,0 +1,84 @@
+package specgate
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readFailureRecords(t *testing.T, path string) []FailureRecord {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open failures file: %v", err)
+	}
+	defer file.Close()
+
+	var records []FailureRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record FailureRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestValidatingProxy_FailuresOut(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/users" {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": "not-a-number", "name": "test"}`))
+	}))
+	defer upstream.Close()
+
+	path := filepath.Join(t.TempDir(), "failures.ndjson")
+	if err := os.WriteFile(path, []byte(`{"status":418}`+"\n"), 0o600); err != nil {
+		t.Fatalf("failed to seed failures file: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Upstream = upstream.URL
+	cfg.FailuresOut = path
+	vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+	for _, target := range []string{"/users/1", "/users", "/users/2"} {
+		vp.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	if err := vp.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+
+	records := readFailureRecords(t, path)
+	if len(records) != 3 {
+		t.Fatalf("expected the seeded record plus 2 failures, got %d: %+v", len(records), records)
+	}
+	if records[0].Status != 418 {
+		t.Errorf("existing records should be kept, got %+v", records[0])
+	}
+
+	for _, record := range records[1:] {
+		if record.Method != http.MethodGet || record.Path != "/users/{id}" || record.OperationID != "getUser" ||
+			record.Status != http.StatusOK || record.Timestamp.IsZero() || !strings.Contains(record.Error, "id") ||
+			record.RequestID == "" {
+			t.Errorf("unexpected failure record %+v", record)
+		}
+	}
+}
+
+func TestOpenFailureWriter_InvalidPath(t *testing.T) {
+	if _, err := OpenFailureWriter(filepath.Join(t.TempDir(), "missing", "failures.ndjson")); err == nil {
+		t.Error("OpenFailureWriter() expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,116 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// Fault injects a delay, an error status or a dropped connection into a
+// fraction of the requests to operations whose path template matches
+// Pattern. Faults are evaluated in order and the first match applies.
+type Fault struct {
+	Pattern     string        `yaml:"pattern"`
+	Probability float64       `yaml:"probability"`
+	Delay       time.Duration `yaml:"delay"`
+	Status      int           `yaml:"status"`
+	Drop        bool          `yaml:"drop"`
+}
+
+func (f Fault) validate() error {
+	if err := validatePathPattern(f.Pattern); err != nil {
+		return fmt.Errorf("pattern: %w", err)
+	}
+	if f.Probability <= 0 || f.Probability > 1 {
+		return fmt.Errorf("probability must be greater than 0 and at most 1, got %g", f.Probability)
+	}
+	if f.Delay < 0 {
+		return errors.New("delay must not be negative")
+	}
+	if f.Status != 0 && (f.Status < 400 || f.Status > 599) {
+		return fmt.Errorf("status must be a 4xx or 5xx status code, got %d", f.Status)
+	}
+	if f.Status != 0 && f.Drop {
+		return errors.New("status and drop are mutually exclusive")
+	}
+	if f.Delay == 0 && f.Status == 0 && !f.Drop {
+		return errors.New("one of delay, status or drop must be set")
+	}
+	return nil
+}
+
+// injectFault applies the fault configured for r's operation, if its
+// probability hits, and reports whether the request has been answered.
+// A delay on its own lets the request continue to the upstream.
+func (vp *ValidatingProxy) injectFault(w http.ResponseWriter, r *http.Request) bool {
+	if len(vp.faults) == 0 {
+		return false
+	}
+
+	route, _, err := vp.findRoute(r)
+	if err != nil {
+		return false
+	}
+	fault, ok := vp.faultFor(route)
+	if !ok || rand.Float64() >= fault.Probability { // #nosec G404 -- fault injection doesn't need a secure source
+		return false
+	}
+
+	vp.log(r.Context()).Warn("Injecting fault",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"delay", fault.Delay,
+		"status", fault.Status,
+		"drop", fault.Drop)
+
+	if fault.Delay > 0 && !sleepContext(r.Context(), fault.Delay) {
+		return true // the client gave up while waiting
+	}
+
+	switch {
+	case fault.Drop:
+		// Aborts the handler and closes the connection without a response
+		panic(http.ErrAbortHandler)
+	case fault.Status != 0:
+		vp.writeError(w, ErrorDetails{
+			Status: fault.Status,
+			Title:  "Injected fault",
+			Detail: "This error was injected by SpecGate",
+			Method: r.Method,
+			Path:   r.URL.Path,
+		})
+		return true
+	}
+	return false
+}
+
+func (vp *ValidatingProxy) faultFor(route *routers.Route) (Fault, bool) {
+	for _, fault := range vp.faults {
+		if matchPathPattern(fault.Pattern, route.Path) {
+			return fault, true
+		}
+	}
+	return Fault{}, false
+}
@@ -0,0 +1,113 @@
+package specgate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestValidatingProxy_InjectFault(t *testing.T) {
+	tests := []struct {
+		name           string
+		fault          Fault
+		path           string
+		expectedStatus int
+		expectDrop     bool
+		minDuration    time.Duration
+	}{
+		{
+			name:           "error status",
+			fault:          Fault{Pattern: "/users/*", Probability: 1, Status: http.StatusServiceUnavailable},
+			path:           "/users/1",
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "delay then proxy",
+			fault:          Fault{Pattern: "/users/*", Probability: 1, Delay: 50 * time.Millisecond},
+			path:           "/users/1",
+			expectedStatus: http.StatusOK,
+			minDuration:    50 * time.Millisecond,
+		},
+		{
+			name:       "dropped connection",
+			fault:      Fault{Pattern: "/users/*", Probability: 1, Drop: true},
+			path:       "/users/1",
+			expectDrop: true,
+		},
+		{
+			name:           "other operation",
+			fault:          Fault{Pattern: "/users/*", Probability: 1, Status: http.StatusServiceUnavailable},
+			path:           "/users",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Path == "/users" {
+					_, _ = w.Write([]byte(`[]`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"id": 1, "name": "test"}`))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Faults = []Fault{tt.fault}
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			proxy := httptest.NewServer(vp)
+			defer proxy.Close()
+
+			start := time.Now()
+			resp, err := proxy.Client().Get(proxy.URL + tt.path)
+			if tt.expectDrop {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatalf("GET %s: expected the connection to be dropped, got status %d", tt.path, resp.StatusCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GET %s: unexpected error: %v", tt.path, err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("GET %s: status = %d, expected %d", tt.path, resp.StatusCode, tt.expectedStatus)
+			}
+			if elapsed := time.Since(start); elapsed < tt.minDuration {
+				t.Errorf("GET %s took %s, expected at least %s", tt.path, elapsed, tt.minDuration)
+			}
+		})
+	}
+}
+
+func TestFault_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		fault       Fault
+		expectError bool
+	}{
+		{name: "status", fault: Fault{Pattern: "/users/*", Probability: 0.5, Status: 503}},
+		{name: "delay and drop", fault: Fault{Pattern: "/users/**", Probability: 1, Delay: time.Second, Drop: true}},
+		{name: "missing probability", fault: Fault{Pattern: "/users/*", Status: 503}, expectError: true},
+		{name: "probability above one", fault: Fault{Pattern: "/users/*", Probability: 2, Status: 503}, expectError: true},
+		{name: "no action", fault: Fault{Pattern: "/users/*", Probability: 1}, expectError: true},
+		{name: "success status", fault: Fault{Pattern: "/users/*", Probability: 1, Status: 200}, expectError: true},
+		{name: "status and drop", fault: Fault{Pattern: "/users/*", Probability: 1, Status: 503, Drop: true}, expectError: true},
+		{name: "invalid pattern", fault: Fault{Pattern: "users", Probability: 1, Status: 503}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fault.validate(); (err != nil) != tt.expectError {
+				t.Errorf("validate() error = %v, expectError %v", err, tt.expectError)
+			}
+		})
+	}
+}
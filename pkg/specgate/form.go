@@ -0,0 +1,96 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+// requestBodyDecoders holds the decoders only used for request bodies. Only
+// requests are validated as forms, so the form decoder isn't one of the
+// response decoders.
+var requestBodyDecoders = map[string]openapi3filter.BodyDecoder{
+	"application/x-www-form-urlencoded": formBodyDecoder,
+}
+
+// formBodyDecoder decodes URL-encoded form bodies with kin-openapi's decoder,
+// which silently drops fields it can't decode, such as age=abc for an
+// integer, and fails on those instead. Empty fields count as absent.
+// Undocumented fields are kept when the schema sets additionalProperties:
+// false, so that they fail validation.
+func formBodyDecoder(body io.Reader, header http.Header, schema *openapi3.SchemaRef, encFn openapi3filter.EncodingFn) (any, error) {
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := openapi3filter.UrlencodedBodyDecoder(bytes.NewReader(raw), header, schema, encFn)
+	if err != nil {
+		return nil, err
+	}
+	form, ok := decoded.(map[string]any)
+	if !ok {
+		return decoded, nil
+	}
+	values, err := url.ParseQuery(string(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	properties, _ := objectProperties(schema.Value)
+	additional := schema.Value.AdditionalProperties
+	closed := additional.Has != nil && !*additional.Has
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		property, documented := properties[name]
+		switch {
+		case !documented && closed:
+			form[name] = values.Get(name)
+		case documented && form[name] == nil && values.Get(name) != "":
+			return nil, &formFieldError{field: name, value: values.Get(name), types: property.Type.Slice()}
+		case documented && form[name] == nil:
+			// An empty field, such as age=, counts as absent
+			delete(form, name)
+		}
+	}
+	return form, nil
+}
+
+// formFieldError reports a form field whose value doesn't decode as its
+// schema's type. The value is redacted along with the field.
+type formFieldError struct {
+	field string
+	value string
+	types []string
+}
+
+func (e *formFieldError) Error() string {
+	if len(e.types) == 0 {
+		return fmt.Sprintf("form field %q: value %q doesn't decode against its schema", e.field, e.value)
+	}
+	return fmt.Sprintf("form field %q: value %q doesn't decode as %s", e.field, e.value, strings.Join(e.types, " or "))
+}
@@ -0,0 +1,186 @@
+package specgate
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidatingProxy_FormRequests(t *testing.T) {
+	const spec = `openapi: 3.0.3
+info:
+  title: Form API
+  version: 1.0.0
+paths:
+  /signup:
+    post:
+      operationId: signUp
+      requestBody:
+        required: true
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name:
+                  type: string
+                  minLength: 2
+                age:
+                  type: integer
+                  minimum: 18
+                newsletter:
+                  type: boolean
+                interests:
+                  type: array
+                  items:
+                    type: integer
+      responses:
+        '204':
+          description: Signed up
+  /login:
+    post:
+      operationId: logIn
+      requestBody:
+        required: true
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              additionalProperties: false
+              properties:
+                user:
+                  type: string
+      responses:
+        '204':
+          description: Logged in
+`
+
+	tests := []struct {
+		name           string
+		mode           string
+		path           string
+		body           string
+		expectedStatus int
+		expectedReason string
+	}{
+		{name: "valid", path: "/signup", body: "name=Ada&age=36&newsletter=true&interests=1&interests=2", expectedStatus: http.StatusNoContent},
+		{name: "empty optional field", path: "/signup", body: "name=Ada&age=", expectedStatus: http.StatusNoContent},
+		{name: "undocumented field", path: "/signup", body: "name=Ada&referrer=ad", expectedStatus: http.StatusNoContent},
+		{name: "missing required field", path: "/signup", body: "age=36", expectedStatus: http.StatusBadRequest, expectedReason: `property \"name\" is missing`},
+		{name: "constraint violated", path: "/signup", body: "name=Ada&age=12", expectedStatus: http.StatusBadRequest, expectedReason: "number must be at least 18"},
+		{name: "invalid integer", path: "/signup", body: "name=Ada&age=old", expectedStatus: http.StatusBadRequest, expectedReason: `form field \"age\": value \"old\" doesn't decode as integer`},
+		{name: "invalid boolean", path: "/signup", body: "name=Ada&newsletter=maybe", expectedStatus: http.StatusBadRequest, expectedReason: `form field \"newsletter\"`},
+		{name: "invalid array item", path: "/signup", body: "name=Ada&interests=1&interests=two", expectedStatus: http.StatusBadRequest, expectedReason: `form field \"interests\"`},
+		{name: "invalid in warn mode", mode: "warn", path: "/signup", body: "name=Ada&age=old", expectedStatus: http.StatusNoContent, expectedReason: `form field \"age\"`},
+		{name: "undocumented field forbidden", path: "/login", body: "user=ada&admin=true", expectedStatus: http.StatusBadRequest, expectedReason: `property \"admin\" is unsupported`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var upstreamBody []byte
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				upstreamBody, _ = io.ReadAll(r.Body)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer upstream.Close()
+
+			mode := tt.mode
+			if mode == "" {
+				mode = "strict"
+			}
+			vp := newTestProxy(t, spec, upstream.URL, mode)
+			vp.requests.enabled = true
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("ServeHTTP() status = %d, expected %d, logs: %s", rec.Code, tt.expectedStatus, logs.String())
+			}
+			if !strings.Contains(logs.String(), tt.expectedReason) {
+				t.Errorf("logs should contain %q, got: %s", tt.expectedReason, logs.String())
+			}
+			if rec.Code == http.StatusNoContent && string(upstreamBody) != tt.body {
+				t.Errorf("upstream received %q, expected the original %q", upstreamBody, tt.body)
+			}
+		})
+	}
+}
+
+func TestFormFieldError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      *formFieldError
+		expected string
+	}{
+		{name: "typed", err: &formFieldError{field: "age", value: "old", types: []string{"integer"}}, expected: `form field "age": value "old" doesn't decode as integer`},
+		{name: "several types", err: &formFieldError{field: "age", value: "old", types: []string{"integer", "boolean"}}, expected: `form field "age": value "old" doesn't decode as integer or boolean`},
+		{name: "untyped", err: &formFieldError{field: "age", value: "old"}, expected: `form field "age": value "old" doesn't decode against its schema`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.expected {
+				t.Errorf("Error() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_RedactFormFields(t *testing.T) {
+	const spec = `openapi: 3.0.3
+info:
+  title: Form API
+  version: 1.0.0
+paths:
+  /pin:
+    post:
+      requestBody:
+        required: true
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              properties:
+                pin:
+                  type: integer
+      responses:
+        '204':
+          description: Changed
+`
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultConfig()
+	cfg.Upstream = upstream.URL
+	cfg.Mode = "strict"
+	cfg.ValidateRequests = true
+	cfg.RedactFields = FieldList{"pin"}
+	vp := newTestProxyWithConfig(t, spec, cfg)
+	var logs bytes.Buffer
+	vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+	req := httptest.NewRequest(http.MethodPost, "/pin", strings.NewReader("pin=s3cret"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	vp.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, expected %d, logs: %s", rec.Code, http.StatusBadRequest, logs.String())
+	}
+	if output := logs.String() + rec.Body.String(); strings.Contains(output, "s3cret") || !strings.Contains(logs.String(), `value \"***\"`) {
+		t.Errorf("form field value should be redacted, got logs: %s body: %s", logs.String(), rec.Body.String())
+	}
+}
@@ -0,0 +1,68 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"fmt"
+	"maps"
+	"net/mail"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// strictFormatValidators checks the common string formats kin-openapi
+// doesn't enforce on its own. date and date-time are always enforced.
+func strictFormatValidators() map[string]openapi3.StringFormatValidator {
+	return map[string]openapi3.StringFormatValidator{
+		"uuid":  openapi3.NewRegexpFormatValidator(openapi3.FormatOfStringForUUIDOfRFC9562),
+		"email": openapi3.NewCallbackValidator(validateEmail),
+		"ipv4":  openapi3.NewIPValidator(true),
+		"ipv6":  openapi3.NewIPValidator(false),
+	}
+}
+
+// validateEmail accepts a bare address, without a display name or angle
+// brackets.
+func validateEmail(value string) error {
+	if address, err := mail.ParseAddress(value); err != nil || address.Address != value {
+		return fmt.Errorf("not a valid email address")
+	}
+	return nil
+}
+
+// FormatPatterns maps custom string format names to the regular expressions
+// values of that format must match.
+type FormatPatterns map[string]string
+
+// schemaOptions returns the options request and response bodies are
+// validated against their schemas with.
+func schemaOptions(cfg *Config) []openapi3.SchemaValidationOption {
+	validators := make(map[string]openapi3.StringFormatValidator)
+	if cfg.StrictFormats {
+		maps.Copy(validators, strictFormatValidators())
+	}
+	for name, pattern := range cfg.StringFormats {
+		validators[name] = openapi3.NewRegexpFormatValidator(pattern)
+	}
+
+	if len(validators) == 0 {
+		return nil
+	}
+	return []openapi3.SchemaValidationOption{openapi3.WithStringFormatValidators(validators)}
+}
@@ -0,0 +1,110 @@
+package specgate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const formatsSpec = `openapi: 3.0.3
+info:
+  title: Formats API
+  version: 1.0.0
+paths:
+  /users/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        "200":
+          description: A user
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  id:
+                    type: string
+                    format: uuid
+                  email:
+                    type: string
+                    format: email
+                  ip:
+                    type: string
+                    format: ipv4
+                  created:
+                    type: string
+                    format: date-time
+                  phone:
+                    type: string
+                    format: phone-e164
+`
+
+func TestValidatingProxy_StringFormats(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		strictFormats  bool
+		stringFormats  FormatPatterns
+		expectedStatus int
+	}{
+		{name: "malformed uuid unchecked by default", body: `{"id": "not-a-uuid"}`, expectedStatus: http.StatusOK},
+		{name: "malformed date-time always checked", body: `{"created": "yesterday"}`, expectedStatus: http.StatusInternalServerError},
+		{
+			name:           "valid formats",
+			body:           `{"id": "3f2b8c1e-9d4a-4f6b-8e2a-1c5d7e9f0a3b", "email": "ada@example.com", "ip": "10.0.0.1", "created": "2025-01-02T03:04:05Z"}`,
+			strictFormats:  true,
+			expectedStatus: http.StatusOK,
+		},
+		{name: "malformed uuid", body: `{"id": "not-a-uuid"}`, strictFormats: true, expectedStatus: http.StatusInternalServerError},
+		{name: "malformed email", body: `{"email": "Ada <ada@example.com>"}`, strictFormats: true, expectedStatus: http.StatusInternalServerError},
+		{name: "malformed ipv4", body: `{"ip": "10.0.0.300"}`, strictFormats: true, expectedStatus: http.StatusInternalServerError},
+		{name: "unknown custom format unchecked", body: `{"phone": "555-1234"}`, expectedStatus: http.StatusOK},
+		{
+			name:           "valid custom format",
+			body:           `{"phone": "+14155550123"}`,
+			stringFormats:  FormatPatterns{"phone-e164": `^\+[1-9][0-9]{1,14}$`},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "malformed custom format",
+			body:           `{"phone": "555-1234"}`,
+			stringFormats:  FormatPatterns{"phone-e164": `^\+[1-9][0-9]{1,14}$`},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "custom format overrides built-in",
+			body:           `{"id": "USR-42"}`,
+			strictFormats:  true,
+			stringFormats:  FormatPatterns{"uuid": `^USR-[0-9]+$`},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			cfg.StrictFormats = tt.strictFormats
+			cfg.StringFormats = tt.stringFormats
+			vp := newTestProxyWithConfig(t, formatsSpec, cfg)
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+func (vp *ValidatingProxy) direct(req *http.Request) {
+	inboundHost := req.Host
+	vp.rewriteRequest(req)
+	vp.setForwardedHeaders(req, inboundHost)
+}
+
+func (vp *ValidatingProxy) setForwardedHeaders(req *http.Request, inboundHost string) {
+	if !vp.xForwardedHeaders {
+		// A nil value stops ReverseProxy from appending the client IP itself
+		req.Header["X-Forwarded-For"] = nil
+	} else {
+		// X-Forwarded-For is appended by ReverseProxy after the Director runs
+		req.Header.Set("X-Forwarded-Host", inboundHost)
+		req.Header.Set("X-Forwarded-Proto", requestScheme(req))
+	}
+
+	if vp.forwardedHeader {
+		req.Header.Add("Forwarded", forwardedElement(req, inboundHost))
+	}
+}
+
+func requestScheme(req *http.Request) string {
+	if req.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// forwardedElement builds an RFC 7239 Forwarded element for the inbound hop.
+func forwardedElement(req *http.Request, inboundHost string) string {
+	var params []string
+	if clientIP, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		if strings.Contains(clientIP, ":") {
+			clientIP = `"[` + clientIP + `]"`
+		}
+		params = append(params, "for="+clientIP)
+	}
+	if inboundHost != "" {
+		params = append(params, `host="`+inboundHost+`"`)
+	}
+	params = append(params, "proto="+requestScheme(req))
+	return strings.Join(params, ";")
+}
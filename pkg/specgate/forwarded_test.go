@@ -0,0 +1,90 @@
+package specgate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidatingProxy_ForwardedHeaders(t *testing.T) {
+	tests := []struct {
+		name              string
+		xForwarded        bool
+		forwarded         bool
+		remoteAddr        string
+		priorFor          string
+		expectedFor       string
+		expectedHost      string
+		expectedProto     string
+		expectedForwarded string
+	}{
+		{
+			name:          "x-forwarded headers by default",
+			xForwarded:    true,
+			remoteAddr:    "192.0.2.1:1234",
+			expectedFor:   "192.0.2.1",
+			expectedHost:  "proxy.example.com",
+			expectedProto: "http",
+		},
+		{
+			name:          "client IP appended to existing chain",
+			xForwarded:    true,
+			remoteAddr:    "192.0.2.1:1234",
+			priorFor:      "203.0.113.7",
+			expectedFor:   "203.0.113.7, 192.0.2.1",
+			expectedHost:  "proxy.example.com",
+			expectedProto: "http",
+		},
+		{
+			name:       "disabled",
+			remoteAddr: "192.0.2.1:1234",
+		},
+		{
+			name:              "forwarded header",
+			forwarded:         true,
+			remoteAddr:        "192.0.2.1:1234",
+			expectedForwarded: `for=192.0.2.1;host="proxy.example.com";proto=http`,
+		},
+		{
+			name:              "forwarded header with IPv6 client",
+			forwarded:         true,
+			remoteAddr:        "[2001:db8::1]:1234",
+			expectedForwarded: `for="[2001:db8::1]";host="proxy.example.com";proto=http`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received http.Header
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r.Header
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.XForwardedHeaders = tt.xForwarded
+			cfg.ForwardedHeader = tt.forwarded
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			req := httptest.NewRequest(http.MethodGet, "http://proxy.example.com/users", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.priorFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.priorFor)
+			}
+			vp.ServeHTTP(httptest.NewRecorder(), req)
+
+			for header, expected := range map[string]string{
+				"X-Forwarded-For":   tt.expectedFor,
+				"X-Forwarded-Host":  tt.expectedHost,
+				"X-Forwarded-Proto": tt.expectedProto,
+				"Forwarded":         tt.expectedForwarded,
+			} {
+				if got := received.Get(header); got != expected {
+					t.Errorf("%s = %q, expected %q", header, got, expected)
+				}
+			}
+		})
+	}
+}
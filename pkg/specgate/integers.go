@@ -0,0 +1,97 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// integerRangeError lists, as JSON pointers with their format, the integers
+// in a response body that don't fit the format their schema gives them.
+type integerRangeError []string
+
+func (e integerRangeError) Error() string {
+	return "response body has integers outside the range of their format: " + strings.Join(e, ", ")
+}
+
+// checkExactIntegers checks the integers in a response body against the
+// int32 and int64 formats without going through float64, which kin-openapi
+// converts every number to. Beyond 2^53 float64 can't tell neighbouring
+// integers apart, so a value just past the int64 range passes validation.
+func checkExactIntegers(decoders *bodyDecoders, route *routers.Route, resp *http.Response, body []byte) error {
+	schema, value, ok := decoders.decodeDocumentedBody(route, resp, body)
+	if !ok {
+		return nil
+	}
+	if outOfRange := integersOutOfRange(schema, value, ""); len(outOfRange) > 0 {
+		return integerRangeError(outOfRange)
+	}
+	return nil
+}
+
+func integersOutOfRange(schema *openapi3.Schema, value any, pointer string) []string {
+	var outOfRange []string
+	switch v := value.(type) {
+	case map[string]any:
+		properties, _ := objectProperties(schema)
+		for _, name := range componentNames(v) {
+			if property, ok := properties[name]; ok {
+				outOfRange = append(outOfRange, integersOutOfRange(property, v[name], pointer+"/"+escapeJSONPointer(name))...)
+			}
+		}
+	case []any:
+		if schema.Items == nil || schema.Items.Value == nil {
+			return nil
+		}
+		for i, item := range v {
+			outOfRange = append(outOfRange, integersOutOfRange(schema.Items.Value, item, pointer+"/"+strconv.Itoa(i))...)
+		}
+	case json.Number:
+		if schema.Type.Is(openapi3.TypeInteger) && !fitsIntegerFormat(v, schema.Format) {
+			outOfRange = append(outOfRange, pointer+" ("+schema.Format+")")
+		}
+	}
+	return outOfRange
+}
+
+func fitsIntegerFormat(number json.Number, format string) bool {
+	// Numbers such as 1e3 are integers too, so parse them exactly as rationals
+	r, ok := new(big.Rat).SetString(number.String())
+	if !ok || !r.IsInt() {
+		return true // not an integer, which schema validation reports
+	}
+
+	n := r.Num()
+	switch format {
+	case "int64":
+		return n.IsInt64()
+	case "int32":
+		return n.IsInt64() && n.Int64() >= math.MinInt32 && n.Int64() <= math.MaxInt32
+	default:
+		return true
+	}
+}
@@ -0,0 +1,108 @@
+package specgate
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidatingProxy_ExactIntegers(t *testing.T) {
+	const spec = `openapi: 3.0.3
+info:
+  title: Integer API
+  version: 1.0.0
+paths:
+  /accounts/{id}:
+    get:
+      operationId: getAccount
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: An account
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  balance:
+                    type: integer
+                    format: int64
+                  version:
+                    type: integer
+                    format: int32
+                  ledger:
+                    type: array
+                    items:
+                      type: integer
+                      format: int64
+`
+
+	tests := []struct {
+		name           string
+		exactIntegers  bool
+		body           string
+		expectedStatus int
+		expectedLog    string
+	}{
+		{name: "largest int64", body: `{"balance": 9223372036854775807}`, expectedStatus: http.StatusOK},
+		{name: "largest int64 checked exactly", exactIntegers: true, body: `{"balance": 9223372036854775807}`, expectedStatus: http.StatusOK},
+		{name: "beyond float64 precision", exactIntegers: true, body: `{"balance": 9007199254740993}`, expectedStatus: http.StatusOK},
+		{name: "smallest int64 checked exactly", exactIntegers: true, body: `{"balance": -9223372036854775808}`, expectedStatus: http.StatusOK},
+		{name: "exponent notation", exactIntegers: true, body: `{"balance": 1e3}`, expectedStatus: http.StatusOK},
+		{name: "past int64 passes as float64", body: `{"balance": 9223372036854775808}`, expectedStatus: http.StatusOK},
+		{
+			name:           "past int64 checked exactly",
+			exactIntegers:  true,
+			body:           `{"balance": 9223372036854775808}`,
+			expectedStatus: http.StatusInternalServerError,
+			expectedLog:    "outside the range of their format: /balance (int64)",
+		},
+		{
+			name:           "below int64 in an array",
+			exactIntegers:  true,
+			body:           `{"ledger": [1, -9223372036854775809]}`,
+			expectedStatus: http.StatusInternalServerError,
+			expectedLog:    "/ledger/1 (int64)",
+		},
+		{name: "past int32", body: `{"version": 2147483648}`, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			cfg.ExactIntegers = tt.exactIntegers
+			vp := newTestProxyWithConfig(t, spec, cfg)
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/accounts/1", nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d, logs: %s", rec.Code, tt.expectedStatus, logs.String())
+			}
+			if rec.Code == http.StatusOK && rec.Body.String() != tt.body {
+				t.Errorf("body = %s, expected it unchanged as %s", rec.Body.String(), tt.body)
+			}
+			if !strings.Contains(logs.String(), tt.expectedLog) {
+				t.Errorf("logs should contain %q, got: %s", tt.expectedLog, logs.String())
+			}
+		})
+	}
+}
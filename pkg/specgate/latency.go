@@ -0,0 +1,55 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/getkin/kin-openapi/routers"
+)
+
+type receivedAtKey struct{}
+
+func withReceivedAt(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), receivedAtKey{}, time.Now()))
+}
+
+// observeLatency records how long the request took from reaching SpecGate
+// until the upstream's response headers arrived, keyed by the operation it
+// matched, and warns when that exceeds the slow threshold.
+func (vp *ValidatingProxy) observeLatency(resp *http.Response, route *routers.Route) {
+	receivedAt, ok := resp.Request.Context().Value(receivedAtKey{}).(time.Time)
+	if !ok {
+		return
+	}
+	latency := time.Since(receivedAt)
+	vp.metrics.recordOperationLatency(route, latency)
+
+	if vp.slowThreshold > 0 && latency > vp.slowThreshold {
+		vp.log(resp.Request.Context()).Warn("Slow operation",
+			"operation", operationName(route),
+			"method", resp.Request.Method,
+			"path", resp.Request.URL.Path,
+			"status", resp.StatusCode,
+			"latency", latency,
+			"threshold", vp.slowThreshold)
+	}
+}
@@ -0,0 +1,70 @@
+package specgate
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidatingProxy_SlowThreshold(t *testing.T) {
+	tests := []struct {
+		name       string
+		threshold  time.Duration
+		path       string
+		expectWarn bool
+	}{
+		{
+			name:       "slower than threshold",
+			threshold:  10 * time.Millisecond,
+			path:       "/users/1",
+			expectWarn: true,
+		},
+		{
+			name:      "faster than threshold",
+			threshold: time.Minute,
+			path:      "/users/1",
+		},
+		{
+			name: "threshold disabled",
+			path: "/users/1",
+		},
+		{
+			name:      "undocumented endpoint",
+			threshold: 10 * time.Millisecond,
+			path:      "/health",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(20 * time.Millisecond)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id": 1, "name": "test"}`))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.SlowThreshold = tt.threshold
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			vp.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			warned := strings.Contains(logs.String(), "Slow operation")
+			if warned != tt.expectWarn {
+				t.Fatalf("slow operation warning = %v, expected %v; logs: %s", warned, tt.expectWarn, logs.String())
+			}
+			if tt.expectWarn && !strings.Contains(logs.String(), "operation=getUser") {
+				t.Errorf("expected warning to name the operation, got: %s", logs.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type LogFormat string
+
+const (
+	LogFormatColor LogFormat = "color"
+	LogFormatJSON  LogFormat = "json"
+	LogFormatText  LogFormat = "text"
+)
+
+func parseLogFormat(format string) (LogFormat, error) {
+	switch strings.ToLower(format) {
+	case "color":
+		return LogFormatColor, nil
+	case "json":
+		return LogFormatJSON, nil
+	case "text":
+		return LogFormatText, nil
+	default:
+		return "", fmt.Errorf("invalid log format '%s': must be one of 'color', 'json', or 'text'", format)
+	}
+}
+
+func parseLogLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("invalid log level '%s': must be one of 'debug', 'info', 'warn', or 'error'", level)
+	}
+}
+
+// newConfiguredLogger returns cfg.Logger, or else the logger for cfg's log
+// format and level, writing to stderr.
+func newConfiguredLogger(cfg *Config) (*slog.Logger, error) {
+	if cfg.Logger != nil {
+		return cfg.Logger, nil
+	}
+	format, err := parseLogFormat(cfg.LogFormat)
+	if err != nil {
+		return nil, err
+	}
+	level, err := parseLogLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	return newLogger(format, os.Stderr, level), nil
+}
+
+func newLogger(format LogFormat, output io.Writer, level slog.Level) *slog.Logger {
+	switch format {
+	case LogFormatJSON:
+		return slog.New(slog.NewJSONHandler(output, &slog.HandlerOptions{Level: level}))
+	case LogFormatText:
+		return slog.New(slog.NewTextHandler(output, &slog.HandlerOptions{Level: level}))
+	default:
+		return slog.New(NewColoredHandler(output, level))
+	}
+}
@@ -0,0 +1,172 @@
+package specgate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseLogFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    LogFormat
+		expectError bool
+	}{
+		{
+			name:     "color",
+			input:    "color",
+			expected: LogFormatColor,
+		},
+		{
+			name:     "json",
+			input:    "json",
+			expected: LogFormatJSON,
+		},
+		{
+			name:     "uppercase text",
+			input:    "TEXT",
+			expected: LogFormatText,
+		},
+		{
+			name:        "invalid format",
+			input:       "xml",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseLogFormat(tt.input)
+			if (err != nil) != tt.expectError {
+				t.Errorf("parseLogFormat() error = %v, expectError %v", err, tt.expectError)
+				return
+			}
+			if result != tt.expected {
+				t.Errorf("parseLogFormat() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    slog.Level
+		expectError bool
+	}{
+		{name: "debug", input: "debug", expected: slog.LevelDebug},
+		{name: "info", input: "info", expected: slog.LevelInfo},
+		{name: "uppercase warn", input: "WARN", expected: slog.LevelWarn},
+		{name: "error", input: "error", expected: slog.LevelError},
+		{name: "invalid level", input: "verbose", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseLogLevel(tt.input)
+			if (err != nil) != tt.expectError {
+				t.Errorf("parseLogLevel() error = %v, expectError %v", err, tt.expectError)
+				return
+			}
+			if result != tt.expected {
+				t.Errorf("parseLogLevel() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_DebugLogging(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users/2" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": 1, "name": "test"}`))
+	}))
+	defer upstream.Close()
+
+	vp := newTestProxy(t, testSpec, upstream.URL, "warn")
+	var logs bytes.Buffer
+	vp.logger = newLogger(LogFormatText, &logs, slog.LevelDebug)
+
+	vp.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	vp.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/2", nil))
+
+	for _, expected := range []string{
+		`operation=getUser mode=warn valid=true`,
+		`method=GET path=/users/1 template=/users/{id} operation=getUser path_params=map[id:1]`,
+		`reason="content type not validated" method=GET path=/users/2 status=404`,
+	} {
+		if !strings.Contains(logs.String(), expected) {
+			t.Errorf("logs = %q, expected them to contain %q", logs.String(), expected)
+		}
+	}
+}
+
+func TestNewLogger_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newLogger(LogFormatJSON, &buf, slog.LevelInfo)
+
+	logger.Error("Response validation failed",
+		"error", errors.New("property \"id\" is missing"),
+		"method", "GET",
+		"path", "/users/1",
+		"status", 200)
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected a single JSON log line, got %q: %v", buf.String(), err)
+	}
+
+	expected := map[string]any{
+		"msg":    "Response validation failed",
+		"level":  "ERROR",
+		"error":  "property \"id\" is missing",
+		"method": "GET",
+		"path":   "/users/1",
+		"status": float64(200),
+	}
+	for key, value := range expected {
+		if record[key] != value {
+			t.Errorf("JSON log field %q = %v, expected %v", key, record[key], value)
+		}
+	}
+}
+
+func TestNewLogger_Formats(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   LogFormat
+		expected string
+	}{
+		{
+			name:     "text",
+			format:   LogFormatText,
+			expected: "level=WARN msg=\"Undocumented endpoint\" path=/unknown",
+		},
+		{
+			name:     "color",
+			format:   LogFormatColor,
+			expected: colorYellow + "WARN" + colorReset + " Undocumented endpoint path=/unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			newLogger(tt.format, &buf, slog.LevelInfo).Warn("Undocumented endpoint", "path", "/unknown")
+
+			if !strings.Contains(buf.String(), tt.expected) {
+				t.Errorf("log output = %q, expected it to contain %q", buf.String(), tt.expected)
+			}
+		})
+	}
+}
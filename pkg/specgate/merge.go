@@ -0,0 +1,179 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+var pathParamPattern = regexp.MustCompile(`\{[^}]*\}`)
+
+// specMerger merges specs split by domain into the first of them. Each
+// path may be defined by only one spec, while components may be shared as
+// long as every spec that defines one defines it identically. Document-level
+// fields such as info and tags come from the first spec.
+type specMerger struct {
+	first     string
+	merged    *openapi3.T
+	origins   map[string]string // path or component key -> spec that defined it
+	conflicts []error
+}
+
+// mergeSpecs merges specs, named by where they were loaded from, reporting
+// every conflict between them at once.
+func mergeSpecs(names []string, specs []*openapi3.T) (*openapi3.T, error) {
+	m := &specMerger{first: names[0], merged: specs[0], origins: make(map[string]string)}
+	if m.merged.Paths == nil {
+		m.merged.Paths = openapi3.NewPaths()
+	}
+	if m.merged.Components == nil {
+		m.merged.Components = &openapi3.Components{}
+	}
+	m.claim(names[0], specs[0])
+
+	for i := 1; i < len(specs); i++ {
+		m.add(names[i], specs[i])
+	}
+
+	if len(m.conflicts) > 0 {
+		return nil, fmt.Errorf("failed to merge specs: %w", errors.Join(m.conflicts...))
+	}
+	return m.merged, nil
+}
+
+// claim records what the first spec defines, so later specs conflict with it.
+func (m *specMerger) claim(name string, spec *openapi3.T) {
+	for _, path := range spec.Paths.Keys() {
+		m.origins[pathKey(path)] = name
+	}
+	c := spec.Components
+	for kind, names := range map[string][]string{
+		"schema":          componentNames(c.Schemas),
+		"parameter":       componentNames(c.Parameters),
+		"header":          componentNames(c.Headers),
+		"request body":    componentNames(c.RequestBodies),
+		"response":        componentNames(c.Responses),
+		"security scheme": componentNames(c.SecuritySchemes),
+		"example":         componentNames(c.Examples),
+		"link":            componentNames(c.Links),
+		"callback":        componentNames(c.Callbacks),
+	} {
+		for _, component := range names {
+			m.origins[kind+" "+component] = name
+		}
+	}
+}
+
+func (m *specMerger) add(name string, spec *openapi3.T) {
+	if openAPIMinorVersion(spec.OpenAPI) != openAPIMinorVersion(m.merged.OpenAPI) {
+		m.conflicts = append(m.conflicts, fmt.Errorf("%s is OpenAPI %s but %s is OpenAPI %s",
+			name, spec.OpenAPI, m.first, m.merged.OpenAPI))
+	}
+
+	for _, path := range spec.Paths.Keys() {
+		key := pathKey(path)
+		if origin, ok := m.origins[key]; ok {
+			m.conflicts = append(m.conflicts, fmt.Errorf("path %s is defined in both %s and %s", path, origin, name))
+			continue
+		}
+		m.origins[key] = name
+
+		// Operations inherit their own spec's top-level security, not the first spec's
+		item := spec.Paths.Value(path)
+		for _, operation := range item.Operations() {
+			if operation.Security == nil {
+				security := append(openapi3.SecurityRequirements{}, spec.Security...)
+				operation.Security = &security
+			}
+		}
+		m.merged.Paths.Set(path, item)
+	}
+
+	if spec.Components == nil {
+		return
+	}
+	c, dst := spec.Components, m.merged.Components
+	dst.Schemas = mergeComponents(m, name, "schema", dst.Schemas, c.Schemas)
+	dst.Parameters = mergeComponents(m, name, "parameter", dst.Parameters, c.Parameters)
+	dst.Headers = mergeComponents(m, name, "header", dst.Headers, c.Headers)
+	dst.RequestBodies = mergeComponents(m, name, "request body", dst.RequestBodies, c.RequestBodies)
+	dst.Responses = mergeComponents(m, name, "response", dst.Responses, c.Responses)
+	dst.SecuritySchemes = mergeComponents(m, name, "security scheme", dst.SecuritySchemes, c.SecuritySchemes)
+	dst.Examples = mergeComponents(m, name, "example", dst.Examples, c.Examples)
+	dst.Links = mergeComponents(m, name, "link", dst.Links, c.Links)
+	dst.Callbacks = mergeComponents(m, name, "callback", dst.Callbacks, c.Callbacks)
+}
+
+// mergeComponents adds the components of one kind from the spec called name
+// to dst. A component already defined identically is shared; one defined
+// differently is a conflict.
+func mergeComponents[M ~map[string]V, V any](m *specMerger, name, kind string, dst, src M) M {
+	for _, component := range componentNames(src) {
+		key := kind + " " + component
+		if existing, ok := dst[component]; ok {
+			if !sameComponent(existing, src[component]) {
+				m.conflicts = append(m.conflicts, fmt.Errorf("%s %q is defined differently in %s and %s", kind, component, m.origins[key], name))
+			}
+			continue
+		}
+
+		if dst == nil {
+			dst = make(M)
+		}
+		dst[component] = src[component]
+		m.origins[key] = name
+	}
+	return dst
+}
+
+func sameComponent(a, b any) bool {
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && string(aJSON) == string(bJSON)
+}
+
+func componentNames[M ~map[string]V, V any](components M) []string {
+	names := make([]string, 0, len(components))
+	for name := range components {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// pathKey identifies a path template regardless of its parameter names, since
+// /users/{id} and /users/{userId} match the same requests.
+func pathKey(path string) string {
+	return "path " + pathParamPattern.ReplaceAllString(path, "{}")
+}
+
+func openAPIMinorVersion(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+	return parts[0] + "." + parts[1]
+}
@@ -0,0 +1,255 @@
+package specgate
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+const usersDomainSpec = `openapi: 3.0.3
+info:
+  title: Users
+  version: 1.0.0
+security:
+  - bearer: []
+paths:
+  /users/{id}:
+    get:
+      operationId: getUser
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: A user
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/User"
+        default:
+          description: Error
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Error"
+components:
+  securitySchemes:
+    bearer:
+      type: http
+      scheme: bearer
+  schemas:
+    User:
+      type: object
+      required: [name]
+    Error:
+      type: object
+      required: [message]
+`
+
+const ordersDomainSpec = `openapi: 3.0.3
+info:
+  title: Orders
+  version: 1.0.0
+paths:
+  /orders/{id}:
+    get:
+      operationId: getOrder
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: An order
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Order"
+        default:
+          description: Error
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Error"
+components:
+  schemas:
+    Order:
+      type: object
+      required: [total]
+    Error:
+      type: object
+      required: [message]
+`
+
+func TestSpecLoader_MergesSpecs(t *testing.T) {
+	loader := specLoader{
+		upstreamURLs: []string{"http://localhost:3000"},
+		router:       RouterGorillaMux,
+		logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	specPath := writeTestSpec(t, usersDomainSpec) + ", " + writeTestSpec(t, ordersDomainSpec)
+
+	spec, router, err := loader.load(specPath)
+	if err != nil {
+		t.Fatalf("load() unexpected error: %v", err)
+	}
+
+	if spec.Info.Title != "Users" {
+		t.Errorf("info title = %q, expected the first spec's", spec.Info.Title)
+	}
+	for _, schema := range []string{"User", "Order", "Error"} {
+		if spec.Components.Schemas[schema] == nil {
+			t.Errorf("merged spec is missing schema %s", schema)
+		}
+	}
+
+	for _, tt := range []struct {
+		path             string
+		expectedSecurity int
+	}{
+		{path: "/users/1", expectedSecurity: 1},
+		{path: "/orders/1", expectedSecurity: 0},
+	} {
+		route, _, err := router.FindRoute(httptest.NewRequest(http.MethodGet, "http://localhost:3000"+tt.path, nil))
+		if err != nil {
+			t.Errorf("FindRoute(%s) unexpected error: %v", tt.path, err)
+			continue
+		}
+		security := spec.Security
+		if route.Operation.Security != nil {
+			security = *route.Operation.Security
+		}
+		if len(security) != tt.expectedSecurity {
+			t.Errorf("%s has %d security requirements, expected %d from its own spec", tt.path, len(security), tt.expectedSecurity)
+		}
+	}
+}
+
+func TestSpecLoader_MergeConflicts(t *testing.T) {
+	tests := []struct {
+		name           string
+		second         string
+		expectedErrors []string
+	}{
+		{
+			name:           "same path",
+			second:         strings.ReplaceAll(ordersDomainSpec, "/orders/{id}", "/users/{id}"),
+			expectedErrors: []string{"path /users/{id} is defined in both"},
+		},
+		{
+			name:           "same path with different parameter names",
+			second:         strings.ReplaceAll(ordersDomainSpec, "/orders/{id}", "/users/{userId}"),
+			expectedErrors: []string{"path /users/{userId} is defined in both"},
+		},
+		{
+			name:           "different component with the same name",
+			second:         strings.Replace(ordersDomainSpec, "required: [message]", "required: [error]", 1),
+			expectedErrors: []string{`schema "Error" is defined differently`},
+		},
+		{
+			name:           "different OpenAPI versions",
+			second:         strings.Replace(ordersDomainSpec, "openapi: 3.0.3", "openapi: 3.1.0", 1),
+			expectedErrors: []string{"is OpenAPI 3.1.0"},
+		},
+		{
+			name: "every conflict is reported",
+			second: strings.Replace(strings.ReplaceAll(ordersDomainSpec, "/orders/{id}", "/users/{id}"),
+				"required: [message]", "required: [error]", 1),
+			expectedErrors: []string{"path /users/{id}", `schema "Error"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := specLoader{
+				upstreamURLs: []string{"http://localhost:3000"},
+				router:       RouterGorillaMux,
+				logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+			first := writeTestSpec(t, usersDomainSpec)
+			second := writeTestSpec(t, tt.second)
+
+			_, _, err := loader.load(first + "," + second)
+			if err == nil {
+				t.Fatal("load() expected a merge conflict")
+			}
+			for _, expected := range append(tt.expectedErrors, first, second) {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("load() error = %v, expected it to contain %q", err, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_MergedSpecs(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name": "not an order"}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultConfig()
+	cfg.Upstream = upstream.URL
+	cfg.Mode = "strict"
+	cfg.Spec = writeTestSpec(t, usersDomainSpec) + "," + writeTestSpec(t, ordersDomainSpec)
+	vp, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	vp.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tt := range []struct {
+		path           string
+		expectedStatus int
+	}{
+		{path: "/users/1", expectedStatus: http.StatusOK},
+		{path: "/orders/1", expectedStatus: http.StatusInternalServerError},
+	} {
+		rec := httptest.NewRecorder()
+		vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.expectedStatus {
+			t.Errorf("%s: status = %d, expected %d", tt.path, rec.Code, tt.expectedStatus)
+		}
+	}
+}
+
+func TestValidatingProxy_WatchMergedSpecs(t *testing.T) {
+	cfg := DefaultConfig()
+	users := writeTestSpec(t, usersDomainSpec)
+	orders := writeTestSpec(t, ordersDomainSpec)
+	cfg.Spec = users + "," + orders
+	vp, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	vp.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := vp.WatchSpec(ctx); err != nil {
+		t.Fatalf("WatchSpec() unexpected error: %v", err)
+	}
+
+	updated := strings.ReplaceAll(ordersDomainSpec, "/orders/{id}", "/carts/{id}")
+	if err := os.WriteFile(orders, []byte(updated), 0o600); err != nil {
+		t.Fatalf("failed to update spec: %v", err)
+	}
+
+	if !waitFor(t, func() bool { return routeExists(vp, "/carts/1") }) {
+		t.Fatal("merged spec was not reloaded after the second file changed")
+	}
+	if !routeExists(vp, "/users/1") {
+		t.Error("reloaded spec should still include the first file")
+	}
+}
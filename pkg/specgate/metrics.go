@@ -0,0 +1,127 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type Metrics struct {
+	registry           *prometheus.Registry
+	requestsProxied    prometheus.Counter
+	responsesValidated prometheus.Counter
+	validationFailures *prometheus.CounterVec
+	requestFailures    *prometheus.CounterVec
+	deprecatedCalls    *prometheus.CounterVec
+	upstreamLatency    prometheus.Histogram
+	operationLatency   *prometheus.HistogramVec
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requestsProxied: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "specgate_requests_proxied_total",
+			Help: "Total number of requests proxied to the upstream.",
+		}),
+		responsesValidated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "specgate_responses_validated_total",
+			Help: "Total number of responses validated against the spec.",
+		}),
+		validationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "specgate_validation_failures_total",
+			Help: "Total number of responses that failed validation.",
+		}, []string{"operation_id", "path", "status"}),
+		requestFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "specgate_request_validation_failures_total",
+			Help: "Total number of requests that failed validation.",
+		}, []string{"operation_id", "path"}),
+		deprecatedCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "specgate_deprecated_calls_total",
+			Help: "Total number of calls to operations marked deprecated in the spec.",
+		}, []string{"operation_id", "path"}),
+		upstreamLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "specgate_upstream_latency_seconds",
+			Help:    "Time until the upstream returned response headers.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		operationLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "specgate_operation_latency_seconds",
+			Help:    "Time from receiving a request until the upstream returned response headers, by operation.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"operation_id", "path"}),
+	}
+
+	m.registry.MustRegister(
+		m.requestsProxied,
+		m.responsesValidated,
+		m.validationFailures,
+		m.requestFailures,
+		m.deprecatedCalls,
+		m.upstreamLatency,
+		m.operationLatency,
+	)
+
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) recordValidationFailure(route *routers.Route, status int) {
+	m.validationFailures.WithLabelValues(operationID(route), route.Path, strconv.Itoa(status)).Inc()
+}
+
+func (m *Metrics) recordRequestFailure(route *routers.Route) {
+	m.requestFailures.WithLabelValues(operationID(route), route.Path).Inc()
+}
+
+func (m *Metrics) recordDeprecatedCall(route *routers.Route) {
+	m.deprecatedCalls.WithLabelValues(operationID(route), route.Path).Inc()
+}
+
+func (m *Metrics) recordOperationLatency(route *routers.Route, latency time.Duration) {
+	m.operationLatency.WithLabelValues(operationID(route), route.Path).Observe(latency.Seconds())
+}
+
+func operationID(route *routers.Route) string {
+	if route.Operation == nil {
+		return ""
+	}
+	return route.Operation.OperationID
+}
+
+type metricsTransport struct {
+	next    http.RoundTripper
+	metrics *Metrics
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	t.metrics.upstreamLatency.Observe(time.Since(start).Seconds())
+	return resp, err
+}
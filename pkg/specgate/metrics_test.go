@@ -0,0 +1,84 @@
+package specgate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestValidatingProxy_Metrics(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/users/1" {
+			_, _ = w.Write([]byte(`{"id": 1, "name": "test"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": "wrong"}`))
+	}))
+	defer upstream.Close()
+
+	vp := newTestProxy(t, testSpec, upstream.URL, "warn")
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/3"} {
+		vp.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if got := testutil.ToFloat64(vp.metrics.requestsProxied); got != 3 {
+		t.Errorf("requests proxied = %v, expected 3", got)
+	}
+	if got := testutil.ToFloat64(vp.metrics.responsesValidated); got != 3 {
+		t.Errorf("responses validated = %v, expected 3", got)
+	}
+	if got := testutil.ToFloat64(vp.metrics.validationFailures.WithLabelValues("getUser", "/users/{id}", "200")); got != 2 {
+		t.Errorf("validation failures = %v, expected 2", got)
+	}
+	if got := testutil.CollectAndCount(vp.metrics.upstreamLatency); got != 1 {
+		t.Errorf("upstream latency collected %d metrics, expected 1", got)
+	}
+
+	rec := httptest.NewRecorder()
+	vp.metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	for _, expected := range []string{
+		"specgate_requests_proxied_total 3",
+		`specgate_validation_failures_total{operation_id="getUser",path="/users/{id}",status="200"} 2`,
+		"specgate_upstream_latency_seconds_count 3",
+		`specgate_operation_latency_seconds_count{operation_id="getUser",path="/users/{id}"} 3`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("metrics output missing %q", expected)
+		}
+	}
+}
+
+func TestValidatingProxy_RequestValidationMetrics(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultConfig()
+	cfg.Upstream = upstream.URL
+	cfg.Mode = "warn"
+	cfg.ValidateRequests = true
+	vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+	for _, body := range []string{`{"name": "test"}`, `{"name": 42}`, `{}`} {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		vp.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if got := testutil.ToFloat64(vp.metrics.requestFailures.WithLabelValues("createUser", "/users")); got != 2 {
+		t.Errorf("request validation failures = %v, expected 2", got)
+	}
+	if got := testutil.ToFloat64(vp.metrics.requestsProxied); got != 3 {
+		t.Errorf("requests proxied = %v, expected invalid requests to be forwarded in warn mode", got)
+	}
+}
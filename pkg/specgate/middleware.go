@@ -0,0 +1,73 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+)
+
+// Middleware returns middleware that validates the responses of the
+// handlers it wraps against cfg.Spec. cfg.Upstream is only used to route
+// requests to operations. Use New and ValidatingProxy.Middleware instead to
+// close the proxy on shutdown.
+func Middleware(cfg *Config) (func(http.Handler) http.Handler, error) {
+	vp, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return vp.Middleware, nil
+}
+
+// Middleware validates the responses of next as if next were the upstream.
+// Responses are buffered in full before they're validated, so handlers that
+// stream or hijack the connection aren't supported.
+func (vp *ValidatingProxy) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, span := startProxySpan(vp.withRequestID(w, withReceivedAt(withClientPath(r))))
+		defer span.End()
+
+		if vp.requests.enabled && !vp.checkRequest(w, r) {
+			return
+		}
+
+		rec := httptest.NewRecorder()
+		next.ServeHTTP(rec, r)
+
+		resp := rec.Result()
+		resp.ContentLength = int64(rec.Body.Len())
+		// Responses are routed as if they came from the upstream
+		resp.Request = r.Clone(r.Context())
+		vp.rewriteRequest(resp.Request)
+		if err := vp.validateResponse(resp); err != nil {
+			vp.log(r.Context()).Error("Failed to validate handler response", "error", err)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		for name, values := range resp.Header {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(resp.StatusCode)
+		if _, err := io.Copy(w, resp.Body); err != nil {
+			vp.log(r.Context()).Warn("Failed to write handler response", "error", err)
+		}
+	})
+}
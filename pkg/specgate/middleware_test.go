@@ -0,0 +1,92 @@
+package specgate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidatingProxy_Middleware(t *testing.T) {
+	tests := []struct {
+		name             string
+		mode             string
+		validateRequests bool
+		method           string
+		target           string
+		body             string
+		expectedStatus   int
+		expectedBody     string
+		expectCalled     bool
+	}{
+		{name: "valid response", mode: "strict", method: http.MethodGet, target: "/users/1", body: `{"id": 1, "name": "Ann"}`, expectedStatus: http.StatusOK, expectedBody: `"Ann"`, expectCalled: true},
+		{name: "invalid response in strict mode", mode: "strict", method: http.MethodGet, target: "/users/1", body: `{"id": "one"}`, expectedStatus: http.StatusInternalServerError, expectCalled: true},
+		{name: "invalid response in warn mode", mode: "warn", method: http.MethodGet, target: "/users/1", body: `{"id": "one"}`, expectedStatus: http.StatusOK, expectedBody: `"one"`, expectCalled: true},
+		{name: "undocumented endpoint", mode: "strict", method: http.MethodGet, target: "/healthz", body: `{"ok": true}`, expectedStatus: http.StatusOK, expectedBody: "ok", expectCalled: true},
+		{name: "invalid request", mode: "strict", validateRequests: true, method: http.MethodGet, target: "/users/abc", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Mode = tt.mode
+			cfg.ValidateRequests = tt.validateRequests
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			called := false
+			handler := vp.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				called = true
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d, body: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.expectedBody) {
+				t.Errorf("body = %q, should contain %q", rec.Body.String(), tt.expectedBody)
+			}
+			if called != tt.expectCalled {
+				t.Errorf("handler called = %v, expected %v", called, tt.expectCalled)
+			}
+			if rec.Header().Get(requestIDHeader) == "" {
+				t.Error("response should carry a request ID")
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_MiddlewareIgnorePaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		basePath string
+	}{
+		{name: "ignored path"},
+		{name: "ignored path under upstream base path", basePath: "/api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Upstream = "http://localhost:3000" + tt.basePath
+			cfg.Mode = "strict"
+			cfg.IgnorePaths = FieldList{"/users/*"}
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			handler := vp.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id": "one"}`))
+			}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d, expected %d, body: %s", rec.Code, http.StatusOK, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+type mockResponse struct {
+	status      int
+	contentType string
+	body        []byte
+}
+
+// usesMock reports whether any operation can be in mock mode, so that
+// other configurations don't pay for an extra route lookup per request.
+func (vp *ValidatingProxy) usesMock() bool {
+	if vp.mode == ModeMock {
+		return true
+	}
+	return slices.ContainsFunc(vp.modeOverrides, func(override modeOverride) bool {
+		return override.mode == ModeMock
+	})
+}
+
+// serveMock answers r from the spec's examples instead of the upstream when
+// its operation is in mock mode, and reports whether it did.
+func (vp *ValidatingProxy) serveMock(w http.ResponseWriter, r *http.Request) bool {
+	if !vp.usesMock() {
+		return false
+	}
+
+	route, _, err := vp.findRoute(r)
+	if err != nil {
+		if vp.mode != ModeMock {
+			return false
+		}
+		vp.writeError(w, ErrorDetails{
+			Status: http.StatusNotFound,
+			Title:  "No documented operation",
+			Detail: fmt.Sprintf("%s %s is not in the API specification", r.Method, r.URL.Path),
+			Method: r.Method,
+			Path:   r.URL.Path,
+		})
+		return true
+	}
+	if vp.modeFor(route) != ModeMock {
+		return false
+	}
+
+	mock, err := mockFromExamples(route.Operation, r.Header.Get("Prefer"))
+	if err != nil {
+		vp.writeError(w, ErrorDetails{
+			Status: http.StatusNotImplemented,
+			Title:  "No mock response available",
+			Detail: err.Error(),
+			Method: r.Method,
+			Path:   r.URL.Path,
+		})
+		return true
+	}
+	writeMock(w, mock)
+	return true
+}
+
+// handleProxyError answers a request that failed to reach the upstream, or
+// whose response failed to be checked, with a 502.
+func (vp *ValidatingProxy) handleProxyError(w http.ResponseWriter, r *http.Request, err error) {
+	var opErr *net.OpError
+	if vp.mockFallback && errors.As(err, &opErr) && r.Context().Err() == nil && vp.serveMockFallback(w, r, err) {
+		return
+	}
+
+	vp.log(r.Context()).Error("Proxy error",
+		"error", err,
+		"method", r.Method,
+		"path", r.URL.Path)
+	w.WriteHeader(http.StatusBadGateway)
+}
+
+// serveMockFallback answers a request the upstream couldn't be reached for
+// from its operation's examples, and reports whether it could.
+func (vp *ValidatingProxy) serveMockFallback(w http.ResponseWriter, r *http.Request, err error) bool {
+	route, _, routeErr := vp.findRoute(r)
+	if routeErr != nil {
+		return false
+	}
+	mock, mockErr := mockFromExamples(route.Operation, r.Header.Get("Prefer"))
+	if mockErr != nil {
+		return false
+	}
+
+	vp.log(r.Context()).Warn("Upstream unreachable, serving mock response",
+		"error", err,
+		"operation", operationName(route),
+		"method", r.Method,
+		"path", r.URL.Path)
+	writeMock(w, mock)
+	return true
+}
+
+func writeMock(w http.ResponseWriter, mock mockResponse) {
+	if mock.contentType != "" {
+		w.Header().Set("Content-Type", mock.contentType)
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(mock.body)))
+	w.WriteHeader(mock.status)
+	_, _ = w.Write(mock.body)
+}
+
+// mockFromExamples builds a response from an operation's examples. The
+// Prefer header can select the status (code=404) and a named example
+// (example=notFound); otherwise the lowest documented 2xx status is used.
+func mockFromExamples(operation *openapi3.Operation, prefer string) (mockResponse, error) {
+	preferences := parsePrefer(prefer)
+
+	status, response, err := pickMockResponse(operation.Responses, preferences["code"])
+	if err != nil {
+		return mockResponse{}, err
+	}
+	if len(response.Content) == 0 {
+		return mockResponse{status: status}, nil
+	}
+
+	contentType := pickMockContentType(response.Content)
+	example, ok := pickExample(response.Content[contentType], preferences["example"])
+	if !ok {
+		return mockResponse{}, fmt.Errorf("the %d response has no example for %s", status, contentType)
+	}
+
+	body, err := encodeExample(example, contentType)
+	if err != nil {
+		return mockResponse{}, fmt.Errorf("failed to encode example: %w", err)
+	}
+	return mockResponse{status: status, contentType: contentType, body: body}, nil
+}
+
+func pickMockResponse(responses *openapi3.Responses, preferredCode string) (int, *openapi3.Response, error) {
+	if responses == nil || responses.Len() == 0 {
+		return 0, nil, errors.New("the operation documents no responses")
+	}
+
+	if preferredCode != "" {
+		status, err := strconv.Atoi(preferredCode)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid preferred status %q", preferredCode)
+		}
+		response := responses.Status(status)
+		if response == nil || response.Value == nil {
+			return 0, nil, fmt.Errorf("the operation documents no %d response", status)
+		}
+		return status, response.Value, nil
+	}
+
+	var best int
+	var bestResponse *openapi3.Response
+	for key, response := range responses.Map() {
+		status := mockStatus(key)
+		if status == 0 || response.Value == nil {
+			continue
+		}
+		// Prefer the lowest success status, then the lowest status overall
+		isSuccess := status >= 200 && status < 300
+		bestIsSuccess := best >= 200 && best < 300
+		if best == 0 || (isSuccess && !bestIsSuccess) || (isSuccess == bestIsSuccess && status < best) {
+			best, bestResponse = status, response.Value
+		}
+	}
+
+	if bestResponse == nil {
+		if response := responses.Default(); response != nil && response.Value != nil {
+			return http.StatusOK, response.Value, nil
+		}
+		return 0, nil, errors.New("the operation documents no responses")
+	}
+	return best, bestResponse, nil
+}
+
+// mockStatus converts a response key such as "200" or "2XX" to a status
+// code, or 0 for "default".
+func mockStatus(key string) int {
+	if class, ok := strings.CutSuffix(strings.ToUpper(key), "XX"); ok {
+		key = class + "00"
+	}
+	status, err := strconv.Atoi(key)
+	if err != nil {
+		return 0
+	}
+	return status
+}
+
+func pickMockContentType(content openapi3.Content) string {
+	if _, ok := content["application/json"]; ok {
+		return "application/json"
+	}
+	contentTypes := make([]string, 0, len(content))
+	for contentType := range content {
+		contentTypes = append(contentTypes, contentType)
+	}
+	slices.Sort(contentTypes)
+	return contentTypes[0]
+}
+
+func pickExample(mediaType *openapi3.MediaType, name string) (any, bool) {
+	if mediaType == nil {
+		return nil, false
+	}
+
+	if example := mediaType.Examples[name]; name != "" && example != nil && example.Value != nil {
+		return example.Value.Value, true
+	}
+	if mediaType.Example != nil {
+		return mediaType.Example, true
+	}
+
+	names := make([]string, 0, len(mediaType.Examples))
+	for name := range mediaType.Examples {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if example := mediaType.Examples[name]; example != nil && example.Value != nil {
+			return example.Value.Value, true
+		}
+	}
+
+	if mediaType.Schema != nil && mediaType.Schema.Value != nil && mediaType.Schema.Value.Example != nil {
+		return mediaType.Schema.Value.Example, true
+	}
+	return nil, false
+}
+
+func encodeExample(example any, contentType string) ([]byte, error) {
+	if s, ok := example.(string); ok && !strings.Contains(contentType, "json") {
+		return []byte(s), nil
+	}
+	return json.Marshal(example)
+}
+
+// parsePrefer parses the key=value preferences of a Prefer header, e.g.
+// "code=404, example=notFound".
+func parsePrefer(header string) map[string]string {
+	preferences := make(map[string]string)
+	for _, part := range strings.FieldsFunc(header, func(r rune) bool { return r == ',' || r == ';' }) {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok {
+			preferences[strings.ToLower(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return preferences
+}
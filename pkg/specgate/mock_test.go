@@ -0,0 +1,206 @@
+package specgate
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testMockSpec = `openapi: 3.0.3
+info:
+  title: Test API
+  version: 1.0.0
+paths:
+  /users/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: A user
+          content:
+            application/json:
+              example: {"id": 1, "name": "Alice"}
+        "404":
+          description: Not found
+          content:
+            application/json:
+              examples:
+                deleted:
+                  value: {"error": "user deleted"}
+                missing:
+                  value: {"error": "no such user"}
+  /health:
+    get:
+      responses:
+        "204":
+          description: Healthy
+  /orders:
+    get:
+      responses:
+        "200":
+          description: Orders
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  type: object
+  /readme:
+    get:
+      responses:
+        "2XX":
+          description: Readme
+          content:
+            text/plain:
+              schema:
+                type: string
+                example: Hello
+`
+
+func TestValidatingProxy_MockMode(t *testing.T) {
+	tests := []struct {
+		name                string
+		path                string
+		prefer              string
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{name: "lowest success example", path: "/users/1", expectedStatus: http.StatusOK, expectedContentType: "application/json", expectedBody: `{"id":1,"name":"Alice"}`},
+		{name: "preferred status", path: "/users/1", prefer: "code=404", expectedStatus: http.StatusNotFound, expectedContentType: "application/json", expectedBody: `{"error":"user deleted"}`},
+		{name: "preferred example", path: "/users/1", prefer: "code=404, example=missing", expectedStatus: http.StatusNotFound, expectedContentType: "application/json", expectedBody: `{"error":"no such user"}`},
+		{name: "undocumented preferred status", path: "/users/1", prefer: "code=500", expectedStatus: http.StatusNotImplemented},
+		{name: "response without content", path: "/health", expectedStatus: http.StatusNoContent},
+		{name: "schema example in status range", path: "/readme", expectedStatus: http.StatusOK, expectedContentType: "text/plain", expectedBody: "Hello"},
+		{name: "no example", path: "/orders", expectedStatus: http.StatusNotImplemented},
+		{name: "undocumented endpoint", path: "/unknown", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				t.Error("mock mode should not contact the upstream")
+				w.WriteHeader(http.StatusTeapot)
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, testMockSpec, upstream.URL, "mock")
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.prefer != "" {
+				req.Header.Set("Prefer", tt.prefer)
+			}
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("ServeHTTP() status = %d, expected %d, body: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+			if tt.expectedContentType != "" && rec.Header().Get("Content-Type") != tt.expectedContentType {
+				t.Errorf("Content-Type = %q, expected %q", rec.Header().Get("Content-Type"), tt.expectedContentType)
+			}
+			if tt.expectedBody != "" && rec.Body.String() != tt.expectedBody {
+				t.Errorf("body = %s, expected %s", rec.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_MockModeOverride(t *testing.T) {
+	var upstreamCalls int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		upstreamCalls++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultConfig()
+	cfg.Upstream = upstream.URL
+	cfg.ModeOverrides = []ModeOverride{{Pattern: "/users/**", Mode: "mock"}}
+	vp := newTestProxyWithConfig(t, testMockSpec, cfg)
+
+	rec := httptest.NewRecorder()
+	vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	if rec.Code != http.StatusOK || upstreamCalls != 0 {
+		t.Errorf("mocked operation: status = %d, upstream calls = %d, expected 200 without contacting the upstream", rec.Code, upstreamCalls)
+	}
+
+	for _, path := range []string{"/health", "/unknown"} {
+		rec = httptest.NewRecorder()
+		vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if upstreamCalls != 2 {
+		t.Errorf("upstream calls = %d, expected operations outside the override to be proxied", upstreamCalls)
+	}
+}
+
+func TestValidatingProxy_MockFallback(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockFallback   bool
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "documented operation", mockFallback: true, path: "/users/1", expectedStatus: http.StatusOK, expectedBody: `{"id":1,"name":"Alice"}`},
+		{name: "no example", mockFallback: true, path: "/orders", expectedStatus: http.StatusBadGateway},
+		{name: "undocumented endpoint", mockFallback: true, path: "/unknown", expectedStatus: http.StatusBadGateway},
+		{name: "disabled", path: "/users/1", expectedStatus: http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.NotFoundHandler())
+			upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.MockFallback = tt.mockFallback
+			vp := newTestProxyWithConfig(t, testMockSpec, cfg)
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("ServeHTTP() status = %d, expected %d, logs: %s", rec.Code, tt.expectedStatus, logs.String())
+			}
+			if tt.expectedBody != "" && rec.Body.String() != tt.expectedBody {
+				t.Errorf("body = %s, expected %s", rec.Body.String(), tt.expectedBody)
+			}
+			if served := strings.Contains(logs.String(), "serving mock response"); served != (tt.expectedStatus == http.StatusOK) {
+				t.Errorf("mock fallback logged = %v, logs: %s", served, logs.String())
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_MockFallbackUpstreamReachable(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"from upstream"}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultConfig()
+	cfg.Upstream = upstream.URL
+	cfg.MockFallback = true
+	vp := newTestProxyWithConfig(t, testMockSpec, cfg)
+
+	rec := httptest.NewRecorder()
+	vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	if rec.Code != http.StatusNotFound || rec.Body.String() != `{"error":"from upstream"}` {
+		t.Errorf("status = %d, body = %s, expected the upstream's error response", rec.Code, rec.Body.String())
+	}
+}
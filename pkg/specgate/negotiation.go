@@ -0,0 +1,118 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// mediaRange is one entry of an Accept header, such as text/* with q=0.5.
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+	}
+	return ranges
+}
+
+// acceptable reports whether contentType is accepted by the most specific of
+// ranges that matches it, so text/plain is acceptable to "text/*;q=0, text/plain".
+func acceptable(ranges []mediaRange, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	mainType, _, _ := strings.Cut(mediaType, "/")
+
+	specificity, q := -1, 0.0
+	for _, r := range ranges {
+		var matched int
+		switch {
+		case r.mediaType == mediaType:
+			matched = 2
+		case r.mediaType == mainType+"/*":
+			matched = 1
+		case r.mediaType == "*/*":
+			matched = 0
+		default:
+			continue
+		}
+		if matched > specificity {
+			specificity, q = matched, r.q
+		}
+	}
+	return q > 0
+}
+
+// checkAccept reports a response whose content type the request's Accept
+// header rules out. 406 responses answer requests that accept nothing the
+// upstream can send, so they aren't checked.
+func checkAccept(resp *http.Response, route *routers.Route) error {
+	accept := strings.Join(resp.Request.Header.Values("Accept"), ", ")
+	contentType := resp.Header.Get("Content-Type")
+	ranges := parseAccept(accept)
+	if len(ranges) == 0 || contentType == "" || resp.StatusCode == http.StatusNotAcceptable || acceptable(ranges, contentType) {
+		return nil
+	}
+
+	err := fmt.Errorf("response content type %q doesn't match the Accept header %q", contentType, accept)
+	documented := documentedMediaTypes(route)
+	if !slices.ContainsFunc(documented, func(mediaType string) bool { return acceptable(ranges, mediaType) }) {
+		err = fmt.Errorf("%w, and the operation only documents %s", err, strings.Join(documented, ", "))
+	}
+	return err
+}
+
+func documentedMediaTypes(route *routers.Route) []string {
+	var mediaTypes []string
+	for _, response := range route.Operation.Responses.Map() {
+		if response.Value == nil {
+			continue
+		}
+		for mediaType := range response.Value.Content {
+			if !slices.Contains(mediaTypes, mediaType) {
+				mediaTypes = append(mediaTypes, mediaType)
+			}
+		}
+	}
+	slices.Sort(mediaTypes)
+	return mediaTypes
+}
@@ -0,0 +1,107 @@
+package specgate
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAcceptable(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		expected    bool
+	}{
+		{name: "exact match", accept: "application/json", contentType: "application/json; charset=utf-8", expected: true},
+		{name: "no match", accept: "application/xml", contentType: "application/json", expected: false},
+		{name: "subtype wildcard", accept: "application/*", contentType: "application/json", expected: true},
+		{name: "any", accept: "*/*", contentType: "text/csv", expected: true},
+		{name: "one of several", accept: "text/html, application/json;q=0.9", contentType: "application/json", expected: true},
+		{name: "refused", accept: "application/json;q=0", contentType: "application/json", expected: false},
+		{name: "refused but more specific range accepts", accept: "text/*;q=0, text/plain", contentType: "text/plain", expected: true},
+		{name: "accepted but more specific range refuses", accept: "*/*, application/xml;q=0", contentType: "application/xml", expected: false},
+		{name: "case insensitive", accept: "Application/JSON", contentType: "application/json", expected: true},
+		{name: "suffix isn't implied", accept: "application/json", contentType: "application/problem+json", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := acceptable(parseAccept(tt.accept), tt.contentType); got != tt.expected {
+				t.Errorf("acceptable(%q, %q) = %v, expected %v", tt.accept, tt.contentType, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_ValidateAccept(t *testing.T) {
+	tests := []struct {
+		name           string
+		accept         string
+		contentType    string
+		status         int
+		expectedStatus int
+		expectedLog    string
+	}{
+		{name: "no Accept header", status: http.StatusOK, expectedStatus: http.StatusOK},
+		{name: "accepted", accept: "application/json", status: http.StatusOK, expectedStatus: http.StatusOK},
+		{name: "wildcard", accept: "*/*", status: http.StatusOK, expectedStatus: http.StatusOK},
+		{
+			name:           "not accepted",
+			accept:         "application/xml",
+			status:         http.StatusOK,
+			expectedStatus: http.StatusInternalServerError,
+			expectedLog:    "the operation only documents application/json",
+		},
+		{name: "not acceptable response", accept: "application/xml", status: http.StatusNotAcceptable, expectedStatus: http.StatusNotAcceptable},
+		{
+			name:           "undecodable content type not accepted",
+			accept:         "application/json",
+			contentType:    "text/plain",
+			status:         http.StatusOK,
+			expectedStatus: http.StatusInternalServerError,
+			expectedLog:    `response content type \"text/plain\" doesn't match`,
+		},
+		{name: "undecodable content type accepted", accept: "text/*", contentType: "text/plain", status: http.StatusOK, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				contentType := tt.contentType
+				if contentType == "" {
+					contentType = "application/json"
+				}
+				w.Header().Set("Content-Type", contentType)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"id": 1, "name": "Ann"}`))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			cfg.ValidateAccept = true
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d, logs: %s", rec.Code, tt.expectedStatus, logs.String())
+			}
+			if !strings.Contains(logs.String(), tt.expectedLog) {
+				t.Errorf("logs should contain %q, got: %s", tt.expectedLog, logs.String())
+			}
+		})
+	}
+}
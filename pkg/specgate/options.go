@@ -0,0 +1,69 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import "log/slog"
+
+// Option changes the configuration of a proxy created with
+// NewValidatingProxy. Any func(*Config) can be passed as an Option, to set
+// options that have no With function.
+type Option func(*Config)
+
+// NewValidatingProxy returns a proxy that validates upstream's responses
+// against spec, starting from DefaultConfig and applying opts in order.
+func NewValidatingProxy(spec, upstream string, opts ...Option) (*ValidatingProxy, error) {
+	cfg := DefaultConfig()
+	cfg.Spec = spec
+	cfg.Upstream = upstream
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return New(cfg)
+}
+
+// NewValidatingProxyWithMode is the three-argument form of
+// NewValidatingProxy from before it took options. Go has no overloading, so
+// it can't keep the old name.
+//
+// Deprecated: use NewValidatingProxy(spec, upstream, WithMode(mode)).
+func NewValidatingProxyWithMode(spec, upstream, mode string) (*ValidatingProxy, error) {
+	return NewValidatingProxy(spec, upstream, WithMode(mode))
+}
+
+// WithMode sets the validation mode: strict, warn, report or mock.
+func WithMode(mode string) Option {
+	return func(c *Config) { c.Mode = mode }
+}
+
+// WithLogger logs through logger instead of a logger for the configured log
+// format and level.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Config) { c.Logger = logger }
+}
+
+// WithMaxBodySize sets the largest body, in bytes, buffered for validation.
+func WithMaxBodySize(size int64) Option {
+	return func(c *Config) { c.MaxBodySize = ByteSize(size) }
+}
+
+// WithSampleRate sets the fraction of responses validated outside strict
+// mode, from 0 to 1.
+func WithSampleRate(rate float64) Option {
+	return func(c *Config) { c.SampleRate = rate }
+}
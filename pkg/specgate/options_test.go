@@ -0,0 +1,117 @@
+package specgate
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewValidatingProxy(t *testing.T) {
+	tests := []struct {
+		name                string
+		opts                []Option
+		expectedMode        Mode
+		expectedMaxBodySize int64
+		expectedSampleRate  float64
+		expectError         bool
+	}{
+		{
+			name:                "defaults",
+			expectedMode:        ModeWarn,
+			expectedMaxBodySize: defaultMaxBodySize,
+			expectedSampleRate:  1,
+		},
+		{
+			name:                "options",
+			opts:                []Option{WithMode("strict"), WithMaxBodySize(1024), WithSampleRate(0.5)},
+			expectedMode:        ModeStrict,
+			expectedMaxBodySize: 1024,
+			expectedSampleRate:  0.5,
+		},
+		{
+			name:                "later options win",
+			opts:                []Option{WithMode("strict"), WithMode("report")},
+			expectedMode:        ModeReport,
+			expectedMaxBodySize: defaultMaxBodySize,
+			expectedSampleRate:  1,
+		},
+		{
+			name:                "custom option",
+			opts:                []Option{func(c *Config) { c.FailureStatus = 502 }},
+			expectedMode:        ModeWarn,
+			expectedMaxBodySize: defaultMaxBodySize,
+			expectedSampleRate:  1,
+		},
+		{
+			name:        "invalid mode",
+			opts:        []Option{WithMode("loud")},
+			expectError: true,
+		},
+		{
+			name:        "invalid sample rate",
+			opts:        []Option{WithSampleRate(2)},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vp, err := NewValidatingProxy(writeTestSpec(t, testSpec), "http://localhost:3000", tt.opts...)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("NewValidatingProxy() error = %v, expectError %v", err, tt.expectError)
+			}
+			if tt.expectError {
+				return
+			}
+
+			if vp.mode != tt.expectedMode || vp.maxBodySize != tt.expectedMaxBodySize || vp.sampleRate != tt.expectedSampleRate {
+				t.Errorf("NewValidatingProxy() mode = %s, max body size = %d, sample rate = %g, expected %s, %d, %g",
+					vp.mode, vp.maxBodySize, vp.sampleRate, tt.expectedMode, tt.expectedMaxBodySize, tt.expectedSampleRate)
+			}
+		})
+	}
+}
+
+func TestNewValidatingProxy_WithLogger(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil)).With("component", "specgate")
+	vp, err := NewValidatingProxy(writeTestSpec(t, testSpec), upstream.URL, WithLogger(logger))
+	if err != nil {
+		t.Fatalf("NewValidatingProxy() unexpected error: %v", err)
+	}
+
+	vp.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+
+	if !strings.Contains(logs.String(), `"component":"specgate"`) || !strings.Contains(logs.String(), "Undocumented endpoint") {
+		t.Errorf("logs should go through the provided logger, got: %q", logs.String())
+	}
+}
+
+func TestNewValidatingProxyWithMode(t *testing.T) {
+	vp, err := NewValidatingProxyWithMode(writeTestSpec(t, testSpec), "http://localhost:3000", "strict")
+	if err != nil {
+		t.Fatalf("NewValidatingProxyWithMode() unexpected error: %v", err)
+	}
+	if vp.mode != ModeStrict {
+		t.Errorf("NewValidatingProxyWithMode() mode = %s, expected %s", vp.mode, ModeStrict)
+	}
+	if _, err := NewValidatingProxyWithMode(writeTestSpec(t, testSpec), "http://localhost:3000", "loud"); err == nil {
+		t.Error("NewValidatingProxyWithMode() with an invalid mode should fail")
+	}
+}
+
+func TestNew_ValidatesConfig(t *testing.T) {
+	cfg := &Config{Spec: writeTestSpec(t, testSpec), Upstream: "http://localhost:3000", Mode: "strict"}
+	if _, err := New(cfg); err == nil {
+		t.Error("New() with a zero max body size should fail validation")
+	}
+}
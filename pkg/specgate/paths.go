@@ -0,0 +1,103 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// matchPathPattern reports whether an OpenAPI path template such as
+// /users/{id} matches a glob pattern. Patterns follow path.Match, so * matches
+// a single segment, and a trailing /** additionally matches any number of
+// nested segments.
+func matchPathPattern(pattern, template string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		if template == prefix || strings.HasPrefix(template, prefix+"/") {
+			return true
+		}
+	}
+
+	matched, err := path.Match(pattern, template)
+	return err == nil && matched
+}
+
+func validatePathPattern(pattern string) error {
+	if !strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("pattern %q must start with /", pattern)
+	}
+	if _, err := path.Match(strings.TrimSuffix(pattern, "/**"), ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// pathFilter limits validation to the operations whose path templates match
+// an include pattern, if any are given, and no exclude pattern. Requests whose
+// paths match an ignore pattern aren't matched to operations at all.
+type pathFilter struct {
+	include []string
+	exclude []string
+	ignore  []string
+}
+
+type clientPathKey struct{}
+
+// withClientPath records the path the client requested, since the proxied
+// request's path has the upstream's base path prepended by the time its
+// response is validated.
+func withClientPath(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), clientPathKey{}, r.URL.Path))
+}
+
+func clientPath(r *http.Request) string {
+	if requestPath, ok := r.Context().Value(clientPathKey{}).(string); ok {
+		return requestPath
+	}
+	return r.URL.Path
+}
+
+func (f pathFilter) ignores(requestPath string) bool {
+	for _, pattern := range f.ignore {
+		if matchPathPattern(pattern, requestPath) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f pathFilter) validates(template string) bool {
+	for _, pattern := range f.exclude {
+		if matchPathPattern(pattern, template) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, pattern := range f.include {
+		if matchPathPattern(pattern, template) {
+			return true
+		}
+	}
+	return false
+}
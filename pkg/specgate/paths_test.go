@@ -0,0 +1,139 @@
+package specgate
+
+import "testing"
+
+func TestMatchPathPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		template string
+		expected bool
+	}{
+		{
+			name:     "exact match",
+			pattern:  "/users/{id}",
+			template: "/users/{id}",
+			expected: true,
+		},
+		{
+			name:     "single segment wildcard",
+			pattern:  "/payments/*",
+			template: "/payments/{id}",
+			expected: true,
+		},
+		{
+			name:     "single segment wildcard does not match nested paths",
+			pattern:  "/payments/*",
+			template: "/payments/{id}/refunds",
+			expected: false,
+		},
+		{
+			name:     "double star matches nested paths",
+			pattern:  "/payments/**",
+			template: "/payments/{id}/refunds",
+			expected: true,
+		},
+		{
+			name:     "double star matches the prefix itself",
+			pattern:  "/payments/**",
+			template: "/payments",
+			expected: true,
+		},
+		{
+			name:     "double star does not match sibling prefixes",
+			pattern:  "/payments/**",
+			template: "/payments-v2/{id}",
+			expected: false,
+		},
+		{
+			name:     "different path",
+			pattern:  "/legacy/*",
+			template: "/users/{id}",
+			expected: false,
+		},
+		{
+			name:     "malformed pattern",
+			pattern:  "/users/[",
+			template: "/users/[",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := matchPathPattern(tt.pattern, tt.template)
+			if result != tt.expected {
+				t.Errorf("matchPathPattern(%q, %q) = %v, expected %v", tt.pattern, tt.template, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidatePathPattern(t *testing.T) {
+	tests := []struct {
+		name        string
+		pattern     string
+		expectError bool
+	}{
+		{
+			name:    "plain path",
+			pattern: "/users",
+		},
+		{
+			name:    "wildcard",
+			pattern: "/users/*",
+		},
+		{
+			name:    "double star",
+			pattern: "/users/**",
+		},
+		{
+			name:        "relative pattern",
+			pattern:     "users/*",
+			expectError: true,
+		},
+		{
+			name:        "malformed pattern",
+			pattern:     "/users/[",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePathPattern(tt.pattern)
+			if (err != nil) != tt.expectError {
+				t.Errorf("validatePathPattern() error = %v, expectError %v", err, tt.expectError)
+			}
+		})
+	}
+}
+
+func TestPathFilter_Validates(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   pathFilter
+		template string
+		expected bool
+	}{
+		{name: "no patterns", filter: pathFilter{}, template: "/users/{id}", expected: true},
+		{name: "included", filter: pathFilter{include: []string{"/users/**"}}, template: "/users/{id}", expected: true},
+		{name: "not included", filter: pathFilter{include: []string{"/users/**"}}, template: "/orders", expected: false},
+		{name: "excluded", filter: pathFilter{exclude: []string{"/users/*"}}, template: "/users/{id}", expected: false},
+		{name: "not excluded", filter: pathFilter{exclude: []string{"/users/*"}}, template: "/users", expected: true},
+		{
+			name:     "exclude wins over include",
+			filter:   pathFilter{include: []string{"/users/**"}, exclude: []string{"/users/{id}"}},
+			template: "/users/{id}",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.validates(tt.template); got != tt.expected {
+				t.Errorf("validates(%q) = %v, expected %v", tt.template, got, tt.expected)
+			}
+		})
+	}
+}
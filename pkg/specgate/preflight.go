@@ -0,0 +1,106 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// defaultPreflightTimeout bounds each upstream connection attempt when
+// -upstream-dial-timeout is 0, so the check can't hang.
+const defaultPreflightTimeout = 10 * time.Second
+
+// runPreflight loads the spec and connects to every upstream without
+// serving, and returns the process exit code.
+func runPreflight(w io.Writer, cfg *Config) int {
+	spec, err := loadSpecOnly(w, cfg)
+	if err != nil {
+		fmt.Fprintf(w, "%s: %v\n", cfg.Spec, err)
+		return 1
+	}
+	fmt.Fprintf(w, "%s: %d operations\n", cfg.Spec, countOperations(spec))
+
+	for _, err := range RemoteSpecMismatches(cfg) {
+		fmt.Fprintf(w, "WARNING: %v\n", err)
+	}
+
+	routes, err := parseUpstreams(cfg.Upstream)
+	if err != nil {
+		fmt.Fprintf(w, "%s: %v\n", cfg.Upstream, err)
+		return 1
+	}
+	timeout := cfg.UpstreamDialTimeout
+	if timeout == 0 {
+		timeout = defaultPreflightTimeout
+	}
+
+	code := 0
+	for _, upstreamURL := range routes.urls() {
+		if err := dialUpstream(upstreamURL, timeout); err != nil {
+			fmt.Fprintf(w, "%s: unreachable: %v\n", upstreamURL, err)
+			code = 1
+			continue
+		}
+		fmt.Fprintf(w, "%s: reachable\n", upstreamURL)
+	}
+
+	if code == 0 {
+		fmt.Fprintln(w, "OK")
+	}
+	return code
+}
+
+func countOperations(spec *openapi3.T) int {
+	count := 0
+	for _, pathItem := range spec.Paths.Map() {
+		count += len(pathItem.Operations())
+	}
+	return count
+}
+
+// dialUpstream opens and closes a TCP connection to the upstream's host.
+func dialUpstream(upstreamURL string, timeout time.Duration) error {
+	target, err := url.Parse(upstreamURL)
+	if err != nil {
+		return err
+	}
+	port := target.Port()
+	if port == "" {
+		port = "80"
+		if target.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(target.Hostname(), port))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
@@ -0,0 +1,66 @@
+package specgate
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRunPreflight(t *testing.T) {
+	reachable := httptest.NewServer(http.NotFoundHandler())
+	defer reachable.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name         string
+		spec         string
+		upstream     string
+		expectedCode int
+		expectedOut  []string
+	}{
+		{
+			name:         "reachable upstream",
+			spec:         testSpec,
+			upstream:     reachable.URL,
+			expectedCode: 0,
+			expectedOut:  []string{"3 operations", reachable.URL + ": reachable", "OK"},
+		},
+		{
+			name:         "unreachable upstream",
+			spec:         testSpec,
+			upstream:     reachable.URL + ",/legacy=" + closed.URL,
+			expectedCode: 1,
+			expectedOut:  []string{reachable.URL + ": reachable", closed.URL + ": unreachable"},
+		},
+		{
+			name:         "invalid spec",
+			spec:         "openapi: 3.0.3\ninfo: {}\npaths: []\n",
+			upstream:     reachable.URL,
+			expectedCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Spec = writeTestSpec(t, tt.spec)
+			cfg.Upstream = tt.upstream
+
+			var out bytes.Buffer
+			if code := runPreflight(&out, cfg); code != tt.expectedCode {
+				t.Errorf("runPreflight() = %d, expected %d, output:\n%s", code, tt.expectedCode, out.String())
+			}
+			for _, expected := range tt.expectedOut {
+				if !strings.Contains(out.String(), expected) {
+					t.Errorf("runPreflight() output should contain %q:\n%s", expected, out.String())
+				}
+			}
+			if tt.expectedCode != 0 && strings.HasSuffix(out.String(), "OK\n") {
+				t.Errorf("runPreflight() should not report OK on failure:\n%s", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// undocumentedPropertiesError lists, as JSON pointers, the properties in a
+// response body that its schema doesn't document.
+type undocumentedPropertiesError []string
+
+func (e undocumentedPropertiesError) Error() string {
+	return "response body has properties the schema doesn't document: " + strings.Join(e, ", ")
+}
+
+// checkUndocumentedProperties treats the response body's schema as if every
+// object in it set additionalProperties: false. Schemas that set
+// additionalProperties themselves, and objects that document no properties
+// at all, still accept any property.
+func checkUndocumentedProperties(decoders *bodyDecoders, route *routers.Route, resp *http.Response, body []byte) error {
+	schema, value, ok := decoders.decodeDocumentedBody(route, resp, body)
+	if !ok {
+		return nil
+	}
+	if undocumented := undocumentedProperties(schema, value, ""); len(undocumented) > 0 {
+		return undocumentedPropertiesError(undocumented)
+	}
+	return nil
+}
+
+// decodeDocumentedBody decodes a response body that passed validation, and
+// returns the schema it was validated against. It reports false when the
+// operation documents no schema or decoder for the body.
+func (d *bodyDecoders) decodeDocumentedBody(route *routers.Route, resp *http.Response, body []byte) (*openapi3.Schema, any, bool) {
+	if route.Operation == nil || route.Operation.Responses == nil {
+		return nil, nil, false
+	}
+	response := route.Operation.Responses.Status(resp.StatusCode)
+	if response == nil {
+		response = route.Operation.Responses.Default()
+	}
+	if response == nil || response.Value == nil {
+		return nil, nil, false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, nil, false
+	}
+	content := response.Value.Content.Get(mediaType)
+	decoder := d.lookup(mediaType)
+	if content == nil || content.Schema == nil || content.Schema.Value == nil || decoder == nil {
+		return nil, nil, false
+	}
+
+	// The body already passed validation, so it decodes
+	value, err := decoder(bytes.NewReader(body), resp.Header, content.Schema, nil)
+	if err != nil {
+		return nil, nil, false
+	}
+	return content.Schema.Value, value, true
+}
+
+func undocumentedProperties(schema *openapi3.Schema, value any, pointer string) []string {
+	var undocumented []string
+	switch v := value.(type) {
+	case map[string]any:
+		properties, open := objectProperties(schema)
+		for _, name := range componentNames(v) {
+			child := pointer + "/" + escapeJSONPointer(name)
+			if property, ok := properties[name]; ok {
+				undocumented = append(undocumented, undocumentedProperties(property, v[name], child)...)
+			} else if !open {
+				undocumented = append(undocumented, child)
+			}
+		}
+	case []any:
+		if schema.Items == nil || schema.Items.Value == nil {
+			return nil
+		}
+		for i, item := range v {
+			undocumented = append(undocumented, undocumentedProperties(schema.Items.Value, item, pointer+"/"+strconv.Itoa(i))...)
+		}
+	}
+	return undocumented
+}
+
+// objectProperties returns the properties an object schema documents,
+// including those of its allOf, anyOf and oneOf subschemas, and whether it
+// accepts properties beyond them.
+func objectProperties(schema *openapi3.Schema) (map[string]*openapi3.Schema, bool) {
+	properties := make(map[string]*openapi3.Schema)
+	open := false
+
+	var visit func(*openapi3.Schema)
+	visit = func(s *openapi3.Schema) {
+		if s.AdditionalProperties.Schema != nil || (s.AdditionalProperties.Has != nil && *s.AdditionalProperties.Has) {
+			open = true
+		}
+		for name, property := range s.Properties {
+			if _, ok := properties[name]; !ok && property != nil && property.Value != nil {
+				properties[name] = property.Value
+			}
+		}
+		for _, sub := range slices.Concat(s.AllOf, s.AnyOf, s.OneOf) {
+			if sub != nil && sub.Value != nil {
+				visit(sub.Value)
+			}
+		}
+	}
+	visit(schema)
+
+	return properties, open || len(properties) == 0
+}
+
+func escapeJSONPointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
@@ -0,0 +1,128 @@
+package specgate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const propertiesSpec = `openapi: 3.0.3
+info:
+  title: Properties API
+  version: 1.0.0
+paths:
+  /users/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        "200":
+          description: A user
+          content:
+            application/json:
+              schema:
+                allOf:
+                  - $ref: "#/components/schemas/Named"
+                  - type: object
+                    properties:
+                      id:
+                        type: integer
+                      address:
+                        type: object
+                        properties:
+                          city:
+                            type: string
+                      tags:
+                        type: array
+                        items:
+                          type: object
+                          properties:
+                            name:
+                              type: string
+                      labels:
+                        type: object
+                        additionalProperties:
+                          type: string
+                      metadata:
+                        type: object
+components:
+  schemas:
+    Named:
+      type: object
+      properties:
+        name:
+          type: string
+`
+
+func TestValidatingProxy_NoAdditionalProperties(t *testing.T) {
+	tests := []struct {
+		name                   string
+		body                   string
+		noAdditionalProperties bool
+		expectedStatus         int
+		expectedError          string
+	}{
+		{
+			name:           "extra property allowed by default",
+			body:           `{"id": 1, "name": "Ada", "nickname": "ada"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:                   "documented properties",
+			body:                   `{"id": 1, "name": "Ada", "address": {"city": "London"}, "tags": [{"name": "admin"}]}`,
+			noAdditionalProperties: true,
+			expectedStatus:         http.StatusOK,
+		},
+		{
+			name:                   "extra top-level property",
+			body:                   `{"id": 1, "name": "Ada", "nickname": "ada"}`,
+			noAdditionalProperties: true,
+			expectedStatus:         http.StatusInternalServerError,
+			expectedError:          "/nickname",
+		},
+		{
+			name:                   "extra nested properties",
+			body:                   `{"address": {"city": "London", "zip": "N1"}, "tags": [{"name": "a"}, {"name": "b", "color": "red"}]}`,
+			noAdditionalProperties: true,
+			expectedStatus:         http.StatusInternalServerError,
+			expectedError:          "/address/zip, /tags/1/color",
+		},
+		{
+			name:                   "explicit additionalProperties and free-form objects",
+			body:                   `{"labels": {"team": "core"}, "metadata": {"anything": true}}`,
+			noAdditionalProperties: true,
+			expectedStatus:         http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			cfg.ExposeErrors = true
+			cfg.NoAdditionalProperties = tt.noAdditionalProperties
+			vp := newTestProxyWithConfig(t, propertiesSpec, cfg)
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.expectedError) {
+				t.Errorf("body = %s, expected it to contain %q", rec.Body.String(), tt.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,1136 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type Mode string
+
+const (
+	ModeStrict Mode = "strict"
+	ModeWarn   Mode = "warn"
+	ModeReport Mode = "report"
+	ModeMock   Mode = "mock"
+)
+
+const defaultMaxBodySize = 10 * 1024 * 1024 // 10MB
+
+const (
+	validationHeader      = "X-SpecGate-Validation"
+	validationErrorHeader = "X-SpecGate-Validation-Error"
+)
+
+type ValidatingProxy struct {
+	specMu            sync.RWMutex
+	spec              *openapi3.T
+	specPath          string
+	upstreams         upstreams
+	proxy             *httputil.ReverseProxy
+	mode              Mode
+	modeOverrides     []modeOverride
+	faults            []Fault
+	mockFallback      bool
+	maxBodySize       int64
+	sampleRate        float64
+	responses         responseValidation
+	streaming         streamPolicy
+	errorResponses    errorResponses
+	slowThreshold     time.Duration
+	logger            *slog.Logger
+	router            routers.Router // guarded by specMu
+	specLoader        specLoader
+	requests          requestValidation
+	xForwardedHeaders bool
+	forwardedHeader   bool
+	access            accessControl
+	metrics           *Metrics
+	failures          *FailureWriter
+	notifiers         []failureNotifier
+	recorder          *fixtureWriter
+	replay            *replayTransport
+	redactor          redactor
+	stats             *Stats
+	validators        customValidators
+	decoders          *bodyDecoders
+}
+
+type modeOverride struct {
+	pattern string
+	mode    Mode
+}
+
+// New returns a proxy that forwards requests to cfg.Upstream and validates
+// the responses against cfg.Spec. cfg is validated first, so start from
+// DefaultConfig rather than a zero Config. Close the proxy to release the
+// files and notifiers it holds.
+func New(cfg *Config) (*ValidatingProxy, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	validMode, overrides, err := parseModes(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	logger, err := newConfiguredLogger(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	errorResponses, err := newErrorResponses(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	upstreams, transport, replay, err := newUpstreams(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	loader, spec, router, err := loadSpec(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	failures, err := openFailuresOut(cfg.FailuresOut)
+	if err != nil {
+		return nil, err
+	}
+
+	vp := &ValidatingProxy{
+		spec:              spec,
+		specPath:          cfg.Spec,
+		upstreams:         upstreams,
+		mode:              validMode,
+		modeOverrides:     overrides,
+		faults:            cfg.Faults,
+		mockFallback:      cfg.MockFallback,
+		maxBodySize:       int64(cfg.MaxBodySize),
+		sampleRate:        cfg.SampleRate,
+		responses:         newResponseValidation(cfg),
+		streaming:         newStreamPolicy(cfg),
+		errorResponses:    errorResponses,
+		slowThreshold:     cfg.SlowThreshold,
+		logger:            logger,
+		router:            router,
+		specLoader:        loader,
+		requests:          newRequestValidation(cfg),
+		xForwardedHeaders: cfg.XForwardedHeaders,
+		forwardedHeader:   cfg.ForwardedHeader,
+		access:            newAccessControl(cfg),
+		metrics:           NewMetrics(),
+		failures:          failures,
+		notifiers:         newNotifiers(cfg, logger),
+		recorder:          newFixtureWriter(cfg.Record),
+		replay:            replay,
+		redactor:          newRedactor(cfg.RedactFields),
+		stats:             NewStats(),
+		validators:        customValidators{byOperation: assertionValidators(cfg.Assertions)},
+		decoders:          newBodyDecoders(),
+	}
+
+	vp.proxy = vp.newReverseProxy(transport)
+
+	vp.warnSpecIssues(spec)
+	return vp, nil
+}
+
+// newUpstreams parses cfg's upstreams and builds the transport that
+// requests to them are sent through.
+func newUpstreams(cfg *Config, logger *slog.Logger) (upstreams, http.RoundTripper, *replayTransport, error) {
+	upstreams, err := parseUpstreams(cfg.Upstream)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid upstream: %w", err)
+	}
+	transport, replay, err := newProxyTransport(cfg, logger)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return upstreams, transport, replay, nil
+}
+
+func (vp *ValidatingProxy) newReverseProxy(transport http.RoundTripper) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Director:       vp.direct,
+		ModifyResponse: vp.validateResponse,
+		ErrorHandler:   vp.handleProxyError,
+		Transport: &metricsTransport{
+			next:    transport,
+			metrics: vp.metrics,
+		},
+	}
+}
+
+func (vp *ValidatingProxy) warnSpecIssues(spec *openapi3.T) {
+	if err := lintSpec(spec); err != nil {
+		vp.logger.Warn("Spec loaded with problems, validation may be unreliable",
+			"error", err,
+			"spec", vp.specPath)
+	}
+}
+
+func (vp *ValidatingProxy) currentRouter() routers.Router {
+	vp.specMu.RLock()
+	defer vp.specMu.RUnlock()
+	return vp.router
+}
+
+// findRoute matches r to an operation. The router matches against the
+// upstream host, so a rewritten copy of r is routed.
+func (vp *ValidatingProxy) findRoute(r *http.Request) (*routers.Route, map[string]string, error) {
+	routeReq := r.Clone(r.Context())
+	vp.rewriteRequest(routeReq)
+	return vp.currentRouter().FindRoute(routeReq)
+}
+
+func (vp *ValidatingProxy) swapSpec(spec *openapi3.T, router routers.Router) {
+	vp.specMu.Lock()
+	defer vp.specMu.Unlock()
+	vp.spec = spec
+	vp.router = router
+}
+
+// Handler returns the proxy as an http.Handler, to serve directly or mount
+// under a path in an existing server.
+func (vp *ValidatingProxy) Handler() http.Handler {
+	return vp
+}
+
+// MetricsHandler serves the proxy's Prometheus metrics.
+func (vp *ValidatingProxy) MetricsHandler() http.Handler {
+	return vp.metrics.Handler()
+}
+
+// Stats returns the validation counts collected so far.
+func (vp *ValidatingProxy) Stats() *Stats {
+	return vp.stats
+}
+
+func (vp *ValidatingProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r, span := startProxySpan(vp.withRequestID(w, withReceivedAt(withClientPath(r))))
+	defer span.End()
+
+	if !vp.admit(w, r) {
+		return
+	}
+
+	if vp.requests.enabled && !vp.checkRequest(w, r) {
+		return
+	}
+	vp.stripCredentials(r)
+
+	if vp.injectFault(w, r) || vp.serveMock(w, r) {
+		return
+	}
+
+	if vp.recorder != nil || vp.replay != nil {
+		r = vp.captureRequest(r)
+	}
+	if vp.serveReplayMiss(w, r) {
+		return
+	}
+	r, ok := vp.selectUpstream(w, r)
+	if !ok {
+		return
+	}
+
+	vp.metrics.requestsProxied.Inc()
+	vp.proxy.ServeHTTP(&streamingWriter{ResponseWriter: w, vp: vp}, r)
+}
+
+// rewriteRequest points req at the upstream that serves its path. Requests
+// no upstream serves are left alone; ServeHTTP rejects them before proxying.
+func (vp *ValidatingProxy) rewriteRequest(req *http.Request) {
+	upstream := vp.upstreamFor(req)
+	if upstream == nil {
+		return
+	}
+	req.URL.Scheme = upstream.Scheme
+	req.URL.Host = upstream.Host
+	req.URL.Path, req.URL.RawPath = joinURLPath(upstream, req.URL)
+	req.Host = upstream.Host
+}
+
+// joinURLPath mounts the request path under the upstream's base path, the
+// same way httputil.NewSingleHostReverseProxy does.
+func joinURLPath(base, reqURL *url.URL) (string, string) {
+	if base.RawPath == "" && reqURL.RawPath == "" {
+		return singleJoiningSlash(base.Path, reqURL.Path), ""
+	}
+
+	basePath := base.EscapedPath()
+	reqPath := reqURL.EscapedPath()
+
+	baseSlash := strings.HasSuffix(basePath, "/")
+	reqSlash := strings.HasPrefix(reqPath, "/")
+
+	switch {
+	case baseSlash && reqSlash:
+		return base.Path + reqURL.Path[1:], basePath + reqPath[1:]
+	case !baseSlash && !reqSlash:
+		return base.Path + "/" + reqURL.Path, basePath + "/" + reqPath
+	}
+	return base.Path + reqURL.Path, basePath + reqPath
+}
+
+func singleJoiningSlash(a, b string) string {
+	aSlash := strings.HasSuffix(a, "/")
+	bSlash := strings.HasPrefix(b, "/")
+	switch {
+	case aSlash && bSlash:
+		return a + b[1:]
+	case !aSlash && !bSlash:
+		return a + "/" + b
+	}
+	return a + b
+}
+
+// responseValidation holds the options for validating upstream responses.
+type responseValidation struct {
+	skipStatus             StatusSet
+	paths                  pathFilter
+	noAdditionalProperties bool
+	annotate               bool
+	compress               bool
+	accept                 bool
+	assumeJSON             bool
+	exactIntegers          bool
+	schemaOptions          []openapi3.SchemaValidationOption
+}
+
+func newResponseValidation(cfg *Config) responseValidation {
+	return responseValidation{
+		skipStatus:             cfg.SkipStatus,
+		paths:                  pathFilter{include: cfg.IncludePaths, exclude: cfg.ExcludePaths, ignore: cfg.IgnorePaths},
+		noAdditionalProperties: cfg.NoAdditionalProperties,
+		annotate:               cfg.ValidationHeader,
+		compress:               cfg.CompressResponses,
+		accept:                 cfg.ValidateAccept,
+		assumeJSON:             cfg.AssumeJSON,
+		exactIntegers:          cfg.ExactIntegers,
+		schemaOptions:          schemaOptions(cfg),
+	}
+}
+
+func (vp *ValidatingProxy) validateResponse(resp *http.Response) error {
+	// The client already has the request ID, so an upstream echoing it back
+	// mustn't add a second copy
+	resp.Header.Del(requestIDHeader)
+	if vp.access.cors != nil {
+		stripUpstreamCORSHeaders(resp.Header)
+	}
+	trace.SpanFromContext(resp.Request.Context()).SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if vp.responses.paths.ignores(clientPath(resp.Request)) {
+		return nil
+	}
+	vp.observeOperation(resp)
+
+	if vp.upstreamUnavailable(resp) {
+		return nil
+	}
+	if reason := vp.skipReason(resp); reason != "" {
+		vp.logNotValidated(resp, reason)
+		return nil
+	}
+
+	route, pathParams, err := vp.findRouteForValidation(resp)
+	if err != nil {
+		return err
+	}
+	if route == nil {
+		return nil // undocumented endpoint
+	}
+
+	if !vp.responses.paths.validates(route.Path) {
+		vp.logNotValidated(resp, "path excluded")
+		return nil
+	}
+	if !vp.sampled(route) {
+		vp.logNotValidated(resp, "not sampled")
+		return nil
+	}
+
+	if vp.responses.accept && vp.failsAccept(resp, route) {
+		return nil
+	}
+	if !validatesBody(resp, route) || missingBody(resp, route) {
+		return vp.performValidation(resp, nil, route, pathParams)
+	}
+	if !vp.validatesContentType(resp.Header.Get("Content-Type")) {
+		vp.logNotValidated(resp, "content type not validated")
+		return nil
+	}
+	bodyBytes, err := vp.readResponseBody(resp)
+	if err != nil || bodyBytes == nil {
+		return err
+	}
+
+	decoded := vp.decodeResponseBody(resp, bodyBytes)
+	if !labelJSON(resp, decoded) {
+		vp.logNotValidated(resp, "content type missing")
+		return nil
+	}
+	if vp.recorder != nil {
+		vp.recordFixture(resp, decoded)
+	}
+	if err := vp.performValidation(resp, decoded, route, pathParams); err != nil || !vp.responses.compress {
+		return err
+	}
+	return compressResponse(resp)
+}
+
+// failsAccept fails resp if the request's Accept header rules out its content
+// type. It runs before the body checks, so it applies to content types whose
+// bodies aren't validated too.
+func (vp *ValidatingProxy) failsAccept(resp *http.Response, route *routers.Route) bool {
+	err := checkAccept(resp, route)
+	if err == nil {
+		return false
+	}
+	vp.metrics.responsesValidated.Inc()
+	vp.failValidation(resp.Request.Context(), resp, route, nil, err)
+	return true
+}
+
+// upstreamUnavailable reports a 503 with Retry-After, which upstreams send
+// during maintenance. Its body is rarely the documented error, so it isn't
+// validated, and it is passed on with Retry-After intact.
+func (vp *ValidatingProxy) upstreamUnavailable(resp *http.Response) bool {
+	retryAfter := resp.Header.Get("Retry-After")
+	if resp.StatusCode != http.StatusServiceUnavailable || retryAfter == "" {
+		return false
+	}
+
+	vp.log(resp.Request.Context()).Warn("Upstream unavailable, skipping validation",
+		"retry_after", retryAfter,
+		"method", resp.Request.Method,
+		"path", resp.Request.URL.Path)
+	return true
+}
+
+// skipReason explains why resp won't be validated whatever operation it
+// belongs to, or returns "" if it may be.
+func (vp *ValidatingProxy) skipReason(resp *http.Response) string {
+	contentType := resp.Header.Get("Content-Type")
+	switch {
+	case vp.responses.skipStatus.Contains(resp.StatusCode):
+		return "status skipped"
+	case vp.streaming.skipsContentType(contentType):
+		return "content type streamed"
+	case !vp.validatesContentType(contentType) && !mayHaveNoBody(resp) && !vp.responses.accept:
+		return "content type not validated"
+	default:
+		return ""
+	}
+}
+
+// validatesContentType reports whether bodies of contentType are validated.
+// With -assume-json, bodies without a content type are validated if they
+// look like JSON.
+func (vp *ValidatingProxy) validatesContentType(contentType string) bool {
+	return vp.decoders.has(contentType) || (contentType == "" && vp.responses.assumeJSON)
+}
+
+// labelJSON reports whether body can be validated against resp's content
+// type. A body sent without one is labelled application/json if it starts
+// like a JSON object or array, and the client receives that label too.
+func labelJSON(resp *http.Response, body []byte) bool {
+	if resp.Header.Get("Content-Type") != "" {
+		return true
+	}
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return false
+	}
+	resp.Header.Set("Content-Type", "application/json")
+	return true
+}
+
+// mayHaveNoBody reports whether resp may legitimately lack a body, in which
+// case it needs no content type for its headers to be validated. Redirects
+// count too: the HTML body they usually carry is only for clients that don't
+// follow them, and is seldom documented.
+func mayHaveNoBody(resp *http.Response) bool {
+	return resp.Request.Method == http.MethodHead || bodylessStatus(resp.StatusCode) || redirectStatus(resp.StatusCode) || resp.ContentLength == 0
+}
+
+func redirectStatus(status int) bool {
+	return status >= 300 && status < 400
+}
+
+func bodylessStatus(status int) bool {
+	return status == http.StatusNoContent || status == http.StatusResetContent || status == http.StatusNotModified
+}
+
+// missingBody reports whether resp has an empty body although the spec
+// documents content for its status. Redirects are let off, as their bodies
+// are only ever a courtesy.
+func missingBody(resp *http.Response, route *routers.Route) bool {
+	return resp.ContentLength == 0 && resp.Request.Method != http.MethodHead &&
+		!bodylessStatus(resp.StatusCode) && !redirectStatus(resp.StatusCode) &&
+		documentsContent(route, resp.StatusCode)
+}
+
+// validatesBody reports whether resp's body is validated along with its
+// headers. HEAD responses and bodyless statuses have no body, and neither
+// do responses the spec documents without content.
+func validatesBody(resp *http.Response, route *routers.Route) bool {
+	if resp.Request.Method == http.MethodHead || bodylessStatus(resp.StatusCode) {
+		return false
+	}
+
+	response := documentedResponse(route, resp.StatusCode)
+	return response == nil || len(response.Content) > 0
+}
+
+func documentsContent(route *routers.Route, status int) bool {
+	response := documentedResponse(route, status)
+	return response != nil && len(response.Content) > 0
+}
+
+// documentedResponse returns the response route documents for status, or
+// nil if it documents none.
+func documentedResponse(route *routers.Route, status int) *openapi3.Response {
+	response := route.Operation.Responses.Status(status)
+	if response == nil {
+		response = route.Operation.Responses.Default()
+	}
+	if response == nil {
+		return nil
+	}
+	return response.Value
+}
+
+func (vp *ValidatingProxy) logNotValidated(resp *http.Response, reason string) {
+	vp.log(resp.Request.Context()).Debug("Response not validated",
+		"reason", reason,
+		"method", resp.Request.Method,
+		"path", resp.Request.URL.Path,
+		"status", resp.StatusCode,
+		"content_type", resp.Header.Get("Content-Type"))
+}
+
+// observeOperation records per-operation observations for every upstream
+// response, whether or not its body is validated.
+func (vp *ValidatingProxy) observeOperation(resp *http.Response) {
+	route, _, err := vp.findResponseRoute(resp.Request)
+	if err != nil {
+		// Undocumented endpoints are logged by validation, but counted here
+		// so responses that are never validated are included
+		if isUndocumentedEndpoint(err) {
+			vp.stats.recordUndocumented(resp.Request.Method, resp.Request.URL.Path)
+		}
+		return
+	}
+	vp.observeLatency(resp, route)
+	if route.Operation != nil && route.Operation.Deprecated {
+		vp.reportDeprecated(resp.Request, route)
+	}
+}
+
+// sampled reports whether this response should be validated. Strict
+// operations are always validated since their responses are enforced.
+func (vp *ValidatingProxy) sampled(route *routers.Route) bool {
+	if vp.sampleRate >= 1 || vp.modeFor(route) == ModeStrict {
+		return true
+	}
+	return rand.Float64() < vp.sampleRate // #nosec G404 -- sampling doesn't need a secure source
+}
+
+func (vp *ValidatingProxy) decodeResponseBody(resp *http.Response, bodyBytes []byte) []byte {
+	contentEncoding := resp.Header.Get("Content-Encoding")
+	if contentEncoding == "" {
+		return bodyBytes
+	}
+
+	decoded, err := decodeContentEncoding(contentEncoding, bodyBytes, vp.maxBodySize)
+	if err != nil {
+		// Upstreams occasionally declare an encoding they didn't apply, so validate the raw bytes instead
+		vp.log(resp.Request.Context()).Warn("Failed to decode response body, validating raw bytes",
+			"error", err,
+			"encoding", contentEncoding,
+			"method", resp.Request.Method,
+			"path", resp.Request.URL.Path)
+		return bodyBytes
+	}
+
+	return decoded
+}
+
+func (vp *ValidatingProxy) readResponseBody(resp *http.Response) ([]byte, error) {
+	if vp.streaming.skipsUnknownLength(resp) {
+		vp.log(resp.Request.Context()).Info("Response length unknown, skipping validation",
+			"method", resp.Request.Method,
+			"path", resp.Request.URL.Path)
+		return nil, nil
+	}
+
+	// Content-Length isn't trusted, so the size is judged by the bytes actually read
+	limited := io.LimitReader(resp.Body, vp.maxBodySize+1)
+	bodyBytes, err := io.ReadAll(limited)
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(bodyBytes)) > vp.maxBodySize {
+		vp.log(resp.Request.Context()).Warn("Response too large, skipping validation", "size", len(bodyBytes))
+		resp.Body = readCloser{
+			Reader: io.MultiReader(bytes.NewReader(bodyBytes), resp.Body),
+			Closer: resp.Body,
+		}
+		return nil, nil
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	return bodyBytes, nil
+}
+
+// findResponseRoute matches the upstream request req to an operation. A HEAD
+// request the spec doesn't document is matched to the GET operation, whose
+// headers its response carries.
+func (vp *ValidatingProxy) findResponseRoute(req *http.Request) (*routers.Route, map[string]string, error) {
+	route, pathParams, err := vp.currentRouter().FindRoute(req)
+	if req.Method == http.MethodHead && errors.Is(err, routers.ErrMethodNotAllowed) {
+		getReq := req.Clone(req.Context())
+		getReq.Method = http.MethodGet
+		if getRoute, getParams, getErr := vp.currentRouter().FindRoute(getReq); getErr == nil {
+			return getRoute, getParams, nil
+		}
+	}
+	return route, pathParams, err
+}
+
+func (vp *ValidatingProxy) findRouteForValidation(resp *http.Response) (*routers.Route, map[string]string, error) {
+	route, pathParams, err := vp.findResponseRoute(resp.Request)
+	if err != nil {
+		if errors.Is(err, routers.ErrMethodNotAllowed) {
+			vp.log(resp.Request.Context()).Warn("Undocumented method on known path",
+				"method", resp.Request.Method,
+				"path", resp.Request.URL.Path)
+			return nil, nil, nil
+		}
+		if isUndocumentedEndpoint(err) {
+			vp.log(resp.Request.Context()).Warn("Undocumented endpoint",
+				"method", resp.Request.Method,
+				"path", resp.Request.URL.Path)
+			return nil, nil, nil
+		}
+		vp.log(resp.Request.Context()).Error("Error finding route",
+			"error", err,
+			"method", resp.Request.Method,
+			"path", resp.Request.URL.Path)
+		return nil, nil, fmt.Errorf("route finding error: %w", err)
+	}
+	vp.logRouteMatch(resp.Request, route, pathParams)
+	return route, pathParams, nil
+}
+
+// logRouteMatch shows which operation r matched, since a literal path such
+// as /users/me can be matched by a templated one like /users/{id}.
+func (vp *ValidatingProxy) logRouteMatch(r *http.Request, route *routers.Route, pathParams map[string]string) {
+	vp.log(r.Context()).Debug("Matched route",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"template", route.Path,
+		"operation", operationName(route),
+		"path_params", pathParams)
+}
+
+func (vp *ValidatingProxy) performValidation(resp *http.Response, bodyBytes []byte, route *routers.Route, pathParams map[string]string) error {
+	ctx, span := startValidationSpan(resp.Request.Context(), "validate response", route)
+	// A nil body means only the headers are validated
+	request, headersOnly := resp.Request, bodyBytes == nil
+	if request.Method == http.MethodHead {
+		// kin-openapi skips HEAD responses entirely, so validate them as if for a GET
+		request = request.Clone(ctx)
+		request.Method = http.MethodGet
+	}
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    request,
+			PathParams: pathParams,
+			Route:      route,
+		},
+		Status: resp.StatusCode,
+		Header: resp.Header,
+		Options: &openapi3filter.Options{
+			// The body is validated with the proxy's own decoders
+			ExcludeResponseBody:     true,
+			SchemaValidationOptions: vp.responses.schemaOptions,
+		},
+	}
+
+	vp.metrics.responsesValidated.Inc()
+	start := time.Now()
+	err := openapi3filter.ValidateResponse(ctx, input)
+	if err == nil && !headersOnly {
+		err = vp.validateResponseBody(input, bodyBytes)
+	}
+	switch {
+	case err != nil:
+	case !headersOnly:
+		err = vp.checkValidBody(resp, route, bodyBytes)
+	case missingBody(resp, route):
+		err = fmt.Errorf("response body is missing, but the spec documents content for status %d", resp.StatusCode)
+	}
+	vp.log(ctx).Debug("Response validated",
+		"operation", operationName(route),
+		"mode", vp.modeFor(route),
+		"valid", err == nil,
+		"duration", time.Since(start),
+		"method", resp.Request.Method,
+		"path", resp.Request.URL.Path,
+		"status", resp.StatusCode)
+	vp.redactor.redact(err)
+	endValidationSpan(span, err)
+	if err != nil {
+		vp.failValidation(ctx, resp, route, bodyBytes, err)
+		return nil
+	}
+
+	vp.stats.recordPass()
+	return nil
+}
+
+// failValidation records and logs a response that failed validation, then
+// replaces or annotates it according to the operation's mode.
+func (vp *ValidatingProxy) failValidation(ctx context.Context, resp *http.Response, route *routers.Route, bodyBytes []byte, err error) {
+	vp.metrics.recordValidationFailure(route, resp.StatusCode)
+	vp.stats.recordFailure(route)
+	vp.recordFailure(resp, route, err)
+	vp.log(ctx).Error("Response validation failed",
+		"error", err,
+		"reason", describeValidationError(err),
+		"operation_id", operationID(route),
+		"method", resp.Request.Method,
+		"path", resp.Request.URL.Path,
+		"status", resp.StatusCode)
+
+	switch {
+	case vp.modeFor(route) == ModeStrict:
+		vp.replaceResponseWithError(resp, route, bodyBytes, err)
+	case vp.responses.annotate:
+		vp.annotateFailure(resp, err)
+	}
+}
+
+// checkValidBody runs the checks beyond the spec's schemas on a response
+// whose body passed schema validation.
+func (vp *ValidatingProxy) checkValidBody(resp *http.Response, route *routers.Route, body []byte) error {
+	if vp.responses.noAdditionalProperties {
+		if err := checkUndocumentedProperties(vp.decoders, route, resp, body); err != nil {
+			return err
+		}
+	}
+	if vp.responses.exactIntegers {
+		if err := checkExactIntegers(vp.decoders, route, resp, body); err != nil {
+			return err
+		}
+	}
+	return vp.validators.check(vp.decoders, route, resp, body)
+}
+
+func (vp *ValidatingProxy) recordFailure(resp *http.Response, route *routers.Route, validationErr error) {
+	if vp.failures == nil && len(vp.notifiers) == 0 {
+		return
+	}
+
+	record := FailureRecord{
+		Timestamp:   time.Now().UTC(),
+		Method:      resp.Request.Method,
+		Path:        route.Path,
+		OperationID: operationID(route),
+		Status:      resp.StatusCode,
+		Error:       validationErr.Error(),
+		RequestID:   requestID(resp.Request.Context()),
+	}
+
+	for _, notifier := range vp.notifiers {
+		notifier.Notify(notifiedFailure{FailureRecord: record, Operation: operationName(route), Mode: vp.modeFor(route)})
+	}
+	if vp.failures != nil {
+		if err := vp.failures.Write(record); err != nil {
+			vp.log(resp.Request.Context()).Error("Failed to write validation failure", "error", err)
+		}
+	}
+}
+
+// Close flushes and releases resources held by the proxy.
+func (vp *ValidatingProxy) Close() error {
+	for _, notifier := range vp.notifiers {
+		notifier.Close()
+	}
+	if vp.failures == nil {
+		return nil
+	}
+	return vp.failures.Close()
+}
+
+func (vp *ValidatingProxy) modeFor(route *routers.Route) Mode {
+	for _, override := range vp.modeOverrides {
+		if matchPathPattern(override.pattern, route.Path) {
+			return override.mode
+		}
+	}
+	return vp.mode
+}
+
+func (vp *ValidatingProxy) replaceResponseWithError(resp *http.Response, route *routers.Route, body []byte, validationErr error) {
+	details := ErrorDetails{
+		Status:      vp.errorResponses.status,
+		Title:       "Response validation failed",
+		Detail:      vp.clientDetail(validationErr, "The upstream response does not match the API specification"),
+		OperationID: vp.clientOperationID(route),
+		Upstream:    vp.upstreamResponse(resp, body),
+	}
+	if resp.Request != nil {
+		details.Method = resp.Request.Method
+		details.Path = resp.Request.URL.Path
+	}
+	errorBody, contentType := vp.renderError(details)
+
+	// Update headers to match the new response
+	resp.Body = io.NopCloser(bytes.NewReader(errorBody))
+	resp.StatusCode = details.Status
+	resp.Header.Set("Content-Type", contentType)
+	resp.Header.Set("Content-Length", strconv.Itoa(len(errorBody)))
+
+	// The error body is never encoded, whatever headers are configured to be
+	// stripped
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Transfer-Encoding")
+	for _, header := range vp.errorResponses.stripHeaders {
+		resp.Header.Del(header)
+	}
+}
+
+// upstreamResponse describes the response a strict-mode error replaces, with
+// up to -error-upstream-body bytes of its redacted body. Bodies that aren't
+// text, or can't be redacted, are left out.
+func (vp *ValidatingProxy) upstreamResponse(resp *http.Response, body []byte) *UpstreamResponse {
+	limit := vp.errorResponses.upstreamBody
+	if limit == 0 {
+		return nil
+	}
+
+	upstream := &UpstreamResponse{Status: resp.StatusCode}
+	body, ok := vp.redactor.redactJSON(body)
+	if !ok || !utf8.Valid(body) {
+		return upstream
+	}
+	if len(body) > limit {
+		for limit > 0 && !utf8.RuneStart(body[limit]) {
+			limit--
+		}
+		body, upstream.Truncated = body[:limit], true
+	}
+	upstream.Body = string(body)
+	return upstream
+}
+
+// annotateFailure marks a response that failed validation but is passed
+// through unchanged. Like strict-mode error bodies, it only says why with
+// -expose-errors.
+func (vp *ValidatingProxy) annotateFailure(resp *http.Response, validationErr error) {
+	resp.Header.Set(validationHeader, "failed")
+	if vp.errorResponses.expose {
+		resp.Header.Set(validationErrorHeader, describeValidationError(validationErr))
+	}
+}
+
+func (vp *ValidatingProxy) writeErrorResponse(w http.ResponseWriter, r *http.Request, route *routers.Route, status int, message string, err error) {
+	vp.writeError(w, ErrorDetails{
+		Status:      status,
+		Title:       message,
+		Detail:      vp.clientDetail(err, "The request does not match the API specification"),
+		Method:      r.Method,
+		Path:        r.URL.Path,
+		OperationID: vp.clientOperationID(route),
+	})
+}
+
+func (vp *ValidatingProxy) writeError(w http.ResponseWriter, details ErrorDetails) {
+	errorBody, contentType := vp.renderError(details)
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(errorBody)))
+	w.WriteHeader(details.Status)
+	_, _ = w.Write(errorBody)
+}
+
+// clientDetail returns what a client is told about a validation error. The
+// full error may expose schema internals, so it is only sent with
+// -expose-errors; it is always logged.
+func (vp *ValidatingProxy) clientDetail(err error, generic string) string {
+	if vp.errorResponses.expose {
+		return err.Error()
+	}
+	return generic
+}
+
+// clientOperationID names the failing operation in error bodies. Like the
+// error itself, it is only sent with -expose-errors.
+func (vp *ValidatingProxy) clientOperationID(route *routers.Route) string {
+	if !vp.errorResponses.expose || route == nil {
+		return ""
+	}
+	return operationID(route)
+}
+
+func (vp *ValidatingProxy) renderError(details ErrorDetails) ([]byte, string) {
+	if vp.errorResponses.renderer != nil {
+		body, contentType, err := vp.errorResponses.renderer(details)
+		if err == nil {
+			return body, contentType
+		}
+		vp.logger.Error("Failed to render error response, falling back to JSON", "error", err)
+	}
+
+	body, contentType, _ := renderJSONError(details)
+	return body, contentType
+}
+
+func parseMode(mode string) (Mode, error) {
+	switch strings.ToLower(mode) {
+	case "strict":
+		return ModeStrict, nil
+	case "warn":
+		return ModeWarn, nil
+	case "report":
+		return ModeReport, nil
+	case "mock":
+		return ModeMock, nil
+	default:
+		return "", fmt.Errorf("invalid mode '%s': must be one of 'strict', 'warn', 'report', or 'mock'", mode)
+	}
+}
+
+// parseModes parses the default mode and the per-path overrides.
+func parseModes(cfg *Config) (Mode, []modeOverride, error) {
+	mode, err := parseMode(cfg.Mode)
+	if err != nil {
+		return "", nil, err
+	}
+	overrides, err := parseModeOverrides(cfg.ModeOverrides)
+	if err != nil {
+		return "", nil, err
+	}
+	return mode, overrides, nil
+}
+
+func parseModeOverrides(overrides []ModeOverride) ([]modeOverride, error) {
+	parsed := make([]modeOverride, 0, len(overrides))
+	for _, override := range overrides {
+		if err := validatePathPattern(override.Pattern); err != nil {
+			return nil, err
+		}
+		mode, err := parseMode(override.Mode)
+		if err != nil {
+			return nil, fmt.Errorf("invalid override for %s: %w", override.Pattern, err)
+		}
+		parsed = append(parsed, modeOverride{pattern: override.Pattern, mode: mode})
+	}
+	return parsed, nil
+}
+
+func isUndocumentedEndpoint(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, routers.ErrPathNotFound) || errors.Is(err, routers.ErrMethodNotAllowed) {
+		return true
+	}
+
+	// Fallback for routers that don't return the sentinel errors from the routers package
+	errMsg := strings.ToLower(err.Error())
+
+	undocumentedPatterns := []string{
+		"no matching operation",
+		"path not found",
+		"no route found",
+		"operation not found",
+		"no match found",
+		"unknown path",
+	}
+
+	for _, pattern := range undocumentedPatterns {
+		if strings.Contains(errMsg, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ColoredHandler provides colored console output similar to zerolog
+type ColoredHandler struct {
+	output io.Writer
+	level  slog.Level
+	attrs  []slog.Attr
+	prefix string
+	// Shared with handlers derived via WithAttrs, since they write to the same output
+	mu *sync.Mutex
+}
+
+func NewColoredHandler(output io.Writer, level slog.Level) *ColoredHandler {
+	return &ColoredHandler{
+		output: output,
+		level:  level,
+		mu:     &sync.Mutex{},
+	}
+}
+
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorGreen  = "\033[32m"
+	colorBlue   = "\033[34m"
+	colorGray   = "\033[90m"
+)
+
+func (h *ColoredHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *ColoredHandler) Handle(_ context.Context, record slog.Record) error {
+	var color string
+	switch record.Level {
+	case slog.LevelError:
+		color = colorRed
+	case slog.LevelWarn:
+		color = colorYellow
+	case slog.LevelInfo:
+		color = colorGreen
+	case slog.LevelDebug:
+		color = colorBlue
+	default:
+		color = colorReset
+	}
+
+	var builder strings.Builder
+	builder.Grow(256) // Pre-allocate reasonable capacity
+
+	timestamp := time.Now().Format("15:04:05")
+	builder.WriteString(colorGray)
+	builder.WriteString(timestamp)
+	builder.WriteString(" ")
+	builder.WriteString(color)
+	builder.WriteString(record.Level.String())
+	builder.WriteString(colorReset)
+
+	builder.WriteString(" ")
+	builder.WriteString(record.Message)
+
+	record.Attrs(func(attr slog.Attr) bool {
+		appendAttr(&builder, h.prefix, attr)
+		return true
+	})
+
+	for _, attr := range h.attrs {
+		appendAttr(&builder, "", attr)
+	}
+
+	builder.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, err := h.output.Write([]byte(builder.String()))
+	return err
+}
+
+func appendAttr(builder *strings.Builder, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		// Inline groups (empty key) contribute their attributes without adding a level
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix += attr.Key + "."
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			appendAttr(builder, groupPrefix, groupAttr)
+		}
+		return
+	}
+
+	builder.WriteString(" ")
+	builder.WriteString(prefix)
+	builder.WriteString(attr.Key)
+	builder.WriteString("=")
+	builder.WriteString(fmt.Sprintf("%v", attr.Value))
+}
+
+func (h *ColoredHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	newAttrs := make([]slog.Attr, len(h.attrs), len(h.attrs)+len(attrs))
+	copy(newAttrs, h.attrs)
+	for _, attr := range attrs {
+		newAttrs = append(newAttrs, slog.Attr{Key: h.prefix + attr.Key, Value: attr.Value})
+	}
+
+	return &ColoredHandler{
+		output: h.output,
+		level:  h.level,
+		attrs:  newAttrs,
+		prefix: h.prefix,
+		mu:     h.mu,
+	}
+}
+
+func (h *ColoredHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &ColoredHandler{
+		output: h.output,
+		level:  h.level,
+		attrs:  h.attrs,
+		prefix: h.prefix + name + ".",
+		mu:     h.mu,
+	}
+}
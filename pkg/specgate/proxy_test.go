@@ -0,0 +1,1678 @@
+package specgate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+const testSpec = `openapi: 3.0.3
+info:
+  title: Test API
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: A list of users
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  type: object
+    post:
+      operationId: createUser
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name:
+                  type: string
+      responses:
+        "201":
+          description: Created
+          content:
+            application/json:
+              schema:
+                type: object
+  /users/{id}:
+    get:
+      operationId: getUser
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: A user
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [id, name]
+                properties:
+                  id:
+                    type: integer
+                  name:
+                    type: string
+`
+
+func writeTestSpec(t *testing.T, spec string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "openapi.yaml")
+	if err := os.WriteFile(path, []byte(spec), 0o600); err != nil {
+		t.Fatalf("failed to write spec: %v", err)
+	}
+	return path
+}
+
+func newTestProxy(t *testing.T, spec, upstreamURL, mode string) *ValidatingProxy {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	cfg.Upstream = upstreamURL
+	cfg.Mode = mode
+	return newTestProxyWithConfig(t, spec, cfg)
+}
+
+func newTestProxyWithConfig(t *testing.T, spec string, cfg *Config) *ValidatingProxy {
+	t.Helper()
+
+	cfg.Spec = writeTestSpec(t, spec)
+	vp, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	vp.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	return vp
+}
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    Mode
+		expectError bool
+	}{
+		{
+			name:        "strict mode",
+			input:       "strict",
+			expected:    ModeStrict,
+			expectError: false,
+		},
+		{
+			name:        "warn mode",
+			input:       "warn",
+			expected:    ModeWarn,
+			expectError: false,
+		},
+		{
+			name:        "report mode",
+			input:       "report",
+			expected:    ModeReport,
+			expectError: false,
+		},
+		{
+			name:        "uppercase input",
+			input:       "STRICT",
+			expected:    ModeStrict,
+			expectError: false,
+		},
+		{
+			name:        "mixed case input",
+			input:       "WaRn",
+			expected:    ModeWarn,
+			expectError: false,
+		},
+		{
+			name:        "invalid mode",
+			input:       "invalid",
+			expected:    "",
+			expectError: true,
+		},
+		{
+			name:        "empty mode",
+			input:       "",
+			expected:    "",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseMode(tt.input)
+			if (err != nil) != tt.expectError {
+				t.Errorf("parseMode() error = %v, expectError %v", err, tt.expectError)
+				return
+			}
+			if result != tt.expected {
+				t.Errorf("parseMode() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsUndocumentedEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "nil error",
+			err:      nil,
+			expected: false,
+		},
+		{
+			name:     "path not found sentinel",
+			err:      routers.ErrPathNotFound,
+			expected: true,
+		},
+		{
+			name:     "method not allowed sentinel",
+			err:      routers.ErrMethodNotAllowed,
+			expected: true,
+		},
+		{
+			name:     "wrapped sentinel",
+			err:      fmt.Errorf("routing failed: %w", routers.ErrPathNotFound),
+			expected: true,
+		},
+		{
+			name:     "other route error",
+			err:      &routers.RouteError{Reason: "server mismatch"},
+			expected: false,
+		},
+		{
+			name:     "no matching operation",
+			err:      errors.New("no matching operation found"),
+			expected: true,
+		},
+		{
+			name:     "path not found",
+			err:      errors.New("path not found"),
+			expected: true,
+		},
+		{
+			name:     "operation not found",
+			err:      errors.New("operation not found"),
+			expected: true,
+		},
+		{
+			name:     "case insensitive match",
+			err:      errors.New("No Route Found"),
+			expected: true,
+		},
+		{
+			name:     "unrelated error",
+			err:      errors.New("connection refused"),
+			expected: false,
+		},
+		{
+			name:     "empty error message",
+			err:      errors.New(""),
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := isUndocumentedEndpoint(tt.err)
+			if result != tt.expected {
+				t.Errorf("isUndocumentedEndpoint() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_ReadResponseBody(t *testing.T) {
+	tests := []struct {
+		name          string
+		contentLength string
+		bodySize      int
+		unknownLength UnknownLength
+		expectSkipped bool
+	}{
+		{
+			name:          "small response",
+			contentLength: "100",
+			bodySize:      100,
+			expectSkipped: false,
+		},
+		{
+			name:          "large content-length header but small body",
+			contentLength: "20971520", // 20MB
+			bodySize:      1024,
+			expectSkipped: false,
+		},
+		{
+			name:          "no content-length but large body",
+			contentLength: "",
+			bodySize:      20971520, // 20MB
+			expectSkipped: true,
+		},
+		{
+			name:          "no content-length buffered",
+			contentLength: "",
+			bodySize:      100,
+			unknownLength: UnknownLengthBuffer,
+			expectSkipped: false,
+		},
+		{
+			name:          "no content-length skipped",
+			contentLength: "",
+			bodySize:      100,
+			unknownLength: UnknownLengthSkip,
+			expectSkipped: true,
+		},
+		{
+			name:          "exactly at limit",
+			contentLength: "10485760", // 10MB
+			bodySize:      10485760,
+			expectSkipped: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := make([]byte, tt.bodySize)
+			for i := range body {
+				body[i] = 'a'
+			}
+
+			resp := &http.Response{
+				Header:        make(http.Header),
+				Body:          io.NopCloser(bytes.NewReader(body)),
+				ContentLength: -1,
+				Request:       httptest.NewRequest(http.MethodGet, "/users", nil),
+			}
+
+			if tt.contentLength != "" {
+				resp.Header.Set("Content-Length", tt.contentLength)
+				resp.ContentLength, _ = strconv.ParseInt(tt.contentLength, 10, 64)
+			}
+
+			vp := &ValidatingProxy{
+				logger:      slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})),
+				maxBodySize: defaultMaxBodySize,
+				streaming:   streamPolicy{unknownLength: tt.unknownLength},
+			}
+
+			result, err := vp.readResponseBody(resp)
+
+			if err != nil {
+				t.Errorf("readResponseBody() unexpected error: %v", err)
+				return
+			}
+
+			if tt.expectSkipped {
+				if result != nil {
+					t.Errorf("readResponseBody() expected nil for large response, got %d bytes", len(result))
+				}
+				forwarded, _ := io.ReadAll(resp.Body)
+				if len(forwarded) != tt.bodySize {
+					t.Errorf("readResponseBody() left %d bytes for the client, expected %d", len(forwarded), tt.bodySize)
+				}
+			} else {
+				if result == nil {
+					t.Errorf("readResponseBody() expected body data, got nil")
+				} else if len(result) != tt.bodySize {
+					t.Errorf("readResponseBody() expected %d bytes, got %d", tt.bodySize, len(result))
+				}
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_ReplaceResponseWithError(t *testing.T) {
+	resp := &http.Response{
+		Header:     make(http.Header),
+		StatusCode: 200,
+		Body:       io.NopCloser(strings.NewReader(`{"original": "data"}`)),
+	}
+	resp.Header.Set("Content-Type", "application/json")
+	resp.Header.Set("Content-Encoding", "gzip")
+	resp.Header.Set("ETag", "123456")
+
+	vp := &ValidatingProxy{errorResponses: errorResponses{
+		status:       http.StatusInternalServerError,
+		expose:       true,
+		stripHeaders: DefaultConfig().StripErrorHeaders,
+	}}
+	testErr := errors.New("test validation error")
+
+	vp.replaceResponseWithError(resp, &routers.Route{Operation: &openapi3.Operation{OperationID: "getUser"}}, nil, testErr)
+
+	if resp.StatusCode != 500 {
+		t.Errorf("replaceResponseWithError() status code = %d, expected 500", resp.StatusCode)
+	}
+
+	if resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("replaceResponseWithError() content-type = %q, expected application/json", resp.Header.Get("Content-Type"))
+	}
+
+	if resp.Header.Get("Content-Encoding") != "" {
+		t.Errorf("replaceResponseWithError() should have removed Content-Encoding header")
+	}
+	if resp.Header.Get("ETag") != "" {
+		t.Errorf("replaceResponseWithError() should have removed ETag header")
+	}
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Errorf("replaceResponseWithError() failed to read body: %v", err)
+		return
+	}
+
+	if !strings.Contains(string(bodyBytes), "Response validation failed") {
+		t.Errorf("replaceResponseWithError() body should contain error message")
+	}
+	if !strings.Contains(string(bodyBytes), "test validation error") {
+		t.Errorf("replaceResponseWithError() body should contain validation error details")
+	}
+
+	expectedLength := len(bodyBytes)
+	actualLength, _ := strconv.Atoi(resp.Header.Get("Content-Length"))
+	if actualLength != expectedLength {
+		t.Errorf("replaceResponseWithError() content-length = %d, expected %d", actualLength, expectedLength)
+	}
+}
+
+func TestValidatingProxy_StripErrorHeaders(t *testing.T) {
+	tests := []struct {
+		name         string
+		stripHeaders FieldList
+		expectedKept []string
+		expectedGone []string
+	}{
+		{
+			name:         "defaults",
+			stripHeaders: DefaultConfig().StripErrorHeaders,
+			expectedKept: []string{"X-Cdn-Tag"},
+			expectedGone: []string{"Content-Encoding", "ETag", "Cache-Control", "Vary"},
+		},
+		{
+			name:         "custom list",
+			stripHeaders: FieldList{"Cache-Control", "x-cdn-tag"},
+			expectedKept: []string{"ETag", "Vary"},
+			expectedGone: []string{"Content-Encoding", "Cache-Control", "X-Cdn-Tag"},
+		},
+		{
+			name:         "empty list still strips encoding",
+			stripHeaders: FieldList{},
+			expectedKept: []string{"ETag", "Cache-Control", "Vary", "X-Cdn-Tag"},
+			expectedGone: []string{"Content-Encoding"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Encoding", "gzip")
+				w.Header().Set("ETag", `"v1"`)
+				w.Header().Set("Cache-Control", "public, max-age=3600")
+				w.Header().Set("Vary", "Accept-Encoding")
+				w.Header().Set("X-Cdn-Tag", "users")
+				_, _ = w.Write(compress(t, "gzip", []byte(`{"id": "one"}`)))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			cfg.StripErrorHeaders = tt.stripHeaders
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+			if rec.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d, expected %d", rec.Code, http.StatusInternalServerError)
+			}
+			for _, header := range tt.expectedKept {
+				if rec.Header().Get(header) == "" {
+					t.Errorf("header %s should be kept", header)
+				}
+			}
+			for _, header := range tt.expectedGone {
+				if value := rec.Header().Get(header); value != "" {
+					t.Errorf("header %s should be stripped, got %q", header, value)
+				}
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_CompressedResponses(t *testing.T) {
+	validBody := []byte(`{"id": 1, "name": "test"}`)
+	invalidBody := []byte(`{"id": "not-a-number"}`)
+
+	tests := []struct {
+		name           string
+		encoding       string
+		body           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "valid gzip body",
+			encoding:       "gzip",
+			body:           compress(t, "gzip", validBody),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid gzip body",
+			encoding:       "gzip",
+			body:           compress(t, "gzip", invalidBody),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "valid brotli body",
+			encoding:       "br",
+			body:           compress(t, "br", validBody),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid brotli body",
+			encoding:       "br",
+			body:           compress(t, "br", invalidBody),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "encoding declared but body not compressed",
+			encoding:       "gzip",
+			body:           validBody,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Encoding", tt.encoding)
+				_, _ = w.Write(tt.body)
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, testSpec, upstream.URL, "strict")
+
+			req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			req.Header.Set("Accept-Encoding", tt.encoding)
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("ServeHTTP() status = %d, expected %d, body: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+			if tt.expectedStatus == http.StatusOK && !bytes.Equal(rec.Body.Bytes(), tt.body) {
+				t.Errorf("ServeHTTP() should pass the original encoded body through to the client")
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_ModeOverrides(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"unexpected": true}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultConfig()
+	cfg.Upstream = upstream.URL
+	cfg.Mode = "warn"
+	cfg.ModeOverrides = []ModeOverride{
+		{Pattern: "/users/*", Mode: "strict"},
+		{Pattern: "/users/**", Mode: "report"},
+	}
+	vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{
+			name:           "override matches the path template",
+			path:           "/users/42",
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "no override falls back to global mode",
+			path:           "/users",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("ServeHTTP() status = %d, expected %d", rec.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestColoredHandler_ConcurrentWrites(t *testing.T) {
+	const goroutines = 50
+	const linesPerGoroutine = 100
+
+	var buf bytes.Buffer
+	logger := slog.New(NewColoredHandler(&buf, slog.LevelInfo)).With("component", "test")
+
+	var wg sync.WaitGroup
+	for i := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range linesPerGoroutine {
+				logger.Error("Response validation failed", "goroutine", i, "line", j)
+			}
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != goroutines*linesPerGoroutine {
+		t.Fatalf("expected %d log lines, got %d", goroutines*linesPerGoroutine, len(lines))
+	}
+
+	for _, line := range lines {
+		if !strings.HasPrefix(line, colorGray) ||
+			!strings.Contains(line, colorRed+"ERROR"+colorReset+" Response validation failed goroutine=") ||
+			!strings.HasSuffix(line, " component=test") {
+			t.Fatalf("malformed log line: %q", line)
+		}
+	}
+}
+
+func TestColoredHandler_WithGroup(t *testing.T) {
+	tests := []struct {
+		name     string
+		log      func(logger *slog.Logger)
+		expected []string
+	}{
+		{
+			name: "group prefixes record attributes",
+			log: func(logger *slog.Logger) {
+				logger.WithGroup("validation").Info("msg", "path", "/users")
+			},
+			expected: []string{" validation.path=/users"},
+		},
+		{
+			name: "attributes added before the group are not prefixed",
+			log: func(logger *slog.Logger) {
+				logger.With("method", "GET").WithGroup("validation").Info("msg", "path", "/users")
+			},
+			expected: []string{" method=GET", " validation.path=/users"},
+		},
+		{
+			name: "attributes added after the group are prefixed",
+			log: func(logger *slog.Logger) {
+				logger.WithGroup("validation").With("method", "GET").Info("msg")
+			},
+			expected: []string{" validation.method=GET"},
+		},
+		{
+			name: "nested groups",
+			log: func(logger *slog.Logger) {
+				logger.WithGroup("proxy").WithGroup("validation").Info("msg", "path", "/users")
+			},
+			expected: []string{" proxy.validation.path=/users"},
+		},
+		{
+			name: "group-valued attributes",
+			log: func(logger *slog.Logger) {
+				logger.WithGroup("proxy").Info("msg", slog.Group("request", "method", "GET", "path", "/users"))
+			},
+			expected: []string{" proxy.request.method=GET", " proxy.request.path=/users"},
+		},
+		{
+			name: "empty group name is ignored",
+			log: func(logger *slog.Logger) {
+				logger.WithGroup("").Info("msg", "path", "/users")
+			},
+			expected: []string{" path=/users"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.log(slog.New(NewColoredHandler(&buf, slog.LevelInfo)))
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(buf.String(), expected) {
+					t.Errorf("log output = %q, expected it to contain %q", buf.String(), expected)
+				}
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_UndocumentedLogging(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		expected    string
+		notExpected string
+	}{
+		{
+			name:        "unknown path",
+			method:      http.MethodGet,
+			path:        "/unknown",
+			expected:    `msg="Undocumented endpoint"`,
+			notExpected: "Undocumented method on known path",
+		},
+		{
+			name:        "undocumented method on known path",
+			method:      http.MethodDelete,
+			path:        "/users/1",
+			expected:    `msg="Undocumented method on known path"`,
+			notExpected: `msg="Undocumented endpoint"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			vp := newTestProxy(t, testSpec, upstream.URL, "warn")
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			vp.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			if !strings.Contains(logs.String(), tt.expected) {
+				t.Errorf("logs = %q, expected %q", logs.String(), tt.expected)
+			}
+			if strings.Contains(logs.String(), tt.notExpected) {
+				t.Errorf("logs = %q, did not expect %q", logs.String(), tt.notExpected)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_MaxBodySize(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": "not-a-number", "name": "a name that pushes the body past the limit"}`))
+	}))
+	defer upstream.Close()
+
+	tests := []struct {
+		name           string
+		maxBodySize    ByteSize
+		expectedStatus int
+	}{
+		{
+			name:           "body within limit is validated",
+			maxBodySize:    1024,
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "oversized body is skipped rather than failed",
+			maxBodySize:    16,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			cfg.MaxBodySize = tt.maxBodySize
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("ServeHTTP() status = %d, expected %d", rec.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestJoinURLPath(t *testing.T) {
+	tests := []struct {
+		name            string
+		base            string
+		request         string
+		expectedPath    string
+		expectedRawPath string
+	}{
+		{name: "root upstream", base: "http://api", request: "/users", expectedPath: "/users"},
+		{name: "base path", base: "http://api/api/v1", request: "/users", expectedPath: "/api/v1/users"},
+		{name: "base path with trailing slash", base: "http://api/api/v1/", request: "/users", expectedPath: "/api/v1/users"},
+		{name: "empty request path", base: "http://api/api/v1", request: "", expectedPath: "/api/v1/"},
+		{
+			name:            "escaped request path",
+			base:            "http://api/api/v1",
+			request:         "/files/a%2Fb",
+			expectedPath:    "/api/v1/files/a/b",
+			expectedRawPath: "/api/v1/files/a%2Fb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, _ := url.Parse(tt.base)
+			reqURL, _ := url.Parse(tt.request)
+
+			path, rawPath := joinURLPath(base, reqURL)
+			if path != tt.expectedPath || rawPath != tt.expectedRawPath {
+				t.Errorf("joinURLPath() = (%q, %q), expected (%q, %q)", path, rawPath, tt.expectedPath, tt.expectedRawPath)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_UpstreamBasePath(t *testing.T) {
+	var receivedPath string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": "not-a-number", "name": "test"}`))
+	}))
+	defer upstream.Close()
+
+	vp := newTestProxy(t, testSpec, upstream.URL+"/api/v1", "strict")
+
+	rec := httptest.NewRecorder()
+	vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	if receivedPath != "/api/v1/users/1" {
+		t.Errorf("upstream received path %q, expected %q", receivedPath, "/api/v1/users/1")
+	}
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected the response to be validated against /users/{id}, got status %d", rec.Code)
+	}
+}
+
+func TestValidatingProxy_SampleRate(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": "not-a-number", "name": "test"}`))
+	}))
+	defer upstream.Close()
+
+	tests := []struct {
+		name            string
+		mode            string
+		sampleRate      float64
+		expectedChecked int
+		expectedStatus  int
+	}{
+		{name: "validate everything", mode: "warn", sampleRate: 1, expectedChecked: 20, expectedStatus: http.StatusOK},
+		{name: "validate nothing", mode: "warn", sampleRate: 0, expectedChecked: 0, expectedStatus: http.StatusOK},
+		{name: "strict ignores sampling", mode: "strict", sampleRate: 0, expectedChecked: 20, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = tt.mode
+			cfg.SampleRate = tt.sampleRate
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			for range 20 {
+				rec := httptest.NewRecorder()
+				vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+				if rec.Code != tt.expectedStatus {
+					t.Fatalf("status = %d, expected %d", rec.Code, tt.expectedStatus)
+				}
+			}
+
+			if vp.stats.checked != tt.expectedChecked {
+				t.Errorf("validated %d responses, expected %d", vp.stats.checked, tt.expectedChecked)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_PartialSampleRate(t *testing.T) {
+	vp := newTestProxy(t, testSpec, "http://localhost:3000", "warn")
+	vp.sampleRate = 0.5
+	route := &routers.Route{Path: "/users/{id}"}
+
+	sampled := 0
+	for range 1000 {
+		if vp.sampled(route) {
+			sampled++
+		}
+	}
+	if sampled < 350 || sampled > 650 {
+		t.Errorf("sampled %d of 1000 responses at rate 0.5", sampled)
+	}
+}
+
+func TestValidatingProxy_SkipStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		skipStatus     string
+		upstreamStatus int
+		expectedStatus int
+	}{
+		{name: "not skipped", skipStatus: "404", upstreamStatus: http.StatusOK, expectedStatus: http.StatusInternalServerError},
+		{name: "exact code", skipStatus: "200", upstreamStatus: http.StatusOK, expectedStatus: http.StatusOK},
+		{name: "class", skipStatus: "5xx", upstreamStatus: http.StatusBadGateway, expectedStatus: http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.upstreamStatus)
+				_, _ = w.Write([]byte(`{"unexpected": true}`))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			if err := cfg.SkipStatus.Set(tt.skipStatus); err != nil {
+				t.Fatalf("failed to parse skip status: %v", err)
+			}
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d", rec.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_UpstreamUnavailable(t *testing.T) {
+	const spec = `openapi: 3.0.3
+info:
+  title: Status API
+  version: 1.0.0
+paths:
+  /status:
+    get:
+      responses:
+        default:
+          description: Error
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [code]
+`
+
+	tests := []struct {
+		name           string
+		status         int
+		retryAfter     string
+		expectedStatus int
+		expectedLog    string
+	}{
+		{name: "unavailable with retry-after", status: http.StatusServiceUnavailable, retryAfter: "120", expectedStatus: http.StatusServiceUnavailable, expectedLog: "retry_after=120"},
+		{name: "unavailable without retry-after", status: http.StatusServiceUnavailable, expectedStatus: http.StatusInternalServerError},
+		{name: "other status with retry-after", status: http.StatusTooManyRequests, retryAfter: "120", expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"message": "down for maintenance"}`))
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, spec, upstream.URL, "strict")
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d", rec.Code, tt.expectedStatus)
+			}
+			if tt.expectedLog == "" {
+				return
+			}
+			if rec.Header().Get("Retry-After") != tt.retryAfter {
+				t.Errorf("Retry-After = %q, expected %q", rec.Header().Get("Retry-After"), tt.retryAfter)
+			}
+			if !strings.Contains(logs.String(), "Upstream unavailable") || !strings.Contains(logs.String(), tt.expectedLog) {
+				t.Errorf("logs should report the unavailable upstream, got %s", logs.String())
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_HeadRequests(t *testing.T) {
+	const spec = `openapi: 3.0.3
+info:
+  title: Head API
+  version: 1.0.0
+paths:
+  /users/{id}:
+    get:
+      operationId: getUser
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: OK
+          headers:
+            X-Version:
+              required: true
+              schema:
+                type: integer
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [id]
+`
+
+	tests := []struct {
+		name           string
+		version        string
+		expectedStatus int
+	}{
+		{name: "valid headers", version: "3", expectedStatus: http.StatusOK},
+		{name: "invalid header", version: "latest", expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Length", "9")
+				w.Header().Set("X-Version", tt.version)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, spec, upstream.URL, "strict")
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/users/1", nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d, logs: %s", rec.Code, tt.expectedStatus, logs.String())
+			}
+			if strings.Contains(logs.String(), "Undocumented") {
+				t.Errorf("HEAD should be matched to the GET operation, got logs: %s", logs.String())
+			}
+			if tt.expectedStatus == http.StatusOK && strings.Contains(logs.String(), "validation failed") {
+				t.Errorf("the empty HEAD body should not be validated, got logs: %s", logs.String())
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_ResponseSize(t *testing.T) {
+	invalidBody := `{"id": "one", "name": "` + strings.Repeat("a", 1000) + `"}`
+
+	tests := []struct {
+		name           string
+		contentLength  string
+		body           string
+		maxBodySize    int64
+		expectedStatus int
+	}{
+		{name: "declared size", contentLength: strconv.Itoa(len(invalidBody)), body: invalidBody, maxBodySize: defaultMaxBodySize, expectedStatus: http.StatusInternalServerError},
+		{name: "declared larger than sent", contentLength: strconv.Itoa(20 << 20), body: invalidBody, maxBodySize: defaultMaxBodySize, expectedStatus: http.StatusInternalServerError},
+		{name: "declared smaller than sent", contentLength: "10", body: invalidBody, maxBodySize: 512, expectedStatus: http.StatusOK},
+		{name: "too large", contentLength: strconv.Itoa(len(invalidBody)), body: invalidBody, maxBodySize: 512, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vp := newTestProxy(t, testSpec, "http://localhost:3000", "strict")
+			vp.maxBodySize = tt.maxBodySize
+
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Header: http.Header{
+					"Content-Type":   {"application/json"},
+					"Content-Length": {tt.contentLength},
+				},
+				ContentLength: int64(len(tt.body)),
+				Body:          io.NopCloser(strings.NewReader(tt.body)),
+				Request:       httptest.NewRequest(http.MethodGet, "http://localhost:3000/users/1", nil),
+			}
+			if err := vp.validateResponse(resp); err != nil {
+				t.Fatalf("validateResponse() unexpected error: %v", err)
+			}
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d", resp.StatusCode, tt.expectedStatus)
+			}
+			if body, _ := io.ReadAll(resp.Body); tt.expectedStatus == http.StatusOK && string(body) != tt.body {
+				t.Errorf("skipped body should be passed through unchanged, got %d bytes", len(body))
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_AssumeJSON(t *testing.T) {
+	tests := []struct {
+		name                string
+		assumeJSON          bool
+		body                string
+		expectedStatus      int
+		expectedContentType string
+	}{
+		{name: "not assumed", body: `{"id": "one"}`, expectedStatus: http.StatusOK},
+		{name: "valid JSON", assumeJSON: true, body: `{"id": 1, "name": "Ann"}`, expectedStatus: http.StatusOK, expectedContentType: "application/json"},
+		{name: "invalid JSON", assumeJSON: true, body: ` {"id": "one"}`, expectedStatus: http.StatusInternalServerError, expectedContentType: "application/json"},
+		{name: "not JSON", assumeJSON: true, body: "id=one", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				// A nil Content-Type stops the server from sniffing one
+				w.Header()["Content-Type"] = nil
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			cfg.AssumeJSON = tt.assumeJSON
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d, body: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+			if contentType := rec.Header().Get("Content-Type"); contentType != tt.expectedContentType {
+				t.Errorf("Content-Type = %q, expected %q", contentType, tt.expectedContentType)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_BodylessResponses(t *testing.T) {
+	const spec = `openapi: 3.0.3
+info:
+  title: Bodyless API
+  version: 1.0.0
+paths:
+  /users/{id}:
+    delete:
+      operationId: deleteUser
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '204':
+          description: Deleted
+          headers:
+            X-Version:
+              required: true
+              schema:
+                type: integer
+        '202':
+          description: Accepted
+          headers:
+            X-Version:
+              required: true
+              schema:
+                type: integer
+`
+
+	tests := []struct {
+		name           string
+		status         int
+		version        string
+		body           string
+		expectedStatus int
+	}{
+		{name: "no content", status: http.StatusNoContent, version: "3", expectedStatus: http.StatusNoContent},
+		{name: "no content with invalid header", status: http.StatusNoContent, version: "latest", expectedStatus: http.StatusInternalServerError},
+		{name: "documented without content", status: http.StatusAccepted, version: "3", body: "queued", expectedStatus: http.StatusAccepted},
+		{name: "documented without content with invalid header", status: http.StatusAccepted, version: "latest", expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("X-Version", tt.version)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, spec, upstream.URL, "strict")
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/users/1", nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d, logs: %s", rec.Code, tt.expectedStatus, logs.String())
+			}
+			if strings.Contains(logs.String(), "not validated") {
+				t.Errorf("headers should be validated without a content type, got logs: %s", logs.String())
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_MissingBody(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		status         int
+		expectedStatus int
+		expectedLog    string
+	}{
+		{name: "documented content", method: http.MethodGet, status: http.StatusOK, expectedStatus: http.StatusInternalServerError, expectedLog: "response body is missing"},
+		{name: "head request", method: http.MethodHead, status: http.StatusOK, expectedStatus: http.StatusOK},
+		{name: "undocumented status", method: http.MethodGet, status: http.StatusNotFound, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, testSpec, upstream.URL, "strict")
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(tt.method, "/users/1", nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d, logs: %s", rec.Code, tt.expectedStatus, logs.String())
+			}
+			if tt.expectedLog != "" && !strings.Contains(logs.String(), tt.expectedLog) {
+				t.Errorf("logs should contain %q, got: %s", tt.expectedLog, logs.String())
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_RedirectResponses(t *testing.T) {
+	const spec = `openapi: 3.0.3
+info:
+  title: Redirect API
+  version: 1.0.0
+paths:
+  /docs:
+    get:
+      operationId: getDocs
+      responses:
+        '302':
+          description: Moved to the current version
+          headers:
+            Location:
+              required: true
+              schema:
+                type: string
+                pattern: '^/docs/v[0-9]+$'
+`
+
+	tests := []struct {
+		name           string
+		status         int
+		location       string
+		expectedStatus int
+		expectedLog    string
+	}{
+		{name: "documented redirect", status: http.StatusFound, location: "/docs/v2", expectedStatus: http.StatusFound},
+		{name: "missing location", status: http.StatusFound, expectedStatus: http.StatusInternalServerError, expectedLog: `response header \"Location\" missing`},
+		{name: "location not matching pattern", status: http.StatusFound, location: "https://example.com/", expectedStatus: http.StatusInternalServerError, expectedLog: "doesn't match the regular expression"},
+		{name: "undocumented redirect", status: http.StatusTemporaryRedirect, location: "https://example.com/", expectedStatus: http.StatusTemporaryRedirect},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.location == "" {
+					w.Header().Set("Content-Type", "text/html; charset=utf-8")
+					w.WriteHeader(tt.status)
+					_, _ = w.Write([]byte("<a>Found</a>."))
+					return
+				}
+				http.Redirect(w, r, tt.location, tt.status)
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, spec, upstream.URL, "strict")
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d, logs: %s", rec.Code, tt.expectedStatus, logs.String())
+			}
+			if !strings.Contains(logs.String(), tt.expectedLog) {
+				t.Errorf("logs should contain %q, got: %s", tt.expectedLog, logs.String())
+			}
+			if strings.Contains(logs.String(), "not validated") {
+				t.Errorf("redirect headers should be validated, got logs: %s", logs.String())
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_IncludeExcludePaths(t *testing.T) {
+	tests := []struct {
+		name           string
+		includePaths   string
+		excludePaths   string
+		expectedStatus int
+	}{
+		{name: "included", includePaths: "/users/*", expectedStatus: http.StatusInternalServerError},
+		{name: "not included", includePaths: "/users", expectedStatus: http.StatusOK},
+		{name: "excluded", excludePaths: "/users/**", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"unexpected": true}`))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			_ = cfg.IncludePaths.Set(tt.includePaths)
+			_ = cfg.ExcludePaths.Set(tt.excludePaths)
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d", rec.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_IgnorePaths(t *testing.T) {
+	tests := []struct {
+		name           string
+		basePath       string
+		path           string
+		expectedStatus int
+		expectWarning  bool
+	}{
+		{name: "ignored undocumented path", path: "/healthz", expectedStatus: http.StatusOK},
+		{name: "ignored documented path", path: "/users/1", expectedStatus: http.StatusOK},
+		{name: "undocumented path", path: "/metrics", expectedStatus: http.StatusOK, expectWarning: true},
+		{name: "ignored undocumented path under upstream base path", basePath: "/api", path: "/healthz", expectedStatus: http.StatusOK},
+		{name: "ignored documented path under upstream base path", basePath: "/api", path: "/users/1", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"unexpected": true}`))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL + tt.basePath
+			cfg.Mode = "strict"
+			cfg.ValidateRequests = true
+			cfg.IgnorePaths = FieldList{"/healthz", "/users/*"}
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d, logs: %s", rec.Code, tt.expectedStatus, logs.String())
+			}
+			if warned := strings.Contains(logs.String(), "Undocumented endpoint"); warned != tt.expectWarning {
+				t.Errorf("undocumented warning = %v, expected %v, logs: %s", warned, tt.expectWarning, logs.String())
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_FailureStatus(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"abc"`)
+		_, _ = w.Write([]byte(`{"id": "not-a-number", "name": "test"}`))
+	}))
+	defer upstream.Close()
+
+	cfg := DefaultConfig()
+	cfg.Upstream = upstream.URL
+	cfg.Mode = "strict"
+	cfg.FailureStatus = http.StatusBadGateway
+	vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+	rec := httptest.NewRecorder()
+	vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, expected %d", rec.Code, http.StatusBadGateway)
+	}
+	if rec.Header().Get("ETag") != "" {
+		t.Error("ETag should be removed from the error response")
+	}
+	if contentLength := rec.Header().Get("Content-Length"); contentLength != strconv.Itoa(rec.Body.Len()) {
+		t.Errorf("Content-Length = %s, expected %d", contentLength, rec.Body.Len())
+	}
+}
+
+func TestValidatingProxy_ExposeErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		exposeErrors bool
+		expectDetail bool
+	}{
+		{name: "hidden by default", exposeErrors: false, expectDetail: false},
+		{name: "exposed", exposeErrors: true, expectDetail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id": "not-a-number", "name": "test"}`))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			cfg.ExposeErrors = tt.exposeErrors
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+			if hasDetail := strings.Contains(rec.Body.String(), "not-a-number"); hasDetail != tt.expectDetail {
+				t.Errorf("body %s: contains error detail = %v, expected %v", rec.Body.String(), hasDetail, tt.expectDetail)
+			}
+			if hasOperation := strings.Contains(rec.Body.String(), `"operation_id":"getUser"`); hasOperation != tt.expectDetail {
+				t.Errorf("body %s: contains operation ID = %v, expected %v", rec.Body.String(), hasOperation, tt.expectDetail)
+			}
+			for _, expected := range []string{"not-a-number", "operation_id=getUser"} {
+				if !strings.Contains(logs.String(), expected) {
+					t.Errorf("log output should always contain %q, got %s", expected, logs.String())
+				}
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_ValidationHeader(t *testing.T) {
+	const invalidBody = `{"id": "not-a-number", "name": "test"}`
+	tests := []struct {
+		name             string
+		mode             string
+		validationHeader bool
+		exposeErrors     bool
+		body             string
+		expectedHeader   string
+		expectedError    string
+	}{
+		{name: "off by default", mode: "warn", body: invalidBody},
+		{name: "warn", mode: "warn", validationHeader: true, body: invalidBody, expectedHeader: "failed"},
+		{name: "report", mode: "report", validationHeader: true, body: invalidBody, expectedHeader: "failed"},
+		{
+			name:             "with exposed errors",
+			mode:             "warn",
+			validationHeader: true,
+			exposeErrors:     true,
+			body:             invalidBody,
+			expectedHeader:   "failed",
+			expectedError:    "response body .id: value must be an integer, got string",
+		},
+		{name: "valid response", mode: "warn", validationHeader: true, body: `{"id": 1, "name": "test"}`},
+		{name: "strict replaces the response", mode: "strict", validationHeader: true, body: invalidBody},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = tt.mode
+			cfg.ValidationHeader = tt.validationHeader
+			cfg.ExposeErrors = tt.exposeErrors
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+			if header := rec.Header().Get(validationHeader); header != tt.expectedHeader {
+				t.Errorf("%s = %q, expected %q", validationHeader, header, tt.expectedHeader)
+			}
+			if header := rec.Header().Get(validationErrorHeader); header != tt.expectedError {
+				t.Errorf("%s = %q, expected %q", validationErrorHeader, header, tt.expectedError)
+			}
+			if tt.mode != "strict" && rec.Body.String() != tt.body {
+				t.Errorf("body = %s, expected it unchanged", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestNew_SpecWithProblems(t *testing.T) {
+	spec := strings.Replace(testSpec, "type: integer", "type: integr", 1)
+
+	cfg := DefaultConfig()
+	cfg.Spec = writeTestSpec(t, spec)
+	cfg.LogFormat = string(LogFormatText)
+	if _, err := New(cfg); err != nil {
+		t.Errorf("New() should only warn about spec problems, got error: %v", err)
+	}
+}
+
+func TestNew_RouterBuildError(t *testing.T) {
+	spec := `openapi: 3.0.0
+info:
+  title: Test API
+  version: 1.0.0
+paths:
+  /users/{id:
+    get:
+      responses:
+        "200":
+          description: User
+`
+
+	cfg := DefaultConfig()
+	cfg.Spec = writeTestSpec(t, spec)
+	_, err := New(cfg)
+	if err == nil {
+		t.Fatal("New() should fail when the router can't be built")
+	}
+	if !strings.Contains(err.Error(), "failed to build gorillamux router") {
+		t.Errorf("error = %v, expected it to name the router build failure", err)
+	}
+}
+
+const testSpec31 = `openapi: 3.1.0
+jsonSchemaDialect: https://json-schema.org/draft/2020-12/schema
+info:
+  title: Test API
+  version: 1.0.0
+paths:
+  /users/{id}:
+    get:
+      operationId: getUser
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: User
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [id, nickname]
+                properties:
+                  id:
+                    type: integer
+                  nickname:
+                    type: [string, "null"]
+                  tags:
+                    type: array
+                    prefixItems:
+                      - const: primary
+`
+
+func TestValidatingProxy_OpenAPI31(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "string in nullable union", body: `{"id": 1, "nickname": "sam"}`, expectedStatus: http.StatusOK},
+		{name: "null in nullable union", body: `{"id": 1, "nickname": null}`, expectedStatus: http.StatusOK},
+		{name: "type outside union", body: `{"id": 1, "nickname": 5}`, expectedStatus: http.StatusInternalServerError},
+		{name: "2020-12 keyword", body: `{"id": 1, "nickname": null, "tags": ["secondary"]}`, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, testSpec31, upstream.URL, "strict")
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestLoadSpec_Versions(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        string
+		expectError string
+	}{
+		{name: "OpenAPI 3.0", spec: testSpec},
+		{name: "OpenAPI 3.1", spec: testSpec31},
+		{name: "Swagger 2.0", spec: testSwaggerSpec},
+		{
+			name:        "missing version",
+			spec:        "info:\n  title: Test API\n  version: 1.0.0\npaths: {}\n",
+			expectError: `no "openapi" version`,
+		},
+		{
+			name:        "future version",
+			spec:        strings.Replace(testSpec31, "openapi: 3.1.0", "openapi: 4.0.0", 1),
+			expectError: `unsupported OpenAPI version "4.0.0"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := specLoader{
+				upstreamURLs: []string{"http://localhost:3000"},
+				router:       RouterGorillaMux,
+				logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+			spec, _, err := loader.load(writeTestSpec(t, tt.spec))
+			if tt.expectError == "" {
+				if err != nil {
+					t.Fatalf("loadSpec() unexpected error: %v", err)
+				}
+				if err := lintSpec(spec); err != nil {
+					t.Errorf("lintSpec() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+				t.Errorf("loadSpec() error = %v, expected it to contain %q", err, tt.expectError)
+			}
+		})
+	}
+}
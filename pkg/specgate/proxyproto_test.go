@@ -0,0 +1,198 @@
+package specgate
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func proxyV2Header(command, family byte, addrs []byte) string {
+	header := append([]byte{}, proxyV2Signature...)
+	header = append(header, 0x20|command, family)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(addrs)))
+	return string(append(header, addrs...))
+}
+
+func TestReadProxyHeader(t *testing.T) {
+	ipv4 := []byte{203, 0, 113, 7, 10, 0, 0, 1, 0xc3, 0x50, 0x01, 0xbb}
+	ipv6 := append(net.ParseIP("2001:db8::1").To16(), net.ParseIP("2001:db8::2").To16()...)
+	ipv6 = append(ipv6, 0x04, 0xd2, 0x01, 0xbb)
+
+	tests := []struct {
+		name          string
+		input         string
+		expectedAddr  string
+		expectedError string
+	}{
+		{name: "v1 tcp4", input: "PROXY TCP4 203.0.113.7 10.0.0.1 50000 443\r\n", expectedAddr: "203.0.113.7:50000"},
+		{name: "v1 tcp6", input: "PROXY TCP6 2001:db8::1 2001:db8::2 1234 443\r\n", expectedAddr: "[2001:db8::1]:1234"},
+		{name: "v1 unknown", input: "PROXY UNKNOWN\r\n"},
+		{name: "v1 family mismatch", input: "PROXY TCP4 2001:db8::1 10.0.0.1 1234 443\r\n", expectedError: "invalid source address"},
+		{name: "v1 invalid port", input: "PROXY TCP4 203.0.113.7 10.0.0.1 99999 443\r\n", expectedError: "invalid source port"},
+		{name: "v1 malformed", input: "PROXY TCP4 203.0.113.7\r\n", expectedError: "malformed PROXY protocol v1 header"},
+		{name: "v1 too long", input: "PROXY TCP4 " + strings.Repeat("1", 120) + "\r\n", expectedError: "too long"},
+		{name: "v2 tcp4", input: proxyV2Header(0x1, 0x11, ipv4), expectedAddr: "203.0.113.7:50000"},
+		{name: "v2 tcp6", input: proxyV2Header(0x1, 0x21, ipv6), expectedAddr: "[2001:db8::1]:1234"},
+		{name: "v2 with TLVs", input: proxyV2Header(0x1, 0x11, append(ipv4, 0x04, 0x00, 0x01, 0x00)), expectedAddr: "203.0.113.7:50000"},
+		{name: "v2 local", input: proxyV2Header(0x0, 0x00, nil)},
+		{name: "v2 unix", input: proxyV2Header(0x1, 0x31, make([]byte, 216))},
+		{name: "v2 short addresses", input: proxyV2Header(0x1, 0x11, ipv4[:8]), expectedError: "too short"},
+		{name: "v2 unknown command", input: proxyV2Header(0x2, 0x11, ipv4), expectedError: "unsupported PROXY protocol command"},
+		{name: "no header", input: "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n", expectedError: "did not start with a PROXY protocol header"},
+		{name: "truncated", input: "PROXY", expectedError: "failed to read PROXY protocol header"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := readProxyHeader(bufio.NewReader(strings.NewReader(tt.input + "rest")))
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("readProxyHeader() error = %v, expected one containing %q", err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readProxyHeader() unexpected error: %v", err)
+			}
+
+			got := ""
+			if addr != nil {
+				got = addr.String()
+			}
+			if got != tt.expectedAddr {
+				t.Errorf("readProxyHeader() address = %q, expected %q", got, tt.expectedAddr)
+			}
+		})
+	}
+}
+
+func TestProxyProtocolListener(t *testing.T) {
+	tests := []struct {
+		name               string
+		header             string
+		expectedRemoteHost string
+		expectResponse     bool
+	}{
+		{name: "client address from header", header: "PROXY TCP4 203.0.113.7 10.0.0.1 50000 443\r\n", expectedRemoteHost: "203.0.113.7", expectResponse: true},
+		{name: "health check keeps peer address", header: "PROXY UNKNOWN\r\n", expectedRemoteHost: "127.0.0.1", expectResponse: true},
+		{name: "missing header closes connection"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("failed to listen: %v", err)
+			}
+			remoteHosts := make(chan string, 1)
+			server := &http.Server{
+				Handler: http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+					host, _, _ := net.SplitHostPort(r.RemoteAddr)
+					remoteHosts <- host
+				}),
+				ReadHeaderTimeout: proxyHeaderTimeout,
+			}
+			go func() { _ = server.Serve(NewProxyProtocolListener(listener)) }()
+			defer func() { _ = server.Close() }()
+
+			conn, err := net.Dial("tcp", listener.Addr().String())
+			if err != nil {
+				t.Fatalf("failed to connect: %v", err)
+			}
+			defer func() { _ = conn.Close() }()
+			if _, err := io.WriteString(conn, tt.header+"GET / HTTP/1.1\r\nHost: specgate\r\n\r\n"); err != nil {
+				t.Fatalf("failed to write request: %v", err)
+			}
+
+			resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+			if !tt.expectResponse {
+				if err == nil {
+					t.Fatalf("expected the connection to be closed, got status %d", resp.StatusCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to read response: %v", err)
+			}
+			_ = resp.Body.Close()
+			if host := <-remoteHosts; host != tt.expectedRemoteHost {
+				t.Errorf("request remote host = %q, expected %q", host, tt.expectedRemoteHost)
+			}
+		})
+	}
+}
+
+func TestProxyProtocolListener_ReadHeaderTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := &http.Server{
+		Handler:           http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}),
+		ReadHeaderTimeout: 100 * time.Millisecond,
+	}
+	go func() { _ = server.Serve(NewProxyProtocolListener(listener)) }()
+	defer func() { _ = server.Close() }()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	// A slowloris client sends the PROXY header promptly, then stalls mid-request
+	if _, err := io.WriteString(conn, "PROXY TCP4 203.0.113.7 10.0.0.1 50000 443\r\nGET / HTTP/1.1\r\n"); err != nil {
+		t.Fatalf("failed to write request: %v", err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = io.ReadAll(conn)
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		t.Fatal("the server should close a connection that exceeds ReadHeaderTimeout after the PROXY header")
+	}
+}
+
+func TestProxyProtocolConn_KeepsReadDeadline(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+	conn, err := NewProxyProtocolListener(listener).Accept()
+	if err != nil {
+		t.Fatalf("failed to accept: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err := io.WriteString(client, "PROXY TCP4 203.0.113.7 10.0.0.1 50000 443\r\nGET"); err != nil {
+		t.Fatalf("failed to write request: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+
+	readErr := make(chan error, 1)
+	go func() {
+		_, err := io.ReadAll(conn)
+		readErr <- err
+	}()
+	select {
+	case err := <-readErr:
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Errorf("read error = %v, expected the caller's deadline to expire", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("reading the PROXY header cleared the caller's read deadline")
+	}
+}
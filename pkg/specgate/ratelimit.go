@@ -0,0 +1,171 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"gopkg.in/yaml.v3"
+)
+
+// maxRateLimitBuckets bounds how many clients are tracked at once. The least
+// recently seen client is forgotten first, which only resets its bucket.
+const maxRateLimitBuckets = 10000
+
+// RateLimit is a per-client request rate, written as RATE or RATE:BURST,
+// where RATE is in requests per second. Without a burst, clients may send up
+// to one second's worth of requests at once.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+func ParseRateLimit(s string) (RateLimit, error) {
+	value, burstValue, hasBurst := strings.Cut(strings.TrimSpace(s), ":")
+	r, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || r <= 0 || math.IsInf(r, 0) {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q", s)
+	}
+
+	burst := max(1, int(math.Ceil(r)))
+	if hasBurst {
+		burst, err = strconv.Atoi(strings.TrimSpace(burstValue))
+		if err != nil || burst < 1 {
+			return RateLimit{}, fmt.Errorf("invalid rate limit burst %q", s)
+		}
+	}
+
+	return RateLimit{Rate: r, Burst: burst}, nil
+}
+
+func (l RateLimit) String() string {
+	if l.Rate == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(l.Rate, 'f', -1, 64) + ":" + strconv.Itoa(l.Burst)
+}
+
+func (l *RateLimit) Set(s string) error {
+	limit, err := ParseRateLimit(s)
+	if err != nil {
+		return err
+	}
+	*l = limit
+	return nil
+}
+
+func (l *RateLimit) UnmarshalYAML(node *yaml.Node) error {
+	return l.Set(node.Value)
+}
+
+func (l RateLimit) MarshalYAML() (any, error) {
+	return l.String(), nil
+}
+
+// rateLimiter keeps a token bucket per client in a bounded LRU.
+type rateLimiter struct {
+	limit     RateLimit
+	keyHeader string
+
+	mu      sync.Mutex
+	buckets map[string]*list.Element
+	order   *list.List
+}
+
+type rateLimitBucket struct {
+	key     string
+	limiter *rate.Limiter
+}
+
+func newRateLimiter(cfg *Config) *rateLimiter {
+	if cfg.RateLimit.Rate == 0 {
+		return nil
+	}
+	return &rateLimiter{
+		limit:     cfg.RateLimit,
+		keyHeader: cfg.RateLimitHeader,
+		buckets:   make(map[string]*list.Element),
+		order:     list.New(),
+	}
+}
+
+func (l *rateLimiter) bucket(key string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.buckets[key]; ok {
+		l.order.MoveToFront(elem)
+		return elem.Value.(*rateLimitBucket).limiter
+	}
+
+	if l.order.Len() >= maxRateLimitBuckets {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.buckets, oldest.Value.(*rateLimitBucket).key)
+	}
+	limiter := rate.NewLimiter(rate.Limit(l.limit.Rate), l.limit.Burst)
+	l.buckets[key] = l.order.PushFront(&rateLimitBucket{key: key, limiter: limiter})
+	return limiter
+}
+
+// clientKey identifies the client r is counted against: its API key when
+// keyHeader is configured and present, otherwise its address.
+func (l *rateLimiter) clientKey(r *http.Request, clientIPHeader string) string {
+	if l.keyHeader != "" {
+		if key := r.Header.Get(l.keyHeader); key != "" {
+			return "key:" + key
+		}
+	}
+	if ip, ok := clientIP(r, clientIPHeader); ok {
+		return "ip:" + ip.String()
+	}
+	return "addr:" + r.RemoteAddr
+}
+
+func (vp *ValidatingProxy) allowRate(w http.ResponseWriter, r *http.Request) bool {
+	limiter := vp.access.rateLimiter.bucket(vp.access.rateLimiter.clientKey(r, vp.access.clientIPHeader))
+	reservation := limiter.Reserve()
+	delay := reservation.Delay()
+	if delay == 0 {
+		return true
+	}
+	reservation.Cancel()
+
+	vp.log(r.Context()).Warn("Rate limit exceeded",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"retry_after", delay.Round(time.Millisecond))
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+	vp.writeError(w, ErrorDetails{
+		Status: http.StatusTooManyRequests,
+		Title:  "Too Many Requests",
+		Detail: "Rate limit exceeded, retry later",
+		Method: r.Method,
+		Path:   r.URL.Path,
+	})
+	return false
+}
@@ -0,0 +1,156 @@
+package specgate
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    RateLimit
+		expectError bool
+	}{
+		{input: "10", expected: RateLimit{Rate: 10, Burst: 10}},
+		{input: "10:20", expected: RateLimit{Rate: 10, Burst: 20}},
+		{input: "0.5", expected: RateLimit{Rate: 0.5, Burst: 1}},
+		{input: " 2.5 : 5 ", expected: RateLimit{Rate: 2.5, Burst: 5}},
+		{input: "", expectError: true},
+		{input: "0", expectError: true},
+		{input: "-1", expectError: true},
+		{input: "fast", expectError: true},
+		{input: "10:0", expectError: true},
+		{input: "10:many", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			limit, err := ParseRateLimit(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("ParseRateLimit(%q) expected error, got %+v", tt.input, limit)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRateLimit(%q) unexpected error: %v", tt.input, err)
+			}
+			if limit != tt.expected {
+				t.Errorf("ParseRateLimit(%q) = %+v, expected %+v", tt.input, limit, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_RateLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		requests  []http.Header
+		addrs     []string
+		expected  []int
+		upstreams int
+	}{
+		{
+			name:      "burst then limited",
+			addrs:     []string{"10.0.0.1:1000", "10.0.0.1:1001", "10.0.0.1:1002"},
+			expected:  []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+			upstreams: 2,
+		},
+		{
+			name:      "clients have separate buckets",
+			addrs:     []string{"10.0.0.1:1000", "10.0.0.1:1001", "10.0.0.2:1000"},
+			expected:  []int{http.StatusOK, http.StatusOK, http.StatusOK},
+			upstreams: 3,
+		},
+		{
+			name:   "api key header shares a bucket across addresses",
+			header: "X-API-Key",
+			addrs:  []string{"10.0.0.1:1000", "10.0.0.2:1000", "10.0.0.3:1000"},
+			requests: []http.Header{
+				{"X-Api-Key": {"abc"}},
+				{"X-Api-Key": {"abc"}},
+				{"X-Api-Key": {"abc"}},
+			},
+			expected:  []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+			upstreams: 2,
+		},
+		{
+			name:   "different api keys from one address",
+			header: "X-API-Key",
+			addrs:  []string{"10.0.0.1:1000", "10.0.0.1:1000", "10.0.0.1:1000"},
+			requests: []http.Header{
+				{"X-Api-Key": {"abc"}},
+				{"X-Api-Key": {"abc"}},
+				{"X-Api-Key": {"def"}},
+			},
+			expected:  []int{http.StatusOK, http.StatusOK, http.StatusOK},
+			upstreams: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstreams := 0
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				upstreams++
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id": 1, "name": "test"}`))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.RateLimit = RateLimit{Rate: 0.001, Burst: 2}
+			cfg.RateLimitHeader = tt.header
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			for i, addr := range tt.addrs {
+				req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+				req.RemoteAddr = addr
+				if tt.requests != nil {
+					req.Header = tt.requests[i]
+				}
+				rec := httptest.NewRecorder()
+				vp.ServeHTTP(rec, req)
+
+				if rec.Code != tt.expected[i] {
+					t.Errorf("request %d: status = %d, expected %d", i, rec.Code, tt.expected[i])
+				}
+				if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+					t.Errorf("request %d: expected Retry-After header", i)
+				}
+			}
+			if upstreams != tt.upstreams {
+				t.Errorf("upstream received %d requests, expected %d", upstreams, tt.upstreams)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_EvictsLeastRecentlyUsed(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RateLimit = RateLimit{Rate: 1, Burst: 1}
+	limiter := newRateLimiter(cfg)
+
+	first := limiter.bucket("client-0")
+	for i := 1; i < maxRateLimitBuckets; i++ {
+		limiter.bucket(fmt.Sprintf("client-%d", i))
+	}
+	if limiter.bucket("client-0") != first {
+		t.Fatal("expected bucket to be kept while under capacity")
+	}
+
+	limiter.bucket("client-new")
+	if got := limiter.order.Len(); got != maxRateLimitBuckets {
+		t.Errorf("tracked %d buckets, expected %d", got, maxRateLimitBuckets)
+	}
+	if _, ok := limiter.buckets["client-1"]; ok {
+		t.Error("expected least recently used bucket to be evicted")
+	}
+	if _, ok := limiter.buckets["client-0"]; !ok {
+		t.Error("expected recently used bucket to be kept")
+	}
+}
@@ -0,0 +1,139 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Fixture is a request and the upstream's response to it, as written by
+// -record.
+type Fixture struct {
+	Request  FixtureRequest  `json:"request"`
+	Response FixtureResponse `json:"response"`
+}
+
+type FixtureRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query,omitempty"`
+	Body   string `json:"body,omitempty"`
+}
+
+type FixtureResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// Key identifies the request a fixture answers. It is used in the fixture's
+// file name, so recording the same request again replaces the fixture.
+func (r FixtureRequest) Key() string {
+	hash := sha256.New()
+	for _, part := range []string{r.Method, r.Path, r.Query, r.Body} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func (r FixtureRequest) fileName() string {
+	path := strings.Trim(unsafeFileNameChars.ReplaceAllString(r.Path, "_"), "_")
+	return fmt.Sprintf("%s_%s_%s.json", r.Method, path, r.Key())
+}
+
+// fixtureWriter writes fixtures as JSON files to a directory.
+type fixtureWriter struct {
+	dir string
+}
+
+func newFixtureWriter(dir string) *fixtureWriter {
+	if dir == "" {
+		return nil
+	}
+	return &fixtureWriter{dir: dir}
+}
+
+func (w *fixtureWriter) Write(fixture Fixture) error {
+	if err := os.MkdirAll(w.dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create record directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(w.dir, fixture.Request.fileName()), data, 0o600)
+}
+
+type fixtureRequestKey struct{}
+
+// captureRequest buffers the request body and remembers the request as the
+// client sent it, before it is rewritten for the upstream. Requests with
+// bodies too large to buffer are not recorded.
+func (vp *ValidatingProxy) captureRequest(r *http.Request) *http.Request {
+	body, complete, err := readRequestBody(r, vp.maxBodySize)
+	if err != nil || !complete {
+		return r
+	}
+
+	captured := FixtureRequest{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		Body:   string(body),
+	}
+	return r.WithContext(context.WithValue(r.Context(), fixtureRequestKey{}, captured))
+}
+
+func (vp *ValidatingProxy) recordFixture(resp *http.Response, body []byte) {
+	request, ok := resp.Request.Context().Value(fixtureRequestKey{}).(FixtureRequest)
+	if !ok {
+		return
+	}
+
+	header := resp.Header.Clone()
+	// The body is stored decoded, so its original framing no longer applies
+	for _, name := range []string{"Content-Encoding", "Content-Length", "Transfer-Encoding", "Date"} {
+		header.Del(name)
+	}
+
+	fixture := Fixture{
+		Request: request,
+		Response: FixtureResponse{
+			Status: resp.StatusCode,
+			Header: header,
+			Body:   string(body),
+		},
+	}
+	if err := vp.recorder.Write(fixture); err != nil {
+		vp.log(resp.Request.Context()).Error("Failed to record fixture", "error", err, "method", request.Method, "path", request.Path)
+	}
+}
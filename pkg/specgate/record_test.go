@@ -0,0 +1,146 @@
+package specgate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidatingProxy_Record(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectFixture  bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "get with query",
+			method:         http.MethodGet,
+			path:           "/users?limit=5",
+			expectFixture:  true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[]`,
+		},
+		{
+			name:           "post with body",
+			method:         http.MethodPost,
+			path:           "/users",
+			body:           `{"name": "test"}`,
+			expectFixture:  true,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"id": 1}`,
+		},
+		{
+			name:   "undocumented endpoint",
+			method: http.MethodGet,
+			path:   "/health",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("X-Upstream", "yes")
+				switch {
+				case r.Method == http.MethodPost:
+					w.WriteHeader(http.StatusCreated)
+					_, _ = w.Write([]byte(`{"id": 1}`))
+				case r.URL.Path == "/users":
+					_, _ = w.Write([]byte(`[]`))
+				default:
+					_, _ = w.Write([]byte(`ok`))
+				}
+			}))
+			defer upstream.Close()
+
+			dir := filepath.Join(t.TempDir(), "fixtures")
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Record = dir
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, req)
+
+			entries, _ := os.ReadDir(dir)
+			if !tt.expectFixture {
+				if len(entries) != 0 {
+					t.Fatalf("expected no fixtures, got %d", len(entries))
+				}
+				return
+			}
+			if len(entries) != 1 {
+				t.Fatalf("expected 1 fixture, got %d", len(entries))
+			}
+			if !strings.HasPrefix(entries[0].Name(), tt.method+"_users_") {
+				t.Errorf("fixture name = %q, expected prefix %q", entries[0].Name(), tt.method+"_users_")
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+			if err != nil {
+				t.Fatalf("failed to read fixture: %v", err)
+			}
+			var fixture Fixture
+			if err := json.Unmarshal(data, &fixture); err != nil {
+				t.Fatalf("failed to parse fixture: %v", err)
+			}
+
+			if fixture.Request.Method != tt.method || fixture.Request.Path+queryString(fixture.Request.Query) != tt.path {
+				t.Errorf("fixture request = %s %s?%s, expected %s %s", fixture.Request.Method, fixture.Request.Path, fixture.Request.Query, tt.method, tt.path)
+			}
+			if fixture.Request.Body != tt.body {
+				t.Errorf("fixture request body = %q, expected %q", fixture.Request.Body, tt.body)
+			}
+			if fixture.Response.Status != tt.expectedStatus {
+				t.Errorf("fixture status = %d, expected %d", fixture.Response.Status, tt.expectedStatus)
+			}
+			if fixture.Response.Body != tt.expectedBody {
+				t.Errorf("fixture body = %q, expected %q", fixture.Response.Body, tt.expectedBody)
+			}
+			if fixture.Response.Header.Get("X-Upstream") != "yes" {
+				t.Errorf("fixture headers = %v, expected X-Upstream to be kept", fixture.Response.Header)
+			}
+			if fixture.Response.Header.Get("Content-Length") != "" {
+				t.Errorf("fixture headers = %v, expected Content-Length to be dropped", fixture.Response.Header)
+			}
+
+			if rec.Body.String() != tt.expectedBody {
+				t.Errorf("response body = %q, expected %q", rec.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestFixtureRequest_Key(t *testing.T) {
+	base := FixtureRequest{Method: http.MethodGet, Path: "/users", Query: "limit=5"}
+
+	variants := []FixtureRequest{
+		{Method: http.MethodPost, Path: "/users", Query: "limit=5"},
+		{Method: http.MethodGet, Path: "/users/1", Query: "limit=5"},
+		{Method: http.MethodGet, Path: "/users", Query: "limit=6"},
+		{Method: http.MethodGet, Path: "/users", Query: "limit=5", Body: "{}"},
+	}
+
+	for _, variant := range variants {
+		if variant.Key() == base.Key() {
+			t.Errorf("Key() for %+v matches %+v", variant, base)
+		}
+	}
+}
+
+func queryString(query string) string {
+	if query == "" {
+		return ""
+	}
+	return "?" + query
+}
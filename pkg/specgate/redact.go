@@ -0,0 +1,217 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+const redactedValue = "***"
+
+// FieldList is a comma-separated list of values, such as the JSON field
+// names or dotted paths of -redact-fields, where * in user.*.ssn matches any
+// single field or array index.
+type FieldList []string
+
+func (l FieldList) String() string {
+	return strings.Join(l, ",")
+}
+
+func (l *FieldList) Set(value string) error {
+	var fields FieldList
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	*l = fields
+	return nil
+}
+
+func validateFieldPattern(pattern string) error {
+	if slices.Contains(strings.Split(pattern, "."), "") {
+		return fmt.Errorf("invalid field pattern %q", pattern)
+	}
+	return nil
+}
+
+// redactor replaces the values of sensitive fields in validation errors
+// before they are logged or returned to clients. Field names are matched
+// case-insensitively against the end of each value's path.
+type redactor struct {
+	patterns [][]string
+}
+
+func newRedactor(fields FieldList) redactor {
+	patterns := make([][]string, 0, len(fields))
+	for _, field := range fields {
+		patterns = append(patterns, strings.Split(field, "."))
+	}
+	return redactor{patterns: patterns}
+}
+
+// redact rewrites the values held by the schema and parse errors within err
+// in place, including those of request parameters, which are matched by the
+// parameter's name. Their messages don't otherwise contain values, so this
+// is all that needs to change for err.Error() to be safe to expose.
+func (r redactor) redact(err error) {
+	if len(r.patterns) == 0 {
+		return
+	}
+	r.redactAt(err, nil)
+}
+
+func (r redactor) redactAt(err error, path []string) {
+	if err == nil {
+		return
+	}
+
+	switch e := err.(type) {
+	case *openapi3.SchemaError:
+		e.Value = r.redactValue(e.Value, append(slices.Clip(path), e.JSONPointer()...))
+	case *openapi3filter.ParseError:
+		if r.matchesAny(append(slices.Clip(path), parsePath(e)...)) {
+			redactParseError(e)
+			return
+		}
+	case *formFieldError:
+		if r.matchesAny(append(slices.Clip(path), e.field)) {
+			e.value = redactedValue
+		}
+		return
+	case *openapi3filter.RequestError:
+		if e.Parameter != nil {
+			r.redactAt(e.Err, []string{e.Parameter.Name})
+			return
+		}
+	case openapi3.MultiError:
+		for _, inner := range e {
+			r.redactAt(inner, path)
+		}
+		return
+	}
+
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		r.redactAt(e.Unwrap(), path)
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			r.redactAt(inner, path)
+		}
+	}
+}
+
+func parsePath(e *openapi3filter.ParseError) []string {
+	var path []string
+	for _, segment := range e.Path() {
+		path = append(path, fmt.Sprint(segment))
+	}
+	return path
+}
+
+// redactParseError replaces the raw value of e and of the parse errors that
+// caused it, which all hold the same value or parts of it.
+func redactParseError(e *openapi3filter.ParseError) {
+	for e != nil {
+		if e.Value != nil {
+			e.Value = redactedValue
+		}
+		e, _ = e.Cause.(*openapi3filter.ParseError)
+	}
+}
+
+// redactJSON returns body with the values of sensitive fields replaced, and
+// whether body could be redacted: a body that isn't JSON can't be when there
+// are fields to redact.
+func (r redactor) redactJSON(body []byte) ([]byte, bool) {
+	if len(r.patterns) == 0 {
+		return body, true
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+	redacted, err := json.Marshal(r.redactValue(value, nil))
+	return redacted, err == nil
+}
+
+func (r redactor) redactValue(value any, path []string) any {
+	if r.matches(path) {
+		return redactedValue
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for key, field := range v {
+			redacted[key] = r.redactValue(field, append(slices.Clip(path), key))
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(v))
+		for i, item := range v {
+			redacted[i] = r.redactValue(item, append(slices.Clip(path), strconv.Itoa(i)))
+		}
+		return redacted
+	}
+	return value
+}
+
+// matchesAny reports whether path or any path above it matches, as a
+// sensitive field's value includes everything nested in it.
+func (r redactor) matchesAny(path []string) bool {
+	for i := len(path); i > 0; i-- {
+		if r.matches(path[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r redactor) matches(path []string) bool {
+	for _, pattern := range r.patterns {
+		if len(pattern) > len(path) {
+			continue
+		}
+
+		tail := path[len(path)-len(pattern):]
+		matched := true
+		for i, segment := range pattern {
+			if segment != "*" && !strings.EqualFold(segment, tail[i]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
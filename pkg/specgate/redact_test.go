@@ -0,0 +1,190 @@
+package specgate
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFieldList_Set(t *testing.T) {
+	var fields FieldList
+	if err := fields.Set(" password, user.ssn ,,"); err != nil {
+		t.Fatalf("Set() unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(fields, FieldList{"password", "user.ssn"}) {
+		t.Errorf("Set() = %v, expected [password user.ssn]", fields)
+	}
+}
+
+func TestRedactor_RedactValue(t *testing.T) {
+	value := map[string]any{
+		"name":     "Alice",
+		"Password": "hunter2",
+		"user":     map[string]any{"ssn": "123-45-6789", "email": "alice@example.com"},
+		"cards":    []any{map[string]any{"number": "4111111111111111", "brand": "visa"}},
+		"ssn":      "top-level ssn is not under user",
+	}
+
+	r := newRedactor(FieldList{"password", "user.ssn", "cards.*.number"})
+	expected := map[string]any{
+		"name":     "Alice",
+		"Password": redactedValue,
+		"user":     map[string]any{"ssn": redactedValue, "email": "alice@example.com"},
+		"cards":    []any{map[string]any{"number": redactedValue, "brand": "visa"}},
+		"ssn":      "top-level ssn is not under user",
+	}
+
+	if redacted := r.redactValue(value, nil); !reflect.DeepEqual(redacted, expected) {
+		t.Errorf("redactValue() = %v, expected %v", redacted, expected)
+	}
+	if value["Password"] != "hunter2" {
+		t.Error("redactValue() should not modify the original value")
+	}
+}
+
+func TestValidatingProxy_RedactFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields FieldList
+		body   string
+		secret string
+	}{
+		{
+			name:   "sensitive field inside failing object",
+			fields: FieldList{"password"},
+			body:   `{"name": "Alice", "password": "hunter2"}`,
+			secret: "hunter2",
+		},
+		{
+			name:   "failing field is sensitive",
+			fields: FieldList{"id"},
+			body:   `{"id": "secret-id", "name": "Alice"}`,
+			secret: "secret-id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			cfg.RedactFields = tt.fields
+			cfg.ExposeErrors = true
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+			if rec.Code != http.StatusInternalServerError {
+				t.Fatalf("ServeHTTP() status = %d, expected %d", rec.Code, http.StatusInternalServerError)
+			}
+			if strings.Contains(rec.Body.String(), tt.secret) || !strings.Contains(rec.Body.String(), redactedValue) {
+				t.Errorf("response body not redacted: %s", rec.Body.String())
+			}
+			if strings.Contains(logs.String(), tt.secret) || !strings.Contains(logs.String(), redactedValue) {
+				t.Errorf("log output not redacted: %s", logs.String())
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_RedactParameters(t *testing.T) {
+	const spec = `openapi: 3.0.3
+info:
+  title: Test API
+  version: 1.0.0
+paths:
+  /items:
+    get:
+      parameters:
+        - name: pin
+          in: query
+          schema:
+            type: integer
+        - name: ids
+          in: query
+          schema:
+            type: array
+            items:
+              type: integer
+        - name: filter
+          in: query
+          style: deepObject
+          explode: true
+          schema:
+            type: object
+            properties:
+              secret:
+                type: integer
+        - name: X-Token
+          in: header
+          schema:
+            type: string
+            pattern: ^tok-
+      responses:
+        "200":
+          description: Items
+`
+
+	tests := []struct {
+		name   string
+		fields FieldList
+		target string
+		token  string
+	}{
+		{name: "query parameter that doesn't parse", fields: FieldList{"pin"}, target: "/items?pin=s3cret"},
+		{name: "array item that doesn't parse", fields: FieldList{"ids"}, target: "/items?ids=1,s3cret"},
+		{name: "nested field that doesn't parse", fields: FieldList{"secret"}, target: "/items?filter[secret]=s3cret"},
+		{name: "header that breaks its schema", fields: FieldList{"x-token"}, target: "/items", token: "s3cret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			cfg.ValidateRequests = true
+			cfg.RedactFields = tt.fields
+			cfg.ExposeErrors = true
+			vp := newTestProxyWithConfig(t, spec, cfg)
+
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.token != "" {
+				req.Header.Set("X-Token", tt.token)
+			}
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("ServeHTTP() status = %d, expected %d", rec.Code, http.StatusBadRequest)
+			}
+			if strings.Contains(rec.Body.String(), "s3cret") || !strings.Contains(rec.Body.String(), redactedValue) {
+				t.Errorf("response body not redacted: %s", rec.Body.String())
+			}
+			if strings.Contains(logs.String(), "s3cret") {
+				t.Errorf("log output not redacted: %s", logs.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RefreshSpec re-fetches the remote spec every interval until ctx is
+// cancelled. A spec that fails to fetch or load is logged and the previous
+// version stays in use.
+func (vp *ValidatingProxy) RefreshSpec(ctx context.Context, interval time.Duration) error {
+	if !isRemoteSpec(vp.specPath) {
+		return errors.New("only remote specs can be refreshed")
+	}
+	if interval <= 0 {
+		return fmt.Errorf("refresh interval must be positive, got %s", interval)
+	}
+
+	go vp.refreshLoop(ctx, interval)
+	return nil
+}
+
+func (vp *ValidatingProxy) refreshLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	version := vp.specLoader.versions[vp.specPath]
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			version = vp.refreshRemoteSpec(ctx, version)
+		}
+	}
+}
+
+// specVersion holds the validators a remote spec was last served with, sent
+// back on refresh so an unchanged spec isn't downloaded and parsed again.
+type specVersion struct {
+	etag         string
+	lastModified string
+}
+
+// refreshRemoteSpec returns the version to send with the next refresh.
+func (vp *ValidatingProxy) refreshRemoteSpec(ctx context.Context, version specVersion) specVersion {
+	data, newVersion, err := fetchSpec(ctx, vp.specPath, version)
+	if err != nil {
+		vp.logger.Error("Failed to refresh spec, keeping previous version",
+			"error", err,
+			"spec", vp.specPath)
+		return version
+	}
+	if data == nil {
+		vp.logger.Debug("Spec unchanged", "spec", vp.specPath)
+		return version
+	}
+
+	specURL, err := url.Parse(vp.specPath)
+	if err != nil {
+		vp.logger.Error("Failed to refresh spec, keeping previous version", "error", err, "spec", vp.specPath)
+		return version
+	}
+
+	spec, router, err := vp.specLoader.loadData(data, specURL)
+	if err != nil {
+		vp.logger.Error("Failed to refresh spec, keeping previous version",
+			"error", err,
+			"spec", vp.specPath)
+		return version
+	}
+
+	vp.swapSpec(spec, router)
+	vp.logger.Info("Refreshed spec", "spec", vp.specPath)
+	vp.warnSpecIssues(spec)
+	return newVersion
+}
+
+// fetchSpec returns nil data when the server reports the spec is unchanged
+// since version.
+func fetchSpec(ctx context.Context, specURL string, version specVersion) ([]byte, specVersion, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, specURL, nil)
+	if err != nil {
+		return nil, specVersion{}, fmt.Errorf("invalid spec URL: %w", err)
+	}
+	if version.etag != "" {
+		req.Header.Set("If-None-Match", version.etag)
+	}
+	if version.lastModified != "" {
+		req.Header.Set("If-Modified-Since", version.lastModified)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, specVersion{}, fmt.Errorf("failed to fetch spec: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, version, nil
+	case http.StatusOK:
+	default:
+		return nil, specVersion{}, fmt.Errorf("failed to fetch spec: unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, specVersion{}, fmt.Errorf("failed to read spec: %w", err)
+	}
+	return data, specVersion{etag: resp.Header.Get("ETag"), lastModified: resp.Header.Get("Last-Modified")}, nil
+}
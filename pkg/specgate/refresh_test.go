@@ -0,0 +1,175 @@
+package specgate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type specServer struct {
+	mu               sync.Mutex
+	spec             string
+	version          int
+	status           int
+	notModifiedCount int
+}
+
+func (s *specServer) set(spec string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spec = spec
+	s.status = status
+	s.version++
+}
+
+func (s *specServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status != http.StatusOK {
+		w.WriteHeader(s.status)
+		return
+	}
+
+	etag := fmt.Sprintf(`"v%d"`, s.version)
+	if r.Header.Get("If-None-Match") == etag {
+		s.notModifiedCount++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = io.WriteString(w, s.spec)
+}
+
+func TestValidatingProxy_RefreshRemoteSpec(t *testing.T) {
+	specs := &specServer{}
+	specs.set(testSpec, http.StatusOK)
+	server := httptest.NewServer(specs)
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.Spec = server.URL + "/openapi.yaml"
+	vp, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() unexpected error: %v", err)
+	}
+	vp.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	ctx := context.Background()
+	version := vp.refreshRemoteSpec(ctx, specVersion{})
+	if version.etag != `"v1"` {
+		t.Fatalf("refreshRemoteSpec() etag = %q, expected %q", version.etag, `"v1"`)
+	}
+
+	if version = vp.refreshRemoteSpec(ctx, version); version.etag != `"v1"` || specs.notModifiedCount != 1 {
+		t.Errorf("unchanged spec: etag = %q, 304 responses = %d", version.etag, specs.notModifiedCount)
+	}
+
+	specs.set(testSpec+`  /orders:
+    get:
+      responses:
+        "200":
+          description: Orders
+`, http.StatusOK)
+	if version = vp.refreshRemoteSpec(ctx, version); version.etag != `"v2"` || !routeExists(vp, "/orders") {
+		t.Fatalf("updated spec was not swapped in (etag %q)", version.etag)
+	}
+
+	tests := []struct {
+		name   string
+		spec   string
+		status int
+	}{
+		{name: "invalid spec", spec: "openapi: [not valid", status: http.StatusOK},
+		{name: "server error", status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specs.set(tt.spec, tt.status)
+			if got := vp.refreshRemoteSpec(ctx, version); got != version {
+				t.Errorf("refreshRemoteSpec() version = %+v, expected previous %+v", got, version)
+			}
+			if !routeExists(vp, "/orders") || !routeExists(vp, "/users/1") {
+				t.Error("a failed refresh should keep serving the last good spec")
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_RefreshStartsFromInitialLoad(t *testing.T) {
+	const lastModified = "Wed, 14 Oct 2026 09:00:00 GMT"
+	tests := []struct {
+		name    string
+		etag    string
+		matches func(r *http.Request) bool
+	}{
+		{name: "ETag", etag: `"v1"`, matches: func(r *http.Request) bool { return r.Header.Get("If-None-Match") == `"v1"` }},
+		{name: "Last-Modified", matches: func(r *http.Request) bool { return r.Header.Get("If-Modified-Since") == lastModified }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetches, notModified int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fetches++
+				if tt.matches(r) {
+					notModified++
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				if tt.etag != "" {
+					w.Header().Set("ETag", tt.etag)
+				} else {
+					w.Header().Set("Last-Modified", lastModified)
+				}
+				_, _ = io.WriteString(w, testSpec)
+			}))
+			defer server.Close()
+
+			cfg := DefaultConfig()
+			cfg.Spec = server.URL + "/openapi.yaml"
+			vp, err := New(cfg)
+			if err != nil {
+				t.Fatalf("New() unexpected error: %v", err)
+			}
+			vp.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+			version := vp.specLoader.versions[cfg.Spec]
+			vp.refreshRemoteSpec(context.Background(), version)
+			if fetches != 2 || notModified != 1 {
+				t.Errorf("the first refresh should be conditional on the initial load, got %d fetches and %d 304 responses", fetches, notModified)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_RefreshSpecRejectsInvalidSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		specPath string
+		interval time.Duration
+		expected string
+	}{
+		{name: "local spec", specPath: "openapi.yaml", interval: time.Minute, expected: "remote"},
+		{name: "zero interval", specPath: "https://api.example.com/openapi.yaml", expected: "positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vp := &ValidatingProxy{specPath: tt.specPath}
+			err := vp.RefreshSpec(context.Background(), tt.interval)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("RefreshSpec() error = %v, expected it to mention %q", err, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReplayFallback controls what happens to a request in replay mode when no
+// recorded fixture matches it.
+type ReplayFallback string
+
+const (
+	ReplayFallbackNotFound ReplayFallback = "not_found"
+	ReplayFallbackUpstream ReplayFallback = "upstream"
+)
+
+func parseReplayFallback(fallback string) (ReplayFallback, error) {
+	switch ReplayFallback(strings.ToLower(fallback)) {
+	case ReplayFallbackNotFound:
+		return ReplayFallbackNotFound, nil
+	case ReplayFallbackUpstream:
+		return ReplayFallbackUpstream, nil
+	default:
+		return "", fmt.Errorf("invalid replay fallback '%s': must be one of 'not_found' or 'upstream'", fallback)
+	}
+}
+
+// fixtureSet holds recorded fixtures by the key of the request they answer.
+type fixtureSet map[string]Fixture
+
+// loadFixtures reads every fixture in dir, as written by -record. It returns
+// nil when dir is empty.
+func loadFixtures(dir string) (fixtureSet, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("failed to open replay directory: %w", err)
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	fixtures := make(fixtureSet, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path) // #nosec G304 -- path is inside the operator's replay directory
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture: %w", err)
+		}
+		var fixture Fixture
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+		}
+		fixtures[fixture.Request.Key()] = fixture
+	}
+	return fixtures, nil
+}
+
+func (s fixtureSet) lookup(r *http.Request) (Fixture, bool) {
+	request, ok := r.Context().Value(fixtureRequestKey{}).(FixtureRequest)
+	if !ok {
+		return Fixture{}, false
+	}
+	fixture, ok := s[request.Key()]
+	return fixture, ok
+}
+
+// replayTransport answers requests from recorded fixtures. Requests without
+// a fixture are passed on to next if the fallback allows it.
+type replayTransport struct {
+	fixtures fixtureSet
+	fallback ReplayFallback
+	next     http.RoundTripper
+}
+
+// newReplayTransport returns nil when cfg doesn't replay fixtures.
+func newReplayTransport(cfg *Config, next http.RoundTripper) (*replayTransport, error) {
+	fallback, err := parseReplayFallback(cfg.ReplayFallback)
+	if err != nil {
+		return nil, err
+	}
+	fixtures, err := loadFixtures(cfg.Replay)
+	if err != nil || fixtures == nil {
+		return nil, err
+	}
+	return &replayTransport{fixtures: fixtures, fallback: fallback, next: next}, nil
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fixture, ok := t.fixtures.lookup(req)
+	if !ok {
+		if t.fallback != ReplayFallbackUpstream {
+			return nil, fmt.Errorf("no recorded fixture for %s %s", req.Method, req.URL.Path)
+		}
+		return t.next.RoundTrip(req)
+	}
+
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+
+	header := fixture.Response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	status := fixture.Response.Status
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(fixture.Response.Body)),
+		ContentLength: int64(len(fixture.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// serveReplayMiss answers requests that have no recorded fixture with a 404,
+// unless they should fall back to the upstream.
+func (vp *ValidatingProxy) serveReplayMiss(w http.ResponseWriter, r *http.Request) bool {
+	if vp.replay == nil || vp.replay.fallback == ReplayFallbackUpstream {
+		return false
+	}
+	if _, ok := vp.replay.fixtures.lookup(r); ok {
+		return false
+	}
+
+	vp.log(r.Context()).Warn("No recorded fixture for request", "method", r.Method, "path", r.URL.Path)
+	vp.writeError(w, ErrorDetails{
+		Status: http.StatusNotFound,
+		Title:  "No recorded fixture",
+		Detail: fmt.Sprintf("%s %s has no recorded response", r.Method, r.URL.Path),
+		Method: r.Method,
+		Path:   r.URL.Path,
+	})
+	return true
+}
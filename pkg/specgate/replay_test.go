@@ -0,0 +1,193 @@
+package specgate
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidatingProxy_Replay(t *testing.T) {
+	fixtures := []Fixture{
+		{
+			Request: FixtureRequest{Method: http.MethodGet, Path: "/users/1"},
+			Response: FixtureResponse{
+				Status: http.StatusOK,
+				Header: http.Header{"Content-Type": {"application/json"}},
+				Body:   `{"id": 1, "name": "recorded"}`,
+			},
+		},
+		{
+			Request: FixtureRequest{Method: http.MethodGet, Path: "/users/2"},
+			Response: FixtureResponse{
+				Status: http.StatusOK,
+				Header: http.Header{"Content-Type": {"application/json"}},
+				Body:   `{"id": "two"}`,
+			},
+		},
+		{
+			Request: FixtureRequest{Method: http.MethodPost, Path: "/users", Body: `{"name": "test"}`},
+			Response: FixtureResponse{
+				Status: http.StatusCreated,
+				Header: http.Header{"Content-Type": {"application/json"}},
+				Body:   `{"id": 3}`,
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		fallback       string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "recorded response",
+			method:         http.MethodGet,
+			path:           "/users/1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id": 1, "name": "recorded"}`,
+		},
+		{
+			name:           "recorded response with body",
+			method:         http.MethodPost,
+			path:           "/users",
+			body:           `{"name": "test"}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"id": 3}`,
+		},
+		{
+			name:           "recorded response is validated",
+			method:         http.MethodGet,
+			path:           "/users/2",
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "different body has no fixture",
+			method:         http.MethodPost,
+			path:           "/users",
+			body:           `{"name": "other"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "no fixture",
+			method:         http.MethodGet,
+			path:           "/users/9",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "no fixture falls back to upstream",
+			method:         http.MethodGet,
+			path:           "/users/9",
+			fallback:       "upstream",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id": 9, "name": "live"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id": 9, "name": "live"}`))
+			}))
+			defer upstream.Close()
+
+			dir := t.TempDir()
+			writer := newFixtureWriter(dir)
+			for _, fixture := range fixtures {
+				if err := writer.Write(fixture); err != nil {
+					t.Fatalf("failed to write fixture: %v", err)
+				}
+			}
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			cfg.Replay = dir
+			if tt.fallback != "" {
+				cfg.ReplayFallback = tt.fallback
+			}
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("status = %d, expected %d: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+			if tt.expectedBody != "" {
+				body, _ := io.ReadAll(rec.Body)
+				if string(body) != tt.expectedBody {
+					t.Errorf("body = %q, expected %q", body, tt.expectedBody)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadFixtures(t *testing.T) {
+	tests := []struct {
+		name          string
+		files         map[string]string
+		missingDir    bool
+		expectedCount int
+		expectedError string
+	}{
+		{
+			name: "fixtures and other files",
+			files: map[string]string{
+				"a.json":    `{"request": {"method": "GET", "path": "/users"}, "response": {"status": 200}}`,
+				"b.json":    `{"request": {"method": "GET", "path": "/users/1"}, "response": {"status": 200}}`,
+				"notes.txt": "not a fixture",
+			},
+			expectedCount: 2,
+		},
+		{
+			name:          "invalid fixture",
+			files:         map[string]string{"bad.json": "{"},
+			expectedError: "bad.json",
+		},
+		{
+			name:          "missing directory",
+			missingDir:    true,
+			expectedError: "replay directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+					t.Fatalf("failed to write %s: %v", name, err)
+				}
+			}
+			if tt.missingDir {
+				dir = filepath.Join(dir, "missing")
+			}
+
+			fixtures, err := loadFixtures(dir)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("loadFixtures() error = %v, expected error containing %q", err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadFixtures() unexpected error: %v", err)
+			}
+			if len(fixtures) != tt.expectedCount {
+				t.Errorf("loadFixtures() returned %d fixtures, expected %d", len(fixtures), tt.expectedCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// requestValidation holds the options for validating requests before they
+// are proxied.
+type requestValidation struct {
+	enabled       bool
+	security      bool
+	coerceQuery   bool
+	schemaOptions []openapi3.SchemaValidationOption
+}
+
+func newRequestValidation(cfg *Config) requestValidation {
+	return requestValidation{
+		enabled:       cfg.ValidateRequests,
+		security:      cfg.ValidateSecurity,
+		coerceQuery:   cfg.CoerceQuery,
+		schemaOptions: schemaOptions(cfg),
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// checkRequest validates r and logs why it is invalid, rejecting it in strict
+// mode. It reports whether r should still be proxied.
+func (vp *ValidatingProxy) checkRequest(w http.ResponseWriter, r *http.Request) bool {
+	if vp.responses.paths.ignores(r.URL.Path) {
+		return true
+	}
+	route, err := vp.validateRequest(r)
+	if err == nil {
+		return true
+	}
+
+	mode, operation := vp.mode, ""
+	if route != nil {
+		mode, operation = vp.modeFor(route), operationID(route)
+	}
+	status := http.StatusBadRequest
+	args := []any{"error", err, "reason", describeValidationError(err), "operation_id", operation, "method", r.Method, "path", r.URL.Path}
+	if schemes := failedSecuritySchemes(err); schemes != nil {
+		status = http.StatusUnauthorized
+		args = append(args, "security_schemes", schemes)
+	}
+	vp.log(r.Context()).Error("Request validation failed", args...)
+
+	if mode != ModeStrict {
+		return true
+	}
+	vp.writeErrorResponse(w, r, route, status, "Request validation failed", err)
+	return false
+}
+
+// validateRequest returns the operation r matched, if any, and why r doesn't
+// satisfy it.
+func (vp *ValidatingProxy) validateRequest(r *http.Request) (*routers.Route, error) {
+	bodyBytes, complete, err := readRequestBody(r, vp.maxBodySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	// The router matches against the upstream host, so route a rewritten copy of the request
+	routeReq := r.Clone(r.Context())
+	vp.rewriteRequest(routeReq)
+
+	route, pathParams, err := vp.currentRouter().FindRoute(routeReq)
+	if err != nil {
+		if isUndocumentedEndpoint(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("route finding error: %w", err)
+	}
+	vp.logRouteMatch(r, route, pathParams)
+
+	options := &openapi3filter.Options{
+		// The body is validated with the proxy's own decoders
+		ExcludeRequestBody:      true,
+		AuthenticationFunc:      openapi3filter.NoopAuthenticationFunc,
+		SkipSettingDefaults:     true,
+		SchemaValidationOptions: vp.requests.schemaOptions,
+	}
+	if vp.requests.security {
+		options.AuthenticationFunc = checkSecurityScheme
+	}
+
+	if !complete {
+		vp.log(r.Context()).Warn("Request too large, skipping body validation",
+			"method", r.Method,
+			"path", r.URL.Path)
+	}
+	routeReq.Body = http.NoBody
+
+	input := &openapi3filter.RequestValidationInput{
+		Request:    routeReq,
+		PathParams: pathParams,
+		Route:      route,
+		Options:    options,
+	}
+
+	ctx, span := startValidationSpan(r.Context(), "validate request", route)
+	start := time.Now()
+	err = openapi3filter.ValidateRequest(ctx, input)
+	if err == nil && complete {
+		err = vp.validateRequestBody(input, bodyBytes)
+	}
+	vp.redactor.redact(err)
+	endValidationSpan(span, err)
+	vp.log(ctx).Debug("Request validated",
+		"operation", operationName(route),
+		"mode", vp.modeFor(route),
+		"valid", err == nil,
+		"duration", time.Since(start),
+		"method", r.Method,
+		"path", r.URL.Path)
+	if err != nil {
+		vp.metrics.recordRequestFailure(route)
+	} else if vp.requests.coerceQuery {
+		coerceQuery(r, route)
+	}
+	return route, err
+}
+
+// readRequestBody buffers up to maxSize bytes of the request body and
+// restores r.Body so the upstream still receives the full payload, or the same
+// read error if reading failed. complete reports whether the whole body fit in
+// the buffer.
+func readRequestBody(r *http.Request, maxSize int64) ([]byte, bool, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true, nil
+	}
+	// Unlike an upstream's, a client's Content-Length is enforced by net/http,
+	// so a body declared over the limit can be streamed without buffering any
+	// of it
+	if r.ContentLength > maxSize {
+		return nil, false, nil
+	}
+
+	bodyBytes, err := io.ReadAll(io.LimitReader(r.Body, maxSize+1))
+	if err != nil || int64(len(bodyBytes)) > maxSize {
+		r.Body = readCloser{
+			Reader: io.MultiReader(bytes.NewReader(bodyBytes), r.Body),
+			Closer: r.Body,
+		}
+		return nil, false, err
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	return bodyBytes, true, nil
+}
@@ -0,0 +1,430 @@
+package specgate
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestValidatingProxy_ValidateRequests(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectUpstream bool
+	}{
+		{
+			name:           "valid request body",
+			mode:           "strict",
+			method:         http.MethodPost,
+			path:           "/users",
+			body:           `{"name": "test"}`,
+			expectedStatus: http.StatusCreated,
+			expectUpstream: true,
+		},
+		{
+			name:           "invalid request body in strict mode",
+			mode:           "strict",
+			method:         http.MethodPost,
+			path:           "/users",
+			body:           `{"name": 42}`,
+			expectedStatus: http.StatusBadRequest,
+			expectUpstream: false,
+		},
+		{
+			name:           "missing required body in strict mode",
+			mode:           "strict",
+			method:         http.MethodPost,
+			path:           "/users",
+			expectedStatus: http.StatusBadRequest,
+			expectUpstream: false,
+		},
+		{
+			name:           "invalid path parameter in strict mode",
+			mode:           "strict",
+			method:         http.MethodGet,
+			path:           "/users/abc",
+			expectedStatus: http.StatusBadRequest,
+			expectUpstream: false,
+		},
+		{
+			name:           "invalid query parameter in strict mode",
+			mode:           "strict",
+			method:         http.MethodGet,
+			path:           "/users?limit=abc",
+			expectedStatus: http.StatusBadRequest,
+			expectUpstream: false,
+		},
+		{
+			name:           "invalid request body in warn mode",
+			mode:           "warn",
+			method:         http.MethodPost,
+			path:           "/users",
+			body:           `{"name": 42}`,
+			expectedStatus: http.StatusCreated,
+			expectUpstream: true,
+		},
+		{
+			name:           "undocumented endpoint in strict mode",
+			mode:           "strict",
+			method:         http.MethodGet,
+			path:           "/unknown",
+			expectedStatus: http.StatusCreated,
+			expectUpstream: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var upstreamCalled bool
+			var upstreamBody []byte
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				upstreamCalled = true
+				upstreamBody, _ = io.ReadAll(r.Body)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, testSpec, upstream.URL, tt.mode)
+			vp.requests.enabled = true
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("ServeHTTP() status = %d, expected %d, body: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+			if upstreamCalled != tt.expectUpstream {
+				t.Errorf("ServeHTTP() upstream called = %v, expected %v", upstreamCalled, tt.expectUpstream)
+			}
+			if tt.expectUpstream && string(upstreamBody) != tt.body {
+				t.Errorf("ServeHTTP() upstream received body %q, expected %q", upstreamBody, tt.body)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_CookieParameters(t *testing.T) {
+	const spec = `openapi: 3.0.3
+info:
+  title: Cookie API
+  version: 1.0.0
+paths:
+  /cart:
+    get:
+      operationId: getCart
+      parameters:
+        - name: session
+          in: cookie
+          required: true
+          schema:
+            type: integer
+        - name: prefs
+          in: cookie
+          style: form
+          explode: false
+          schema:
+            type: object
+            properties:
+              theme:
+                type: string
+              size:
+                type: integer
+      responses:
+        '204':
+          description: The cart
+`
+
+	tests := []struct {
+		name           string
+		cookie         string
+		expectedStatus int
+		expectedReason string
+	}{
+		{name: "valid cookies", cookie: "session=12; prefs=theme,dark,size,3", expectedStatus: http.StatusNoContent},
+		{name: "missing required cookie", expectedStatus: http.StatusBadRequest, expectedReason: `parameter \"session\" in cookie`},
+		{name: "invalid cookie", cookie: "session=abc", expectedStatus: http.StatusBadRequest, expectedReason: `parameter \"session\" in cookie`},
+		{name: "invalid object cookie", cookie: "session=12; prefs=theme,dark,size,large", expectedStatus: http.StatusBadRequest, expectedReason: `parameter \"prefs\" in cookie`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, spec, upstream.URL, "strict")
+			vp.requests.enabled = true
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			req := httptest.NewRequest(http.MethodGet, "/cart", nil)
+			if tt.cookie != "" {
+				req.Header.Set("Cookie", tt.cookie)
+			}
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("ServeHTTP() status = %d, expected %d, body: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+			if !strings.Contains(logs.String(), tt.expectedReason) {
+				t.Errorf("logs should contain %q, got: %s", tt.expectedReason, logs.String())
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_MultipartRequests(t *testing.T) {
+	const spec = `openapi: 3.0.3
+info:
+  title: Upload API
+  version: 1.0.0
+paths:
+  /photos:
+    post:
+      operationId: uploadPhoto
+      requestBody:
+        required: true
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+              required: [title, file]
+              properties:
+                title:
+                  type: string
+                  maxLength: 10
+                rating:
+                  type: integer
+                  minimum: 1
+                file:
+                  type: string
+                  format: binary
+            encoding:
+              file:
+                contentType: image/png
+      responses:
+        '204':
+          description: Uploaded
+`
+
+	tests := []struct {
+		name           string
+		fields         map[string]string
+		fileType       string
+		maxBodySize    int64
+		expectedStatus int
+		expectedReason string
+	}{
+		{name: "valid", fields: map[string]string{"title": "Sunset", "rating": "5"}, fileType: "image/png", expectedStatus: http.StatusNoContent},
+		{name: "missing required field", fields: map[string]string{"rating": "5"}, fileType: "image/png", expectedStatus: http.StatusBadRequest, expectedReason: `property \"title\" is missing`},
+		{name: "missing file", fields: map[string]string{"title": "Sunset"}, expectedStatus: http.StatusBadRequest, expectedReason: `property \"file\" is missing`},
+		{name: "field too long", fields: map[string]string{"title": "Sunset over the bay"}, fileType: "image/png", expectedStatus: http.StatusBadRequest, expectedReason: "maximum string length is 10"},
+		{name: "invalid integer field", fields: map[string]string{"title": "Sunset", "rating": "five"}, fileType: "image/png", expectedStatus: http.StatusBadRequest, expectedReason: "path rating: value five: an invalid integer"},
+		{name: "field below minimum", fields: map[string]string{"title": "Sunset", "rating": "0"}, fileType: "image/png", expectedStatus: http.StatusBadRequest, expectedReason: "number must be at least 1"},
+		{name: "wrong file content type", fields: map[string]string{"title": "Sunset"}, fileType: "image/gif", expectedStatus: http.StatusBadRequest, expectedReason: "not matching content types"},
+		{name: "too large to validate", fields: map[string]string{"rating": "0"}, fileType: "image/gif", maxBodySize: 64, expectedStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body bytes.Buffer
+			writer := multipart.NewWriter(&body)
+			for name, value := range tt.fields {
+				_ = writer.WriteField(name, value)
+			}
+			if tt.fileType != "" {
+				header := textproto.MIMEHeader{}
+				header.Set("Content-Disposition", `form-data; name="file"; filename="photo"`)
+				header.Set("Content-Type", tt.fileType)
+				part, _ := writer.CreatePart(header)
+				_, _ = part.Write([]byte("\x89PNG\r\n\x1a\n\x00\xff"))
+			}
+			_ = writer.Close()
+			sent := body.String()
+
+			var upstreamBody []byte
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				upstreamBody, _ = io.ReadAll(r.Body)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, spec, upstream.URL, "strict")
+			vp.requests.enabled = true
+			if tt.maxBodySize > 0 {
+				vp.maxBodySize = tt.maxBodySize
+			}
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			req := httptest.NewRequest(http.MethodPost, "/photos", strings.NewReader(sent))
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("ServeHTTP() status = %d, expected %d, logs: %s", rec.Code, tt.expectedStatus, logs.String())
+			}
+			if !strings.Contains(logs.String(), tt.expectedReason) {
+				t.Errorf("logs should contain %q, got: %s", tt.expectedReason, logs.String())
+			}
+			if rec.Code == http.StatusNoContent && string(upstreamBody) != sent {
+				t.Errorf("upstream received %d bytes, expected the %d sent", len(upstreamBody), len(sent))
+			}
+		})
+	}
+}
+
+func TestReadRequestBody(t *testing.T) {
+	tests := []struct {
+		name           string
+		bodySize       int
+		unknownLength  bool
+		expectComplete bool
+	}{
+		{
+			name:           "empty body",
+			bodySize:       0,
+			expectComplete: true,
+		},
+		{
+			name:           "small body",
+			bodySize:       100,
+			expectComplete: true,
+		},
+		{
+			name:           "exactly at limit",
+			bodySize:       defaultMaxBodySize,
+			expectComplete: true,
+		},
+		{
+			name:           "over limit",
+			bodySize:       defaultMaxBodySize + 1,
+			expectComplete: false,
+		},
+		{
+			name:           "exactly at limit without content length",
+			bodySize:       defaultMaxBodySize,
+			unknownLength:  true,
+			expectComplete: true,
+		},
+		{
+			name:           "over limit without content length",
+			bodySize:       defaultMaxBodySize + 1,
+			unknownLength:  true,
+			expectComplete: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := bytes.Repeat([]byte("a"), tt.bodySize)
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+			if tt.unknownLength {
+				req.ContentLength = -1
+			}
+
+			buffered, complete, err := readRequestBody(req, defaultMaxBodySize)
+			if err != nil {
+				t.Fatalf("readRequestBody() unexpected error: %v", err)
+			}
+			if complete != tt.expectComplete {
+				t.Errorf("readRequestBody() complete = %v, expected %v", complete, tt.expectComplete)
+			}
+			if complete && len(buffered) != tt.bodySize {
+				t.Errorf("readRequestBody() buffered %d bytes, expected %d", len(buffered), tt.bodySize)
+			}
+
+			forwarded, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Fatalf("failed to read restored body: %v", err)
+			}
+			if !bytes.Equal(forwarded, body) {
+				t.Errorf("readRequestBody() restored %d bytes, expected %d", len(forwarded), len(body))
+			}
+		})
+	}
+}
+
+func TestReadRequestBody_ReadError(t *testing.T) {
+	errDisconnected := errors.New("client disconnected")
+	req := httptest.NewRequest(http.MethodPost, "/", io.MultiReader(strings.NewReader(`{"name"`), iotest.ErrReader(errDisconnected)))
+	req.ContentLength = -1
+
+	if _, _, err := readRequestBody(req, defaultMaxBodySize); !errors.Is(err, errDisconnected) {
+		t.Fatalf("readRequestBody() error = %v, expected %v", err, errDisconnected)
+	}
+
+	forwarded, err := io.ReadAll(req.Body)
+	if string(forwarded) != `{"name"` || !errors.Is(err, errDisconnected) {
+		t.Errorf("restored body = %q with error %v, expected the bytes read and the same error", forwarded, err)
+	}
+}
+
+func TestValidatingProxy_LargeRequestBody(t *testing.T) {
+	const maxBodySize = 16
+
+	tests := []struct {
+		name           string
+		body           string
+		unknownLength  bool
+		expectedStatus int
+	}{
+		{name: "invalid body at limit", body: `{"name": 42}    `, expectedStatus: http.StatusBadRequest},
+		{name: "invalid body just over limit", body: `{"name": 42}     `, expectedStatus: http.StatusCreated},
+		{name: "invalid body just over limit without content length", body: `{"name": 42}     `, unknownLength: true, expectedStatus: http.StatusCreated},
+		{name: "large body", body: `{"name": "` + strings.Repeat("a", 1<<20) + `"}`, expectedStatus: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var upstreamBody []byte
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				upstreamBody, _ = io.ReadAll(r.Body)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, testSpec, upstream.URL, "strict")
+			vp.requests.enabled = true
+			vp.maxBodySize = maxBodySize
+
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.unknownLength {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("ServeHTTP() status = %d, expected %d, body: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+			if tt.expectedStatus == http.StatusCreated && string(upstreamBody) != tt.body {
+				t.Errorf("upstream received %d bytes, expected the full %d", len(upstreamBody), len(tt.body))
+			}
+		})
+	}
+}
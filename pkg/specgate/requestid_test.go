@@ -0,0 +1,97 @@
+package specgate
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestValidatingProxy_RequestID(t *testing.T) {
+	tests := []struct {
+		name       string
+		incoming   string
+		expectKept bool
+	}{
+		{
+			name:       "incoming id is reused",
+			incoming:   "abc-123",
+			expectKept: true,
+		},
+		{
+			name: "missing id is generated",
+		},
+		{
+			name:     "id with spaces is replaced",
+			incoming: "abc 123",
+		},
+		{
+			name:     "overlong id is replaced",
+			incoming: strings.Repeat("a", maxRequestIDLength+1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var upstreamID string
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				upstreamID = r.Header.Get(requestIDHeader)
+				w.Header().Set(requestIDHeader, upstreamID)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id": "wrong"}`))
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, testSpec, upstream.URL, "warn")
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			if tt.incoming != "" {
+				req.Header.Set(requestIDHeader, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, req)
+
+			ids := rec.Header().Values(requestIDHeader)
+			if len(ids) != 1 {
+				t.Fatalf("response has request IDs %q, expected exactly one", ids)
+			}
+			id := ids[0]
+
+			if tt.expectKept {
+				if id != tt.incoming {
+					t.Errorf("request ID = %q, expected %q", id, tt.incoming)
+				}
+			} else if !uuidPattern.MatchString(id) {
+				t.Errorf("request ID = %q, expected a generated UUID", id)
+			}
+
+			if upstreamID != id {
+				t.Errorf("upstream saw request ID %q, expected %q", upstreamID, id)
+			}
+			if !strings.Contains(logs.String(), "request_id="+id) {
+				t.Errorf("expected validation failure log to carry request ID %q, got: %s", id, logs.String())
+			}
+		})
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	seen := make(map[string]bool)
+	for range 100 {
+		id := newRequestID()
+		if !uuidPattern.MatchString(id) {
+			t.Fatalf("newRequestID() = %q, expected a version 4 UUID", id)
+		}
+		if seen[id] {
+			t.Fatalf("newRequestID() returned %q twice", id)
+		}
+		seen[id] = true
+	}
+}
@@ -0,0 +1,100 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// retryTransport retries idempotent requests that failed to reach the
+// upstream, doubling the backoff after each attempt. Each retry goes to the
+// replica the upstream's route picks at the time. Upstream error responses
+// are not retried.
+type retryTransport struct {
+	next    http.RoundTripper
+	retries int
+	backoff time.Duration
+	logger  *slog.Logger
+}
+
+func newRetryTransport(next http.RoundTripper, retries int, backoff time.Duration, logger *slog.Logger) http.RoundTripper {
+	if retries <= 0 {
+		return next
+	}
+	return &retryTransport{next: next, retries: retries, backoff: backoff, logger: logger}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err == nil || !isRetryable(req) {
+		return resp, err
+	}
+
+	for attempt := 1; attempt <= t.retries; attempt++ {
+		requestLogger(req.Context(), t.logger).Warn("Upstream request failed, retrying",
+			"error", err,
+			"attempt", attempt,
+			"retries", t.retries,
+			"method", req.Method,
+			"path", req.URL.Path)
+
+		if !sleepContext(req.Context(), t.backoff<<(attempt-1)) {
+			return nil, err
+		}
+
+		retryReq := reselectUpstream(req.Clone(req.Context()))
+		if req.GetBody != nil {
+			if retryReq.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+
+		if resp, err = t.next.RoundTrip(retryReq); err == nil {
+			return resp, nil
+		}
+	}
+	return nil, err
+}
+
+// isRetryable reports whether req can safely be sent again: its method must
+// be idempotent, and any body must be rewindable.
+func isRetryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// sleepContext waits for d, returning false if ctx is done first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
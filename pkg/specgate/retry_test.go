@@ -0,0 +1,85 @@
+package specgate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakyTransport fails the first failures round trips, then succeeds.
+type flakyTransport struct {
+	failures int
+	attempts int
+	bodies   []string
+}
+
+func (t *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.attempts++
+	if req.Body != nil {
+		body, _ := io.ReadAll(req.Body)
+		t.bodies = append(t.bodies, string(body))
+	}
+	if t.attempts <= t.failures {
+		return nil, errors.New("connection refused")
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		body             io.Reader
+		rewindable       bool
+		retries          int
+		failures         int
+		expectedAttempts int
+		expectError      bool
+	}{
+		{name: "succeeds after retries", method: http.MethodGet, retries: 2, failures: 2, expectedAttempts: 3},
+		{name: "gives up after retries", method: http.MethodGet, retries: 1, failures: 5, expectedAttempts: 2, expectError: true},
+		{name: "no retries configured", method: http.MethodGet, retries: 0, failures: 1, expectedAttempts: 1, expectError: true},
+		{name: "non-idempotent method", method: http.MethodPost, retries: 3, failures: 1, expectedAttempts: 1, expectError: true},
+		{name: "body that can't be rewound", method: http.MethodPut, body: strings.NewReader(`{"name": "test"}`), retries: 3, failures: 1, expectedAttempts: 1, expectError: true},
+		{name: "rewindable body", method: http.MethodPut, body: bytes.NewReader([]byte(`{"name": "test"}`)), rewindable: true, retries: 3, failures: 1, expectedAttempts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &flakyTransport{failures: tt.failures}
+			transport := newRetryTransport(next, tt.retries, time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			req, err := http.NewRequestWithContext(context.Background(), tt.method, "http://upstream/users", tt.body)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			if !tt.rewindable {
+				req.GetBody = nil
+			}
+
+			resp, err := transport.RoundTrip(req)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("RoundTrip() error = %v, expectError %v", err, tt.expectError)
+			}
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if next.attempts != tt.expectedAttempts {
+				t.Errorf("RoundTrip() made %d attempts, expected %d", next.attempts, tt.expectedAttempts)
+			}
+			if tt.rewindable {
+				for _, body := range next.bodies {
+					if body != `{"name": "test"}` {
+						t.Errorf("attempt sent body %q, expected the full body", body)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+// securitySchemeError records which security scheme a request failed to
+// satisfy, so it can be logged separately from the validation error.
+type securitySchemeError struct {
+	scheme string
+	err    error
+}
+
+func (e *securitySchemeError) Error() string {
+	return fmt.Sprintf("security scheme %q: %v", e.scheme, e.err)
+}
+
+func (e *securitySchemeError) Unwrap() error {
+	return e.err
+}
+
+// checkSecurityScheme is an openapi3filter.AuthenticationFunc that asserts a
+// request carries the credentials its security scheme declares. Only their
+// presence is checked; verifying them is left to the upstream.
+func checkSecurityScheme(_ context.Context, input *openapi3filter.AuthenticationInput) error {
+	req := input.RequestValidationInput.Request
+	scheme := input.SecurityScheme
+
+	var err error
+	switch scheme.Type {
+	case "http":
+		err = checkAuthorizationHeader(req, scheme.Scheme)
+	case "apiKey":
+		err = checkAPIKey(req, scheme.In, scheme.Name)
+	case "oauth2", "openIdConnect":
+		err = checkAuthorizationHeader(req, "bearer")
+	}
+
+	if err != nil {
+		return input.NewError(&securitySchemeError{scheme: input.SecuritySchemeName, err: err})
+	}
+	return nil
+}
+
+func checkAuthorizationHeader(req *http.Request, authScheme string) error {
+	prefix, credentials, _ := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(prefix, authScheme) || strings.TrimSpace(credentials) == "" {
+		return fmt.Errorf("missing %s credentials in Authorization header", strings.ToLower(authScheme))
+	}
+	return nil
+}
+
+func checkAPIKey(req *http.Request, in, name string) error {
+	var found bool
+	switch in {
+	case "header":
+		found = req.Header.Get(name) != ""
+	case "query":
+		found = req.URL.Query().Get(name) != ""
+	case "cookie":
+		cookie, err := req.Cookie(name)
+		found = err == nil && cookie.Value != ""
+	}
+
+	if !found {
+		return fmt.Errorf("missing API key %q in %s", name, in)
+	}
+	return nil
+}
+
+// failedSecuritySchemes returns the names of the security schemes that
+// caused a request validation error, if any.
+func failedSecuritySchemes(err error) []string {
+	var requirementsErr *openapi3filter.SecurityRequirementsError
+	if !errors.As(err, &requirementsErr) {
+		return nil
+	}
+
+	var schemes []string
+	for _, e := range requirementsErr.Errors {
+		var schemeErr *securitySchemeError
+		if errors.As(e, &schemeErr) {
+			schemes = append(schemes, schemeErr.scheme)
+		}
+	}
+	return schemes
+}
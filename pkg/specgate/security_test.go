@@ -0,0 +1,112 @@
+package specgate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+const testSecuritySpec = `openapi: 3.0.3
+info:
+  title: Test API
+  version: 1.0.0
+components:
+  securitySchemes:
+    bearerAuth:
+      type: http
+      scheme: bearer
+    apiKeyHeader:
+      type: apiKey
+      in: header
+      name: X-API-Key
+    apiKeyQuery:
+      type: apiKey
+      in: query
+      name: api_key
+paths:
+  /users:
+    get:
+      security:
+        - bearerAuth: []
+      responses:
+        "200":
+          description: Users
+  /reports:
+    get:
+      security:
+        - apiKeyHeader: []
+        - apiKeyQuery: []
+      responses:
+        "200":
+          description: Reports
+  /health:
+    get:
+      security: []
+      responses:
+        "200":
+          description: Health
+`
+
+func TestValidatingProxy_ValidateSecurity(t *testing.T) {
+	tests := []struct {
+		name             string
+		validateSecurity bool
+		path             string
+		headers          map[string]string
+		expectedStatus   int
+	}{
+		{name: "bearer token", validateSecurity: true, path: "/users", headers: map[string]string{"Authorization": "Bearer abc"}, expectedStatus: http.StatusOK},
+		{name: "missing bearer token", validateSecurity: true, path: "/users", expectedStatus: http.StatusUnauthorized},
+		{name: "wrong auth scheme", validateSecurity: true, path: "/users", headers: map[string]string{"Authorization": "Basic abc"}, expectedStatus: http.StatusUnauthorized},
+		{name: "empty bearer token", validateSecurity: true, path: "/users", headers: map[string]string{"Authorization": "Bearer "}, expectedStatus: http.StatusUnauthorized},
+		{name: "api key header", validateSecurity: true, path: "/reports", headers: map[string]string{"X-API-Key": "abc"}, expectedStatus: http.StatusOK},
+		{name: "api key query alternative", validateSecurity: true, path: "/reports?api_key=abc", expectedStatus: http.StatusOK},
+		{name: "missing api key", validateSecurity: true, path: "/reports", expectedStatus: http.StatusUnauthorized},
+		{name: "no security required", validateSecurity: true, path: "/health", expectedStatus: http.StatusOK},
+		{name: "disabled", validateSecurity: false, path: "/users", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, testSecuritySpec, upstream.URL, "strict")
+			vp.requests.enabled = true
+			vp.requests.security = tt.validateSecurity
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("ServeHTTP() status = %d, expected %d, body: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestFailedSecuritySchemes(t *testing.T) {
+	err := &openapi3filter.SecurityRequirementsError{
+		Errors: []error{
+			&openapi3filter.RequestError{Err: &securitySchemeError{scheme: "apiKeyHeader", err: errors.New("missing")}},
+			&openapi3filter.RequestError{Err: &securitySchemeError{scheme: "apiKeyQuery", err: errors.New("missing")}},
+		},
+	}
+
+	if schemes := failedSecuritySchemes(err); !reflect.DeepEqual(schemes, []string{"apiKeyHeader", "apiKeyQuery"}) {
+		t.Errorf("failedSecuritySchemes() = %v, expected [apiKeyHeader apiKeyQuery]", schemes)
+	}
+	if schemes := failedSecuritySchemes(errors.New("request body has an error")); schemes != nil {
+		t.Errorf("failedSecuritySchemes() = %v, expected nil", schemes)
+	}
+}
@@ -0,0 +1,95 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const unixPrefix = "unix:"
+
+// NewServer returns a server for vp on cfg.Port, with the configured
+// timeouts, serving HTTPS when cfg has a TLS certificate. A port of the form
+// unix:/path/to.sock is kept as the server's Addr for Listen.
+func NewServer(cfg *Config, vp *ValidatingProxy) (*http.Server, error) {
+	tlsConfig, err := newServerTLSConfig(cfg, vp.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Server{
+		Addr:              serverAddr(cfg.Port),
+		Handler:           vp.Handler(),
+		TLSConfig:         tlsConfig,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}, nil
+}
+
+func serverAddr(port string) string {
+	if strings.HasPrefix(port, unixPrefix) {
+		return port
+	}
+	return ":" + port
+}
+
+// Listen opens a listener for a server's Addr, which is a Unix domain socket
+// for addresses of the form unix:/path/to.sock and a TCP address otherwise.
+// A socket file that no server is listening on any more is replaced, and the
+// file is removed when the listener is closed, as it is by
+// http.Server.Shutdown.
+func Listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, unixPrefix)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+
+	// A socket left behind by a process that didn't shut down cleanly would
+	// otherwise make the listen fail with "address already in use"
+	if info, err := os.Lstat(path); err == nil && info.Mode()&fs.ModeSocket != 0 {
+		if !staleSocket(path) {
+			return nil, fmt.Errorf("listen unix %s: address already in use by a running server", path)
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// staleSocket reports whether nothing is listening on the socket at path,
+// which only a refused connection shows for certain.
+func staleSocket(path string) bool {
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		_ = conn.Close()
+		return false
+	}
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,123 @@
+package specgate
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListen_UnixSocketInUse(t *testing.T) {
+	dir, err := os.MkdirTemp("", "specgate")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	path := filepath.Join(dir, "proxy.sock")
+
+	running, err := Listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("Listen() unexpected error: %v", err)
+	}
+	defer func() { _ = running.Close() }()
+
+	if second, err := Listen("unix:" + path); err == nil {
+		_ = second.Close()
+		t.Fatal("Listen() on a socket a server is listening on should fail")
+	}
+	if _, err := net.Dial("unix", path); err != nil {
+		t.Errorf("the running server's socket should be left in place, dial error: %v", err)
+	}
+}
+
+func TestNewServer_Timeouts(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ReadTimeout = time.Minute
+	cfg.ReadHeaderTimeout = 5 * time.Second
+	cfg.WriteTimeout = 0
+	cfg.IdleTimeout = 10 * time.Minute
+
+	server, err := NewServer(cfg, newTestProxy(t, testSpec, "http://localhost:3000", "warn"))
+	if err != nil {
+		t.Fatalf("NewServer() unexpected error: %v", err)
+	}
+
+	if server.ReadTimeout != time.Minute || server.ReadHeaderTimeout != 5*time.Second ||
+		server.WriteTimeout != 0 || server.IdleTimeout != 10*time.Minute {
+		t.Errorf("server timeouts = read %v, read header %v, write %v, idle %v",
+			server.ReadTimeout, server.ReadHeaderTimeout, server.WriteTimeout, server.IdleTimeout)
+	}
+}
+
+func TestListen_UnixSocket(t *testing.T) {
+	tests := []struct {
+		name        string
+		staleSocket bool
+	}{
+		{name: "new socket"},
+		{name: "stale socket left behind", staleSocket: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// t.TempDir can exceed the length limit of socket paths on some platforms
+			dir, err := os.MkdirTemp("", "specgate")
+			if err != nil {
+				t.Fatalf("failed to create temp dir: %v", err)
+			}
+			defer func() { _ = os.RemoveAll(dir) }()
+			path := filepath.Join(dir, "proxy.sock")
+
+			if tt.staleSocket {
+				stale, err := net.Listen("unix", path)
+				if err != nil {
+					t.Fatalf("failed to create stale socket: %v", err)
+				}
+				stale.(*net.UnixListener).SetUnlinkOnClose(false)
+				_ = stale.Close()
+			}
+
+			cfg := DefaultConfig()
+			cfg.Port = "unix:" + path
+			server, err := NewServer(cfg, newTestProxy(t, testSpec, "http://localhost:3000", "warn"))
+			if err != nil {
+				t.Fatalf("NewServer() unexpected error: %v", err)
+			}
+			server.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			})
+
+			listener, err := Listen(server.Addr)
+			if err != nil {
+				t.Fatalf("Listen() unexpected error: %v", err)
+			}
+			go func() { _ = server.Serve(listener) }()
+
+			client := &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, "unix", path)
+				},
+			}}
+			resp, err := client.Get("http://specgate/users")
+			if err != nil {
+				t.Fatalf("request over socket failed: %v", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusTeapot {
+				t.Errorf("status = %d, expected %d", resp.StatusCode, http.StatusTeapot)
+			}
+
+			if err := server.Shutdown(context.Background()); err != nil {
+				t.Fatalf("Shutdown() unexpected error: %v", err)
+			}
+			if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("socket file should be removed on shutdown, stat error: %v", err)
+			}
+		})
+	}
+}
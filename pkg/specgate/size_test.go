@@ -0,0 +1,108 @@
+package specgate
+
+import "testing"
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    ByteSize
+		expectError bool
+	}{
+		{
+			name:     "plain bytes",
+			input:    "1048576",
+			expected: 1048576,
+		},
+		{
+			name:     "bytes suffix",
+			input:    "512B",
+			expected: 512,
+		},
+		{
+			name:     "kilobytes",
+			input:    "512KB",
+			expected: 512 * 1024,
+		},
+		{
+			name:     "megabytes",
+			input:    "25MB",
+			expected: 25 * 1024 * 1024,
+		},
+		{
+			name:     "binary unit",
+			input:    "25MiB",
+			expected: 25 * 1024 * 1024,
+		},
+		{
+			name:     "gigabytes with space and lowercase",
+			input:    "1 gb",
+			expected: 1 << 30,
+		},
+		{
+			name:     "short unit",
+			input:    "10M",
+			expected: 10 * 1024 * 1024,
+		},
+		{
+			name:        "fractional value",
+			input:       "1.5MB",
+			expectError: true,
+		},
+		{
+			name:        "negative value",
+			input:       "-1MB",
+			expectError: true,
+		},
+		{
+			name:        "unknown unit",
+			input:       "10TB",
+			expectError: true,
+		},
+		{
+			name:        "overflow",
+			input:       "9999999999999GB",
+			expectError: true,
+		},
+		{
+			name:        "empty",
+			input:       "",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseByteSize(tt.input)
+			if (err != nil) != tt.expectError {
+				t.Errorf("ParseByteSize() error = %v, expectError %v", err, tt.expectError)
+				return
+			}
+			if result != tt.expected {
+				t.Errorf("ParseByteSize() = %d, expected %d", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestByteSize_String(t *testing.T) {
+	tests := []struct {
+		size     ByteSize
+		expected string
+	}{
+		{size: 0, expected: "0B"},
+		{size: 100, expected: "100B"},
+		{size: 2048, expected: "2KB"},
+		{size: 10 * 1024 * 1024, expected: "10MB"},
+		{size: 1 << 30, expected: "1GB"},
+		{size: 1536, expected: "1536B"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if result := tt.size.String(); result != tt.expected {
+				t.Errorf("ByteSize.String() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
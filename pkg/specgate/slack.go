@@ -0,0 +1,222 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxSlackAttachments keeps messages readable; further failures in a batch
+// are only counted.
+const maxSlackAttachments = 20
+
+// slackBatch holds the failures of one window that are sent in full, and
+// counts those past maxSlackAttachments.
+type slackBatch struct {
+	failures []notifiedFailure
+	dropped  int
+}
+
+type slackMessage struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+type slackAttachment struct {
+	Color     string       `json:"color"`
+	Title     string       `json:"title"`
+	Text      string       `json:"text"`
+	Fields    []slackField `json:"fields"`
+	Footer    string       `json:"footer,omitempty"`
+	Timestamp int64        `json:"ts"`
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// slackNotifier collects validation failures for a short window and posts
+// them to a Slack incoming webhook as a single message, one attachment per
+// failure.
+type slackNotifier struct {
+	url    string
+	window time.Duration
+	client *http.Client
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	pending slackBatch
+	timer   *time.Timer
+	closed  bool
+
+	queue chan slackBatch
+	done  chan struct{}
+}
+
+func newSlackNotifier(cfg *Config, logger *slog.Logger) *slackNotifier {
+	n := &slackNotifier{
+		url:    cfg.SlackWebhook,
+		window: cfg.SlackBatchWindow,
+		client: &http.Client{Timeout: 10 * time.Second},
+		logger: logger,
+		queue:  make(chan slackBatch, webhookQueueSize),
+		done:   make(chan struct{}),
+	}
+	go n.run()
+	return n
+}
+
+// Notify adds the failure to the current batch, starting a new batch if
+// there is none. It never blocks.
+func (n *slackNotifier) Notify(failure notifiedFailure) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		return
+	}
+	if len(n.pending.failures) < maxSlackAttachments {
+		n.pending.failures = append(n.pending.failures, failure)
+	} else {
+		n.pending.dropped++
+	}
+	if n.timer == nil {
+		n.timer = time.AfterFunc(n.window, n.flush)
+	}
+}
+
+func (n *slackNotifier) flush() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.flushLocked()
+}
+
+func (n *slackNotifier) flushLocked() {
+	batch := n.pending
+	n.pending = slackBatch{}
+	n.timer = nil
+
+	if len(batch.failures) == 0 || n.closed {
+		return
+	}
+	select {
+	case n.queue <- batch:
+	default:
+		n.logger.Warn("Slack queue full, dropping notification", "failures", len(batch.failures)+batch.dropped)
+	}
+}
+
+func (n *slackNotifier) run() {
+	defer close(n.done)
+	for batch := range n.queue {
+		if err := n.send(batch); err != nil {
+			n.logger.Error("Failed to send Slack notification", "error", err)
+		}
+	}
+}
+
+func (n *slackNotifier) send(batch slackBatch) error {
+	body, err := json.Marshal(newSlackMessage(batch))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response from Slack: %s", resp.Status)
+	}
+	return nil
+}
+
+// Close sends the current batch and any still queued, then stops the
+// notifier. Later calls do nothing.
+func (n *slackNotifier) Close() {
+	n.mu.Lock()
+	if !n.closed {
+		if n.timer != nil {
+			n.timer.Stop()
+		}
+		n.flushLocked()
+		n.closed = true
+		close(n.queue)
+	}
+	n.mu.Unlock()
+
+	<-n.done
+}
+
+func newSlackMessage(batch slackBatch) slackMessage {
+	text := "SpecGate: 1 validation failure"
+	if total := len(batch.failures) + batch.dropped; total > 1 {
+		text = fmt.Sprintf("SpecGate: %d validation failures", total)
+	}
+	if batch.dropped > 0 {
+		text += fmt.Sprintf(" (showing the first %d, and %d more)", len(batch.failures), batch.dropped)
+	}
+
+	message := slackMessage{Text: text}
+	for _, failure := range batch.failures {
+		message.Attachments = append(message.Attachments, slackAttachment{
+			Color: slackColor(failure.Mode),
+			Title: fmt.Sprintf("%s %s (%s)", failure.Method, failure.Path, failure.Operation),
+			Text:  failure.Error,
+			Fields: []slackField{
+				{Title: "Status", Value: strconv.Itoa(failure.Status), Short: true},
+				{Title: "Mode", Value: string(failure.Mode), Short: true},
+			},
+			Footer:    failure.RequestID,
+			Timestamp: failure.Timestamp.Unix(),
+		})
+	}
+	return message
+}
+
+// slackColor matches the attachment color to how seriously the mode treats
+// a failure: strict blocks the response, warn and report only log it.
+func slackColor(mode Mode) string {
+	switch mode {
+	case ModeStrict:
+		return "danger"
+	case ModeWarn:
+		return "warning"
+	default:
+		return "#439FE0"
+	}
+}
@@ -0,0 +1,175 @@
+package specgate
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestValidatingProxy_SlackWebhook(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": "wrong"}`))
+	}))
+	defer upstream.Close()
+
+	var mu sync.Mutex
+	var messages []slackMessage
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message slackMessage
+		_ = json.NewDecoder(r.Body).Decode(&message)
+		mu.Lock()
+		defer mu.Unlock()
+		messages = append(messages, message)
+	}))
+	defer slack.Close()
+
+	cfg := DefaultConfig()
+	cfg.Upstream = upstream.URL
+	cfg.Mode = "strict"
+	cfg.SlackWebhook = slack.URL
+	cfg.SlackBatchWindow = time.Hour
+	vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/3"} {
+		vp.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if err := vp.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+
+	if len(messages) != 1 {
+		t.Fatalf("expected the failures to be batched into 1 message, got %d", len(messages))
+	}
+	message := messages[0]
+	if message.Text != "SpecGate: 3 validation failures" || len(message.Attachments) != 3 {
+		t.Fatalf("unexpected message %+v", message)
+	}
+	attachment := message.Attachments[0]
+	if attachment.Color != "danger" || attachment.Title != "GET /users/{id} (getUser)" || attachment.Text == "" {
+		t.Errorf("unexpected attachment %+v", attachment)
+	}
+}
+
+func TestSlackNotifier_AfterClose(t *testing.T) {
+	var posts int
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts++
+	}))
+	defer slack.Close()
+
+	cfg := DefaultConfig()
+	cfg.SlackWebhook = slack.URL
+	notifier := newSlackNotifier(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	notifier.Close()
+
+	notifier.Notify(notifiedFailure{FailureRecord: FailureRecord{OperationID: "getUser"}, Operation: "getUser"})
+	notifier.Close()
+
+	if posts != 0 {
+		t.Errorf("expected failures after Close to be dropped, got %d posts", posts)
+	}
+}
+
+func TestSlackNotifier_CapsBatch(t *testing.T) {
+	var messages []slackMessage
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message slackMessage
+		_ = json.NewDecoder(r.Body).Decode(&message)
+		messages = append(messages, message)
+	}))
+	defer slack.Close()
+
+	cfg := DefaultConfig()
+	cfg.SlackWebhook = slack.URL
+	cfg.SlackBatchWindow = time.Hour
+	notifier := newSlackNotifier(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	const failures = 1000
+	for range failures {
+		notifier.Notify(notifiedFailure{FailureRecord: FailureRecord{OperationID: "getUser"}, Operation: "getUser"})
+	}
+	notifier.mu.Lock()
+	pending, dropped := len(notifier.pending.failures), notifier.pending.dropped
+	notifier.mu.Unlock()
+	if pending != maxSlackAttachments || dropped != failures-maxSlackAttachments {
+		t.Errorf("kept %d failures and counted %d, expected %d and %d", pending, dropped, maxSlackAttachments, failures-maxSlackAttachments)
+	}
+	notifier.Close()
+
+	if len(messages) != 1 {
+		t.Fatalf("expected the failures to be batched into 1 message, got %d", len(messages))
+	}
+	if expected := "SpecGate: 1000 validation failures (showing the first 20, and 980 more)"; messages[0].Text != expected {
+		t.Errorf("text = %q, expected %q", messages[0].Text, expected)
+	}
+	if len(messages[0].Attachments) != maxSlackAttachments {
+		t.Errorf("got %d attachments, expected %d", len(messages[0].Attachments), maxSlackAttachments)
+	}
+}
+
+func TestNewSlackMessage(t *testing.T) {
+	tests := []struct {
+		name                string
+		failures            int
+		mode                Mode
+		expectedText        string
+		expectedAttachments int
+		expectedColor       string
+	}{
+		{
+			name:                "single warn failure",
+			failures:            1,
+			mode:                ModeWarn,
+			expectedText:        "SpecGate: 1 validation failure",
+			expectedAttachments: 1,
+			expectedColor:       "warning",
+		},
+		{
+			name:                "report failures",
+			failures:            2,
+			mode:                ModeReport,
+			expectedText:        "SpecGate: 2 validation failures",
+			expectedAttachments: 2,
+			expectedColor:       "#439FE0",
+		},
+		{
+			name:                "too many failures",
+			failures:            maxSlackAttachments + 5,
+			mode:                ModeStrict,
+			expectedText:        "SpecGate: 25 validation failures (showing the first 20, and 5 more)",
+			expectedAttachments: maxSlackAttachments,
+			expectedColor:       "danger",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch := slackBatch{failures: make([]notifiedFailure, min(tt.failures, maxSlackAttachments))}
+			batch.dropped = tt.failures - len(batch.failures)
+			for i := range batch.failures {
+				batch.failures[i] = notifiedFailure{
+					FailureRecord: FailureRecord{Method: http.MethodGet, Path: "/users/{id}", Status: http.StatusOK},
+					Operation:     "getUser",
+					Mode:          tt.mode,
+				}
+			}
+
+			message := newSlackMessage(batch)
+			if message.Text != tt.expectedText {
+				t.Errorf("text = %q, expected %q", message.Text, tt.expectedText)
+			}
+			if len(message.Attachments) != tt.expectedAttachments {
+				t.Fatalf("got %d attachments, expected %d", len(message.Attachments), tt.expectedAttachments)
+			}
+			if message.Attachments[0].Color != tt.expectedColor {
+				t.Errorf("color = %q, expected %q", message.Attachments[0].Color, tt.expectedColor)
+			}
+		})
+	}
+}
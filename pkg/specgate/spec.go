@@ -0,0 +1,387 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/getkin/kin-openapi/routers/legacy"
+)
+
+type RouterBackend string
+
+const (
+	RouterGorillaMux RouterBackend = "gorillamux"
+	RouterLegacy     RouterBackend = "legacy"
+)
+
+func parseRouterBackend(backend string) (RouterBackend, error) {
+	switch RouterBackend(strings.ToLower(backend)) {
+	case RouterGorillaMux:
+		return RouterGorillaMux, nil
+	case RouterLegacy:
+		return RouterLegacy, nil
+	default:
+		return "", fmt.Errorf("invalid router '%s': must be one of 'gorillamux' or 'legacy'", backend)
+	}
+}
+
+// specLoader loads specs and builds their routers. The same loader is used
+// for the initial load and for every reload or refresh.
+type specLoader struct {
+	upstreamURLs []string
+	keepBasePath bool
+	serverVars   ServerVars
+	router       RouterBackend
+	logger       *slog.Logger
+	// Remote specs' versions as loaded, for the first refresh to send
+	versions map[string]specVersion
+}
+
+func newSpecLoader(cfg *Config, logger *slog.Logger) (specLoader, error) {
+	router, err := parseRouterBackend(cfg.Router)
+	if err != nil {
+		return specLoader{}, err
+	}
+	upstreams, err := parseUpstreams(cfg.Upstream)
+	if err != nil {
+		return specLoader{}, fmt.Errorf("invalid upstream: %w", err)
+	}
+	return specLoader{
+		upstreamURLs: upstreams.urls(),
+		keepBasePath: cfg.KeepSpecBasePath,
+		serverVars:   cfg.ServerVariables,
+		router:       router,
+		logger:       logger,
+		versions:     make(map[string]specVersion),
+	}, nil
+}
+
+// loadSpec creates the spec loader for cfg and loads the initial spec.
+func loadSpec(cfg *Config, logger *slog.Logger) (specLoader, *openapi3.T, routers.Router, error) {
+	loader, err := newSpecLoader(cfg, logger)
+	if err != nil {
+		return specLoader{}, nil, nil, err
+	}
+	spec, router, err := loader.load(cfg.Spec)
+	if err != nil {
+		return specLoader{}, nil, nil, err
+	}
+	return loader, spec, router, nil
+}
+
+// load loads the spec at specPath, or merges the specs when specPath lists
+// several, separated by commas.
+func (l specLoader) load(specPath string) (*openapi3.T, routers.Router, error) {
+	paths := splitSpecPaths(specPath)
+	if len(paths) == 1 {
+		spec, err := l.loadDocument(paths[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		return l.prepare(spec)
+	}
+
+	specs := make([]*openapi3.T, len(paths))
+	for i, path := range paths {
+		spec, err := l.loadDocument(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		specs[i] = spec
+	}
+
+	spec, err := mergeSpecs(paths, specs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return l.prepare(spec)
+}
+
+func (l specLoader) loadDocument(specPath string) (*openapi3.T, error) {
+	var data []byte
+	var location *url.URL
+	var err error
+
+	if isRemoteSpec(specPath) {
+		location, err = url.Parse(specPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid spec URL: %w", err)
+		}
+		var version specVersion
+		data, version, err = fetchSpec(context.Background(), specPath, specVersion{})
+		if err == nil {
+			l.versions[specPath] = version
+		}
+	} else {
+		location = &url.URL{Path: filepath.ToSlash(specPath)}
+		data, err = os.ReadFile(specPath) // #nosec G304 -- path is supplied by the operator
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load spec: %w", err)
+	}
+
+	return l.parse(data, location)
+}
+
+func (l specLoader) loadData(data []byte, location *url.URL) (*openapi3.T, routers.Router, error) {
+	spec, err := l.parse(data, location)
+	if err != nil {
+		return nil, nil, err
+	}
+	return l.prepare(spec)
+}
+
+func (l specLoader) parse(data []byte, location *url.URL) (*openapi3.T, error) {
+	if !isSwagger2(data) {
+		spec, err := newOpenAPILoader().LoadFromDataWithPath(data, location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load spec: %w", err)
+		}
+		return spec, nil
+	}
+
+	spec, caveats, err := convertSwagger2(data, location)
+	if err != nil {
+		return nil, err
+	}
+
+	l.logger.Warn("Converted Swagger 2.0 spec to OpenAPI 3.0", "spec", location.String())
+	for _, caveat := range caveats {
+		l.logger.Warn("Swagger 2.0 conversion is approximate", "detail", caveat)
+	}
+	return spec, nil
+}
+
+func (l specLoader) prepare(spec *openapi3.T) (*openapi3.T, routers.Router, error) {
+	if err := checkSpecVersion(spec.OpenAPI); err != nil {
+		return nil, nil, err
+	}
+
+	servers, err := l.servers(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	spec.Servers = servers
+
+	var router routers.Router
+	switch l.router {
+	case RouterLegacy:
+		var legacyRouter routers.Router
+		legacyRouter, err = legacy.NewRouter(spec)
+		router = sentinelRouter{legacyRouter}
+	default:
+		router, err = gorillamux.NewRouter(spec)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build %s router from spec: %w", l.router, err)
+	}
+
+	return spec, router, nil
+}
+
+// servers returns the servers requests are routed against: the upstreams,
+// since the router matches rewritten requests. With keepBasePath, each of
+// the spec's own base paths is mounted under every upstream, so a spec
+// documenting https://api.example.com/v2 routes /v2/users to /users.
+func (l specLoader) servers(spec *openapi3.T) (openapi3.Servers, error) {
+	basePaths := []string{""}
+	if l.keepBasePath {
+		var err error
+		if basePaths, err = specBasePaths(spec, l.serverVars, l.logger); err != nil {
+			return nil, err
+		}
+	}
+
+	var servers openapi3.Servers
+	for _, upstreamURL := range l.upstreamURLs {
+		for _, basePath := range basePaths {
+			serverURL := upstreamURL
+			if basePath != "" {
+				serverURL = singleJoiningSlash(upstreamURL, basePath)
+			}
+			servers = append(servers, &openapi3.Server{URL: serverURL})
+		}
+	}
+	return servers, nil
+}
+
+// specBasePaths returns the distinct base paths of the spec's servers, with
+// server variables set to the values in vars or else to their defaults.
+func specBasePaths(spec *openapi3.T, vars ServerVars, logger *slog.Logger) ([]string, error) {
+	declared := make(map[string]bool)
+	var basePaths []string
+	for _, server := range spec.Servers {
+		resolved, err := resolveServerVariables(server, vars)
+		if err != nil {
+			return nil, err
+		}
+		for name := range server.Variables {
+			declared[name] = true
+		}
+
+		basePath, err := resolved.BasePath()
+		if err != nil {
+			logger.Warn("Ignoring spec server with an invalid URL", "server", server.URL, "error", err)
+			continue
+		}
+		if basePath = strings.TrimSuffix(basePath, "/"); !slices.Contains(basePaths, basePath) {
+			basePaths = append(basePaths, basePath)
+		}
+	}
+
+	for name := range vars {
+		if !declared[name] {
+			return nil, fmt.Errorf("server variable %q is not declared by the spec's servers", name)
+		}
+	}
+	if len(basePaths) == 0 {
+		return []string{""}, nil
+	}
+	return basePaths, nil
+}
+
+// resolveServerVariables returns a copy of server whose variable defaults are
+// replaced by the values in vars, which must be among the variable's enum.
+func resolveServerVariables(server *openapi3.Server, vars ServerVars) (*openapi3.Server, error) {
+	resolved := *server
+	resolved.Variables = make(map[string]*openapi3.ServerVariable, len(server.Variables))
+	for name, variable := range server.Variables {
+		resolvedVariable := *variable
+		if value, ok := vars[name]; ok {
+			if len(variable.Enum) > 0 && !slices.Contains(variable.Enum, value) {
+				return nil, fmt.Errorf("server variable %q of %s: %q must be one of %s",
+					name, server.URL, value, strings.Join(variable.Enum, ", "))
+			}
+			resolvedVariable.Default = value
+		}
+		resolved.Variables[name] = &resolvedVariable
+	}
+	return &resolved, nil
+}
+
+// ServerVars sets server variables in the spec's server URLs, given on the
+// command line as repeated "name=value" flags.
+type ServerVars map[string]string
+
+func (v ServerVars) String() string {
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	vars := make([]string, 0, len(names))
+	for _, name := range names {
+		vars = append(vars, name+"="+v[name])
+	}
+	return strings.Join(vars, ", ")
+}
+
+func (v *ServerVars) Set(value string) error {
+	name, varValue, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("invalid server variable %q: must be \"name=value\"", value)
+	}
+	if *v == nil {
+		*v = make(ServerVars)
+	}
+	(*v)[strings.TrimSpace(name)] = strings.TrimSpace(varValue)
+	return nil
+}
+
+// sentinelRouter maps errors that merely copy the text of the routers package
+// sentinels (as the legacy router's do) back to the sentinels themselves, so
+// undocumented endpoints are detected regardless of the backend.
+type sentinelRouter struct {
+	routers.Router
+}
+
+func (r sentinelRouter) FindRoute(req *http.Request) (*routers.Route, map[string]string, error) {
+	route, pathParams, err := r.Router.FindRoute(req)
+
+	var routeErr *routers.RouteError
+	if errors.As(err, &routeErr) {
+		switch routeErr.Reason {
+		case routers.ErrPathNotFound.Error():
+			err = routers.ErrPathNotFound
+		case routers.ErrMethodNotAllowed.Error():
+			err = routers.ErrMethodNotAllowed
+		}
+	}
+	return route, pathParams, err
+}
+
+func newOpenAPILoader() *openapi3.Loader {
+	loader := openapi3.NewLoader()
+	// The default reader caches documents process-wide, which would hide changes on reload
+	loader.ReadFromURIFunc = openapi3.ReadFromURIs(openapi3.ReadFromHTTP(http.DefaultClient), openapi3.ReadFromFile)
+	return loader
+}
+
+// checkSpecVersion rejects versions whose schema semantics the validator
+// doesn't implement, rather than silently mis-validating against them.
+// OpenAPI 3.1 specs are validated as JSON Schema 2020-12.
+func checkSpecVersion(version string) error {
+	if version == "" {
+		return errors.New(`spec has no "openapi" version field`)
+	}
+	if !strings.HasPrefix(version, "3.0.") && !strings.HasPrefix(version, "3.1.") {
+		return fmt.Errorf("unsupported OpenAPI version %q: SpecGate supports 3.0.x and 3.1.x", version)
+	}
+	return nil
+}
+
+// lintSpec checks the spec for problems that don't prevent it from loading,
+// such as invalid schemas or examples that don't match them.
+func lintSpec(spec *openapi3.T) error {
+	if err := spec.Validate(context.Background()); err != nil {
+		return fmt.Errorf("spec has problems: %w", err)
+	}
+	return nil
+}
+
+// splitSpecPaths splits a comma-separated list of spec paths and URLs.
+func splitSpecPaths(specPath string) []string {
+	var paths []string
+	for _, path := range strings.Split(specPath, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+func isRemoteSpec(specPath string) bool {
+	return strings.HasPrefix(specPath, "http://") || strings.HasPrefix(specPath, "https://")
+}
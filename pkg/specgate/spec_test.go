@@ -0,0 +1,274 @@
+package specgate
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+func TestParseRouterBackend(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    RouterBackend
+		expectError bool
+	}{
+		{name: "gorillamux", input: "gorillamux", expected: RouterGorillaMux},
+		{name: "legacy", input: "legacy", expected: RouterLegacy},
+		{name: "case insensitive", input: "Legacy", expected: RouterLegacy},
+		{name: "unknown", input: "chi", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseRouterBackend(tt.input)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("parseRouterBackend() error = %v, expectError %v", err, tt.expectError)
+			}
+			if result != tt.expected {
+				t.Errorf("parseRouterBackend() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSpecLoader_RouterBackends(t *testing.T) {
+	tests := []struct {
+		method      string
+		path        string
+		expected    string
+		expectedErr error
+	}{
+		{method: http.MethodGet, path: "/users", expected: "/users"},
+		{method: http.MethodGet, path: "/users/42", expected: "/users/{id}"},
+		{method: http.MethodDelete, path: "/users", expectedErr: routers.ErrMethodNotAllowed},
+		{method: http.MethodGet, path: "/orders", expectedErr: routers.ErrPathNotFound},
+	}
+
+	for _, backend := range []RouterBackend{RouterGorillaMux, RouterLegacy} {
+		t.Run(string(backend), func(t *testing.T) {
+			loader := specLoader{
+				upstreamURLs: []string{"http://localhost:3000"},
+				router:       backend,
+				logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+			_, router, err := loader.load(writeTestSpec(t, testSpec))
+			if err != nil {
+				t.Fatalf("load() unexpected error: %v", err)
+			}
+
+			for _, tt := range tests {
+				req := httptest.NewRequest(tt.method, "http://localhost:3000"+tt.path, nil)
+				route, _, err := router.FindRoute(req)
+
+				if tt.expectedErr != nil {
+					if !errors.Is(err, tt.expectedErr) {
+						t.Errorf("%s %s: error = %v, expected %v", tt.method, tt.path, err, tt.expectedErr)
+					}
+					continue
+				}
+				if err != nil || route.Path != tt.expected {
+					t.Errorf("%s %s: matched %v (error %v), expected %s", tt.method, tt.path, route, err, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestSpecLoader_LegacyRouterRequiresValidSpec(t *testing.T) {
+	loader := specLoader{
+		upstreamURLs: []string{"http://localhost:3000"},
+		router:       RouterLegacy,
+		logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	spec := writeTestSpec(t, `openapi: 3.0.0
+info:
+  title: Test API
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      responses:
+        "200":
+          description: Users
+          content:
+            application/json:
+              schema:
+                type: strin
+`)
+	if _, _, err := loader.load(spec); err == nil {
+		t.Error("legacy router should refuse a spec that fails validation")
+	}
+}
+
+func TestValidatingProxy_KeepSpecBasePath(t *testing.T) {
+	specWithServers := strings.Replace(testSpec, "paths:\n", `servers:
+  - url: https://api.example.com/v2
+  - url: "{scheme}://staging.example.com/{version}/"
+    variables:
+      scheme:
+        default: https
+      version:
+        default: v3
+        enum: [v3, v4]
+paths:
+`, 1)
+
+	tests := []struct {
+		name           string
+		keepBasePath   bool
+		serverVars     ServerVars
+		upstreamPath   string
+		path           string
+		expectedPath   string
+		expectedStatus int
+	}{
+		{
+			name:           "base path ignored by default",
+			path:           "/v2/users/1",
+			expectedPath:   "/v2/users/1",
+			expectedStatus: http.StatusOK, // undocumented, so not validated
+		},
+		{
+			name:           "base path kept",
+			keepBasePath:   true,
+			path:           "/v2/users/1",
+			expectedPath:   "/v2/users/1",
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "base path from server variables",
+			keepBasePath:   true,
+			path:           "/v3/users/1",
+			expectedPath:   "/v3/users/1",
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "base path from server variable override",
+			keepBasePath:   true,
+			serverVars:     ServerVars{"version": "v4"},
+			path:           "/v4/users/1",
+			expectedPath:   "/v4/users/1",
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "default replaced by server variable override",
+			keepBasePath:   true,
+			serverVars:     ServerVars{"version": "v4"},
+			path:           "/v3/users/1",
+			expectedPath:   "/v3/users/1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "base path under upstream base path",
+			keepBasePath:   true,
+			upstreamPath:   "/api",
+			path:           "/v2/users/1",
+			expectedPath:   "/api/v2/users/1",
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "path outside the base path",
+			keepBasePath:   true,
+			path:           "/users/1",
+			expectedPath:   "/users/1",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var receivedPath string
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				receivedPath = r.URL.Path
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id": "not-a-number"}`))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL + tt.upstreamPath
+			cfg.Mode = "strict"
+			cfg.KeepSpecBasePath = tt.keepBasePath
+			cfg.ServerVariables = tt.serverVars
+			vp := newTestProxyWithConfig(t, specWithServers, cfg)
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d", rec.Code, tt.expectedStatus)
+			}
+			if receivedPath != tt.expectedPath {
+				t.Errorf("upstream received %q, expected %q", receivedPath, tt.expectedPath)
+			}
+		})
+	}
+}
+
+func TestSpecBasePaths_ServerVariables(t *testing.T) {
+	spec := &openapi3.T{
+		OpenAPI: "3.0.0",
+		Servers: openapi3.Servers{{
+			URL: "https://{region}.api.example.com/{version}",
+			Variables: map[string]*openapi3.ServerVariable{
+				"region":  {Default: "eu"},
+				"version": {Default: "v1", Enum: []string{"v1", "v2"}},
+			},
+		}},
+	}
+
+	tests := []struct {
+		name          string
+		vars          ServerVars
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "defaults",
+			expected: []string{"/v1"},
+		},
+		{
+			name:     "override within enum",
+			vars:     ServerVars{"region": "us", "version": "v2"},
+			expected: []string{"/v2"},
+		},
+		{
+			name:          "override outside enum",
+			vars:          ServerVars{"version": "v3"},
+			expectedError: `"v3" must be one of v1, v2`,
+		},
+		{
+			name:          "undeclared variable",
+			vars:          ServerVars{"tenant": "acme"},
+			expectedError: `server variable "tenant" is not declared`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			basePaths, err := specBasePaths(spec, tt.vars, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("specBasePaths() error = %v, expected error containing %q", err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("specBasePaths() unexpected error: %v", err)
+			}
+			if !slices.Equal(basePaths, tt.expected) {
+				t.Errorf("specBasePaths() = %v, expected %v", basePaths, tt.expected)
+			}
+		})
+	}
+}
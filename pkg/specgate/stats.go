@@ -0,0 +1,135 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// maxUndocumentedEndpoints caps the distinct undocumented endpoints Stats
+// tracks, since clients can request any number of paths. Further endpoints
+// are counted together under otherUndocumented.
+const (
+	maxUndocumentedEndpoints = 100
+	otherUndocumented        = "(other endpoints)"
+)
+
+// Stats tallies validation outcomes for the summary printed on shutdown.
+type Stats struct {
+	mu                  sync.Mutex
+	checked             int
+	failed              int
+	failuresByOperation map[string]int
+	undocumented        map[string]int
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		failuresByOperation: make(map[string]int),
+		undocumented:        make(map[string]int),
+	}
+}
+
+func (s *Stats) recordPass() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checked++
+}
+
+func (s *Stats) recordFailure(route *routers.Route) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checked++
+	s.failed++
+	s.failuresByOperation[operationName(route)]++
+}
+
+// recordUndocumented counts a request to an endpoint the spec doesn't
+// document. There is no path template to group by, so the raw path is used.
+func (s *Stats) recordUndocumented(method, path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := method + " " + path
+	if _, ok := s.undocumented[key]; !ok && len(s.undocumented) >= maxUndocumentedEndpoints {
+		key = otherUndocumented
+	}
+	s.undocumented[key]++
+}
+
+// Failed returns the number of responses that have failed validation.
+func (s *Stats) Failed() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.failed
+}
+
+// operationName falls back to "METHOD /path" for operations without an operationId.
+func operationName(route *routers.Route) string {
+	if route.Operation != nil && route.Operation.OperationID != "" {
+		return route.Operation.OperationID
+	}
+	return route.Method + " " + route.Path
+}
+
+func (s *Stats) WriteSummary(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Validation summary")
+	fmt.Fprintf(tw, "  Checked:\t%d\n", s.checked)
+	fmt.Fprintf(tw, "  Passed:\t%d\n", s.checked-s.failed)
+	fmt.Fprintf(tw, "  Failed:\t%d\n", s.failed)
+
+	writeCounts(tw, "OPERATION\tFAILURES", s.failuresByOperation)
+	writeCounts(tw, "UNDOCUMENTED ENDPOINT\tHITS", s.undocumented)
+
+	return tw.Flush()
+}
+
+// writeCounts lists counts under heading, most frequent first.
+func writeCounts(w io.Writer, heading string, counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if counts[a] != counts[b] {
+			return counts[a] > counts[b]
+		}
+		return a < b
+	})
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "  "+heading)
+	for _, key := range keys {
+		fmt.Fprintf(w, "  %s\t%d\n", key, counts[key])
+	}
+}
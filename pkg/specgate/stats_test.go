@@ -0,0 +1,108 @@
+package specgate
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+func TestStats_WriteSummary(t *testing.T) {
+	getUser := &routers.Route{Method: "GET", Path: "/users/{id}", Operation: &openapi3.Operation{OperationID: "getUser"}}
+	listUsers := &routers.Route{Method: "GET", Path: "/users", Operation: &openapi3.Operation{OperationID: "listUsers"}}
+	anonymous := &routers.Route{Method: "DELETE", Path: "/users/{id}", Operation: &openapi3.Operation{}}
+
+	stats := NewStats()
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(stats.recordPass)
+	}
+	for _, route := range []*routers.Route{listUsers, getUser, anonymous, getUser} {
+		wg.Go(func() { stats.recordFailure(route) })
+	}
+	for _, path := range []string{"/health", "/internal/debug", "/health"} {
+		wg.Go(func() { stats.recordUndocumented("GET", path) })
+	}
+	wg.Wait()
+
+	var out bytes.Buffer
+	if err := stats.WriteSummary(&out); err != nil {
+		t.Fatalf("WriteSummary() unexpected error: %v", err)
+	}
+	summary := out.String()
+
+	for _, pattern := range []string{
+		`Checked:\s+14\n`,
+		`Passed:\s+10\n`,
+		`Failed:\s+4\n`,
+		`getUser\s+2\n\s+DELETE /users/\{id\}\s+1\n\s+listUsers\s+1\n`,
+		`UNDOCUMENTED ENDPOINT\s+HITS\n\s+GET /health\s+2\n\s+GET /internal/debug\s+1\n`,
+	} {
+		if !regexp.MustCompile(pattern).MatchString(summary) {
+			t.Errorf("summary does not match %q:\n%s", pattern, summary)
+		}
+	}
+}
+
+func TestStats_WriteSummaryWithoutFailures(t *testing.T) {
+	var out bytes.Buffer
+	if err := NewStats().WriteSummary(&out); err != nil {
+		t.Fatalf("WriteSummary() unexpected error: %v", err)
+	}
+	if strings.Contains(out.String(), "OPERATION") || strings.Contains(out.String(), "UNDOCUMENTED") {
+		t.Errorf("summary without failures should not include a breakdown:\n%s", out.String())
+	}
+}
+
+func TestStats_UndocumentedLimit(t *testing.T) {
+	stats := NewStats()
+	for i := range maxUndocumentedEndpoints + 50 {
+		stats.recordUndocumented("GET", "/random/"+strconv.Itoa(i))
+	}
+	stats.recordUndocumented("GET", "/random/0")
+
+	if len(stats.undocumented) != maxUndocumentedEndpoints+1 {
+		t.Errorf("tracked %d undocumented endpoints, expected %d plus one overflow bucket", len(stats.undocumented), maxUndocumentedEndpoints)
+	}
+	if stats.undocumented[otherUndocumented] != 50 {
+		t.Errorf("overflow bucket = %d, expected 50", stats.undocumented[otherUndocumented])
+	}
+	if stats.undocumented["GET /random/0"] != 2 {
+		t.Errorf("GET /random/0 = %d, expected an endpoint tracked before the limit to keep counting", stats.undocumented["GET /random/0"])
+	}
+}
+
+func TestValidatingProxy_Stats(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/users/1" {
+			_, _ = w.Write([]byte(`{"id": 1, "name": "test"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": "not-a-number"}`))
+	}))
+	defer upstream.Close()
+
+	vp := newTestProxy(t, testSpec, upstream.URL, "report")
+	for _, target := range []string{"/users/1", "/users/2", "/undocumented", "/undocumented"} {
+		vp.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	if vp.stats.checked != 2 || vp.stats.failed != 1 || vp.stats.failuresByOperation["getUser"] != 1 {
+		t.Errorf("unexpected stats: checked=%d failed=%d byOperation=%v",
+			vp.stats.checked, vp.stats.failed, vp.stats.failuresByOperation)
+	}
+	if vp.stats.undocumented["GET /undocumented"] != 2 || len(vp.stats.undocumented) != 1 {
+		t.Errorf("undocumented = %v, expected GET /undocumented hit twice", vp.stats.undocumented)
+	}
+	if vp.stats.Failed() != 1 {
+		t.Errorf("Failed() = %d, expected 1", vp.stats.Failed())
+	}
+}
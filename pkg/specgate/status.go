@@ -0,0 +1,132 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StatusSet is a set of HTTP status codes written as a comma-separated list
+// of exact codes (404), classes (5xx) and ranges (500-503).
+type StatusSet []statusRange
+
+type statusRange struct {
+	min, max int
+}
+
+func ParseStatusSet(s string) (StatusSet, error) {
+	var set StatusSet
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		r, err := parseStatusRange(part)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, r)
+	}
+	return set, nil
+}
+
+func parseStatusRange(s string) (statusRange, error) {
+	if class, ok := strings.CutSuffix(strings.ToLower(s), "xx"); ok {
+		n, err := strconv.Atoi(class)
+		if err != nil || n < 1 || n > 5 {
+			return statusRange{}, fmt.Errorf("invalid status class %q", s)
+		}
+		return statusRange{min: n * 100, max: n*100 + 99}, nil
+	}
+
+	low, high, isRange := strings.Cut(s, "-")
+	if !isRange {
+		high = low
+	}
+
+	minStatus, minErr := parseStatusCode(low)
+	maxStatus, maxErr := parseStatusCode(high)
+	if minErr != nil || maxErr != nil || minStatus > maxStatus {
+		return statusRange{}, fmt.Errorf("invalid status code %q", s)
+	}
+	return statusRange{min: minStatus, max: maxStatus}, nil
+}
+
+func parseStatusCode(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 100 || n > 599 {
+		return 0, fmt.Errorf("invalid status code %q", s)
+	}
+	return n, nil
+}
+
+func (s StatusSet) Contains(status int) bool {
+	for _, r := range s {
+		if status >= r.min && status <= r.max {
+			return true
+		}
+	}
+	return false
+}
+
+func (s StatusSet) String() string {
+	parts := make([]string, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r.min == r.max:
+			parts = append(parts, strconv.Itoa(r.min))
+		case r.min%100 == 0 && r.max == r.min+99:
+			parts = append(parts, fmt.Sprintf("%dxx", r.min/100))
+		default:
+			parts = append(parts, fmt.Sprintf("%d-%d", r.min, r.max))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func (s *StatusSet) Set(value string) error {
+	set, err := ParseStatusSet(value)
+	if err != nil {
+		return err
+	}
+	*s = set
+	return nil
+}
+
+// UnmarshalYAML accepts either a comma-separated string or a list.
+func (s *StatusSet) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.SequenceNode {
+		return s.Set(node.Value)
+	}
+
+	var items []string
+	if err := node.Decode(&items); err != nil {
+		return err
+	}
+	return s.Set(strings.Join(items, ","))
+}
+
+func (s StatusSet) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
@@ -0,0 +1,67 @@
+package specgate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseStatusSet(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    StatusSet
+		expectError bool
+	}{
+		{name: "empty", input: "", expected: nil},
+		{name: "exact code", input: "404", expected: StatusSet{{min: 404, max: 404}}},
+		{name: "class", input: "5xx", expected: StatusSet{{min: 500, max: 599}}},
+		{name: "uppercase class", input: "4XX", expected: StatusSet{{min: 400, max: 499}}},
+		{name: "range", input: "500-503", expected: StatusSet{{min: 500, max: 503}}},
+		{
+			name:     "mixed list with spaces",
+			input:    "404, 5xx ,",
+			expected: StatusSet{{min: 404, max: 404}, {min: 500, max: 599}},
+		},
+		{name: "not a number", input: "abc", expectError: true},
+		{name: "out of range code", input: "600", expectError: true},
+		{name: "invalid class", input: "6xx", expectError: true},
+		{name: "reversed range", input: "503-500", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseStatusSet(tt.input)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("ParseStatusSet(%q) error = %v, expectError %v", tt.input, err, tt.expectError)
+			}
+			if !tt.expectError && !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParseStatusSet(%q) = %v, expected %v", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStatusSet_Contains(t *testing.T) {
+	set, err := ParseStatusSet("404,5xx,429-431")
+	if err != nil {
+		t.Fatalf("ParseStatusSet() unexpected error: %v", err)
+	}
+
+	for status, expected := range map[int]bool{
+		200: false,
+		404: true,
+		405: false,
+		429: true,
+		431: true,
+		500: true,
+		599: true,
+	} {
+		if got := set.Contains(status); got != expected {
+			t.Errorf("Contains(%d) = %v, expected %v", status, got, expected)
+		}
+	}
+
+	if got := set.String(); got != "404,5xx,429-431" {
+		t.Errorf("String() = %q, expected %q", got, "404,5xx,429-431")
+	}
+}
@@ -0,0 +1,110 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// UnknownLength controls what happens to a response body the upstream sent
+// without a Content-Length, typically with chunked transfer encoding.
+type UnknownLength string
+
+const (
+	// UnknownLengthBuffer buffers the body up to the maximum body size and
+	// validates it if it fits, as for any other response.
+	UnknownLengthBuffer UnknownLength = "buffer"
+	// UnknownLengthSkip streams the body through without validating it.
+	UnknownLengthSkip UnknownLength = "skip"
+)
+
+func parseUnknownLength(value string) (UnknownLength, error) {
+	switch UnknownLength(strings.ToLower(value)) {
+	case UnknownLengthBuffer:
+		return UnknownLengthBuffer, nil
+	case UnknownLengthSkip:
+		return UnknownLengthSkip, nil
+	default:
+		return "", fmt.Errorf("invalid unknown length handling '%s': must be one of 'buffer' or 'skip'", value)
+	}
+}
+
+// streamPolicy decides which responses are streamed to the client as they
+// arrive rather than buffered for validation.
+type streamPolicy struct {
+	skipContentTypes []string
+	unknownLength    UnknownLength
+}
+
+// newStreamPolicy assumes cfg has been validated.
+func newStreamPolicy(cfg *Config) streamPolicy {
+	unknownLength, _ := parseUnknownLength(cfg.UnknownLength)
+	return streamPolicy{
+		skipContentTypes: cfg.SkipContentTypes,
+		unknownLength:    unknownLength,
+	}
+}
+
+// skipsContentType reports whether responses of contentType are passed
+// through without being buffered or validated. Entries may name a media
+// type, or a whole type as in text/*.
+func (p streamPolicy) skipsContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, skipped := range p.skipContentTypes {
+		skipped = strings.ToLower(skipped)
+		if skipped == mediaType || strings.HasSuffix(skipped, "/*") && strings.HasPrefix(mediaType, skipped[:len(skipped)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipsUnknownLength reports whether resp is streamed through unvalidated
+// because its length isn't known up front.
+func (p streamPolicy) skipsUnknownLength(resp *http.Response) bool {
+	return resp.ContentLength < 0 && p.unknownLength == UnknownLengthSkip
+}
+
+// streamingWriter lifts the server's write timeout for responses with a
+// skipped content type, so streams such as server-sent events can stay open
+// longer than an ordinary response may take.
+type streamingWriter struct {
+	http.ResponseWriter
+	vp *ValidatingProxy
+}
+
+func (w *streamingWriter) WriteHeader(status int) {
+	if w.vp.streaming.skipsContentType(w.Header().Get("Content-Type")) {
+		if err := http.NewResponseController(w.ResponseWriter).SetWriteDeadline(time.Time{}); err != nil && err != http.ErrNotSupported {
+			w.vp.logger.Warn("Failed to lift write timeout for streaming response", "error", err)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *streamingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,101 @@
+package specgate
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidatingProxy_SkipContentTypes(t *testing.T) {
+	tests := []struct {
+		name             string
+		skipContentTypes string
+		expectedStatus   int
+	}{
+		{name: "not skipped", skipContentTypes: "text/event-stream", expectedStatus: http.StatusInternalServerError},
+		{name: "exact type", skipContentTypes: "application/json", expectedStatus: http.StatusOK},
+		{name: "wildcard", skipContentTypes: "application/*", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				_, _ = w.Write([]byte(`{"unexpected": true}`))
+			}))
+			defer upstream.Close()
+
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.Mode = "strict"
+			if err := cfg.SkipContentTypes.Set(tt.skipContentTypes); err != nil {
+				t.Fatalf("failed to parse skip content types: %v", err)
+			}
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d", rec.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_StreamsServerSentEvents(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: first\n\n"))
+		w.(http.Flusher).Flush()
+		<-release
+		_, _ = w.Write([]byte("data: second\n\n"))
+	}))
+	defer upstream.Close()
+
+	vp := newTestProxy(t, testSpec, upstream.URL, "strict")
+	server := httptest.NewUnstartedServer(vp)
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/users")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, expected %d", resp.StatusCode, http.StatusOK)
+	}
+
+	// The first event must arrive while the upstream is still holding the
+	// stream open, which it only can if nothing buffers the response
+	reader := bufio.NewReader(resp.Body)
+	line := make(chan string, 1)
+	go func() {
+		text, _ := reader.ReadString('\n')
+		line <- text
+	}()
+	select {
+	case text := <-line:
+		if strings.TrimSpace(text) != "data: first" {
+			t.Errorf("first line = %q, expected %q", text, "data: first")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("first event was not streamed before the upstream finished")
+	}
+
+	// Outlive the server's write timeout before sending the second event
+	time.Sleep(300 * time.Millisecond)
+	close(release)
+
+	rest, _ := io.ReadAll(reader)
+	if !strings.Contains(string(rest), "data: second") {
+		t.Errorf("stream after write timeout = %q, expected the second event", rest)
+	}
+}
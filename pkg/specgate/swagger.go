@@ -0,0 +1,82 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	oasyaml "github.com/oasdiff/yaml"
+	"gopkg.in/yaml.v3"
+)
+
+func isSwagger2(data []byte) bool {
+	var header struct {
+		Swagger string `yaml:"swagger"`
+	}
+	return yaml.Unmarshal(data, &header) == nil && header.Swagger == "2.0"
+}
+
+// convertSwagger2 converts a Swagger 2.0 document to OpenAPI 3.0. It returns
+// the Swagger 2.0 constructs that have no exact OpenAPI 3.0 equivalent, so
+// they can be reported as warnings.
+func convertSwagger2(data []byte, location *url.URL) (*openapi3.T, []string, error) {
+	jsonData, err := oasyaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse Swagger 2.0 spec: %w", err)
+	}
+
+	var doc2 openapi2.T
+	if err := json.Unmarshal(jsonData, &doc2); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse Swagger 2.0 spec: %w", err)
+	}
+
+	spec, err := openapi2conv.ToV3WithLoader(&doc2, newOpenAPILoader(), location)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert Swagger 2.0 spec: %w", err)
+	}
+
+	return spec, swagger2Caveats(&doc2), nil
+}
+
+func swagger2Caveats(doc2 *openapi2.T) []string {
+	var caveats []string
+	for path, item := range doc2.Paths {
+		for method, operation := range item.Operations() {
+			for _, param := range slices.Concat(item.Parameters, operation.Parameters) {
+				if param == nil {
+					continue
+				}
+				switch {
+				case param.Type != nil && param.Type.Is("file"):
+					caveats = append(caveats, fmt.Sprintf("%s %s: file parameter %q is validated as a binary string", method, path, param.Name))
+				case param.CollectionFormat == "tsv":
+					caveats = append(caveats, fmt.Sprintf("%s %s: tsv collectionFormat of %q has no OpenAPI 3 equivalent", method, path, param.Name))
+				}
+			}
+		}
+	}
+	slices.Sort(caveats)
+	return caveats
+}
@@ -0,0 +1,128 @@
+package specgate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+const testSwaggerSpec = `swagger: "2.0"
+info:
+  title: Legacy API
+  version: 1.0.0
+host: legacy.example.com
+basePath: /
+produces:
+  - application/json
+paths:
+  /users/{id}:
+    get:
+      operationId: getUser
+      parameters:
+        - name: id
+          in: path
+          required: true
+          type: integer
+      responses:
+        200:
+          description: User
+          schema:
+            $ref: "#/definitions/User"
+  /avatars:
+    post:
+      consumes:
+        - multipart/form-data
+      parameters:
+        - name: image
+          in: formData
+          type: file
+        - name: tags
+          in: query
+          type: array
+          items:
+            type: string
+          collectionFormat: tsv
+      responses:
+        204:
+          description: Uploaded
+definitions:
+  User:
+    type: object
+    required: [id, name]
+    properties:
+      id:
+        type: integer
+      name:
+        type: string
+`
+
+func TestIsSwagger2(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected bool
+	}{
+		{name: "swagger yaml", data: testSwaggerSpec, expected: true},
+		{name: "swagger json", data: `{"swagger": "2.0", "paths": {}}`, expected: true},
+		{name: "openapi 3", data: testSpec, expected: false},
+		{name: "invalid yaml", data: "swagger: [", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSwagger2([]byte(tt.data)); got != tt.expected {
+				t.Errorf("isSwagger2() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertSwagger2(t *testing.T) {
+	spec, caveats, err := convertSwagger2([]byte(testSwaggerSpec), &url.URL{Path: "legacy.yaml"})
+	if err != nil {
+		t.Fatalf("convertSwagger2() unexpected error: %v", err)
+	}
+
+	if spec.Paths.Find("/users/{id}") == nil || spec.Components.Schemas["User"] == nil {
+		t.Error("converted spec is missing paths or definitions")
+	}
+
+	expected := []string{
+		`POST /avatars: file parameter "image" is validated as a binary string`,
+		`POST /avatars: tsv collectionFormat of "tags" has no OpenAPI 3 equivalent`,
+	}
+	if !reflect.DeepEqual(caveats, expected) {
+		t.Errorf("caveats = %q, expected %q", caveats, expected)
+	}
+}
+
+func TestValidatingProxy_Swagger2(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "valid response", body: `{"id": 1, "name": "test"}`, expectedStatus: http.StatusOK},
+		{name: "invalid response", body: `{"id": "one"}`, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, testSwaggerSpec, upstream.URL, "strict")
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+		})
+	}
+}
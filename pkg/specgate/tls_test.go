@@ -0,0 +1,120 @@
+package specgate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a self-signed certificate and key for commonName to
+// dir, returning their paths.
+func writeTestCert(t *testing.T, dir, commonName string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	return certPath, keyPath
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeTestCert(t, dir, "first")
+
+	reloader, err := newCertReloader(certPath, keyPath, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("newCertReloader() unexpected error: %v", err)
+	}
+
+	commonName := func() string {
+		t.Helper()
+		cert, err := reloader.GetCertificate(nil)
+		if err != nil {
+			t.Fatalf("GetCertificate() unexpected error: %v", err)
+		}
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatalf("failed to parse certificate: %v", err)
+		}
+		return leaf.Subject.CommonName
+	}
+
+	if name := commonName(); name != "first" {
+		t.Fatalf("GetCertificate() served %q, expected first", name)
+	}
+
+	writeTestCert(t, dir, "second")
+	later := time.Now().Add(time.Minute)
+	for _, path := range []string{certPath, keyPath} {
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatalf("failed to touch %s: %v", path, err)
+		}
+	}
+	if name := commonName(); name != "second" {
+		t.Errorf("GetCertificate() served %q after renewal, expected second", name)
+	}
+
+	if err := os.WriteFile(certPath, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("failed to corrupt certificate: %v", err)
+	}
+	later = later.Add(time.Minute)
+	if err := os.Chtimes(certPath, later, later); err != nil {
+		t.Fatalf("failed to touch %s: %v", certPath, err)
+	}
+	if name := commonName(); name != "second" {
+		t.Errorf("GetCertificate() served %q after a failed reload, expected the previous certificate", name)
+	}
+}
+
+func TestNewServerTLSConfig(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	cfg := DefaultConfig()
+	if tlsConfig, err := newServerTLSConfig(cfg, logger); err != nil || tlsConfig != nil {
+		t.Errorf("newServerTLSConfig() = %v, %v, expected no TLS config without a certificate", tlsConfig, err)
+	}
+
+	cfg.TLSCert, cfg.TLSKey = writeTestCert(t, t.TempDir(), "specgate")
+	if tlsConfig, err := newServerTLSConfig(cfg, logger); err != nil || tlsConfig == nil {
+		t.Errorf("newServerTLSConfig() = %v, %v, expected a TLS config", tlsConfig, err)
+	}
+
+	cfg.TLSKey = filepath.Join(t.TempDir(), "missing.pem")
+	if _, err := newServerTLSConfig(cfg, logger); err == nil {
+		t.Error("newServerTLSConfig() expected an error for a missing key")
+	}
+}
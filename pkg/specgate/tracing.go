@@ -0,0 +1,114 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/getkin/kin-openapi/routers"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer returns a tracer from the global tracer provider, which does
+// nothing until SetupTracing installs an exporting one.
+func tracer() trace.Tracer {
+	return otel.Tracer("github.com/sorenjohanson/specgate")
+}
+
+// SetupTracing exports spans over OTLP/HTTP to cfg.OtelEndpoint and returns a
+// function that flushes them on shutdown. Without an endpoint, tracing stays
+// disabled.
+func SetupTracing(ctx context.Context, cfg *Config) (func(context.Context) error, error) {
+	if cfg.OtelEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(tracesURL(cfg.OtelEndpoint)))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", "specgate")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK())
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// tracesURL adds the standard OTLP traces path to a bare collector URL such
+// as http://localhost:4318.
+func tracesURL(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Path != "" && u.Path != "/") {
+		return endpoint
+	}
+	u.Path = "/v1/traces"
+	return u.String()
+}
+
+// startProxySpan continues the client's trace, if it sent one, with a span
+// covering the proxied request. The span's context is passed on to the
+// upstream.
+func startProxySpan(r *http.Request) (*http.Request, trace.Span) {
+	propagator := otel.GetTextMapPropagator()
+	ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := tracer().Start(ctx, r.Method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path)))
+	propagator.Inject(ctx, propagation.HeaderCarrier(r.Header))
+	return r.WithContext(ctx), span
+}
+
+func startValidationSpan(ctx context.Context, name string, route *routers.Route) (context.Context, trace.Span) {
+	attributes := []attribute.KeyValue{attribute.String("http.route", route.Path)}
+	if id := operationID(route); id != "" {
+		attributes = append(attributes, attribute.String("specgate.operation_id", id))
+	}
+	return tracer().Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// endValidationSpan records a validation failure on span as an event and an
+// error status, then ends it.
+func endValidationSpan(span trace.Span, err error) {
+	if err != nil {
+		span.AddEvent("validation failure", trace.WithAttributes(attribute.String("error", err.Error())))
+		span.SetStatus(codes.Error, "validation failed")
+	}
+	span.End()
+}
@@ -0,0 +1,105 @@
+package specgate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestValidatingProxy_Tracing(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	tests := []struct {
+		name          string
+		path          string
+		expectFailure bool
+	}{
+		{
+			name: "valid response",
+			path: "/users/1",
+		},
+		{
+			name:          "invalid response",
+			path:          "/users/2",
+			expectFailure: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			otel.SetTracerProvider(provider)
+			otel.SetTextMapPropagator(propagation.TraceContext{})
+			t.Cleanup(func() {
+				otel.SetTracerProvider(sdktrace.NewTracerProvider())
+				otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+			})
+
+			var upstreamTraceparent string
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				upstreamTraceparent = r.Header.Get("Traceparent")
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Path == "/users/1" {
+					_, _ = w.Write([]byte(`{"id": 1, "name": "test"}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"id": "wrong"}`))
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, testSpec, upstream.URL, "warn")
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+			vp.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			if len(spans) != 2 {
+				t.Fatalf("expected a proxy span and a validation span, got %d spans", len(spans))
+			}
+			validation, proxy := spans[0], spans[1]
+
+			if proxy.SpanContext().TraceID().String() != traceID {
+				t.Errorf("proxy span trace ID = %s, expected the client's %s", proxy.SpanContext().TraceID(), traceID)
+			}
+			if !strings.Contains(upstreamTraceparent, proxy.SpanContext().SpanID().String()) {
+				t.Errorf("upstream traceparent = %q, expected it to carry the proxy span %s", upstreamTraceparent, proxy.SpanContext().SpanID())
+			}
+
+			if validation.Name() != "validate response" || validation.Parent().SpanID() != proxy.SpanContext().SpanID() {
+				t.Errorf("expected %q span as a child of the proxy span, got %q", "validate response", validation.Name())
+			}
+			failed := validation.Status().Code == codes.Error && len(validation.Events()) == 1
+			if failed != tt.expectFailure {
+				t.Errorf("validation span failed = %v, expected %v (status %v, events %v)", failed, tt.expectFailure, validation.Status(), validation.Events())
+			}
+		})
+	}
+}
+
+func TestTracesURL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		expected string
+	}{
+		{"http://localhost:4318", "http://localhost:4318/v1/traces"},
+		{"http://localhost:4318/", "http://localhost:4318/v1/traces"},
+		{"https://collector.example.com/otlp/v1/traces", "https://collector.example.com/otlp/v1/traces"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			if got := tracesURL(tt.endpoint); got != tt.expected {
+				t.Errorf("tracesURL(%q) = %q, expected %q", tt.endpoint, got, tt.expected)
+			}
+		})
+	}
+}
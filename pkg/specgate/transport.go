@@ -0,0 +1,113 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// newProxyTransport returns the transport the reverse proxy sends requests
+// through, and the replay transport within it when fixtures are replayed.
+func newProxyTransport(cfg *Config, logger *slog.Logger) (http.RoundTripper, *replayTransport, error) {
+	upstream, err := newUpstreamTransport(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	health := newHealthTransport(upstream, cfg.UpstreamFailTimeout, logger)
+	transport := newRetryTransport(health, cfg.Retries, cfg.RetryBackoff, logger)
+
+	replay, err := newReplayTransport(cfg, transport)
+	if err != nil {
+		return nil, nil, err
+	}
+	if replay == nil {
+		return transport, nil, nil
+	}
+	return replay, replay, nil
+}
+
+// newUpstreamTransport returns the transport for requests to the upstream:
+// Go's default transport with the configured TLS settings, timeouts and
+// connection pool.
+func newUpstreamTransport(cfg *Config) (*http.Transport, error) {
+	tlsConfig, err := newUpstreamTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	transport.DialContext = (&net.Dialer{
+		Timeout:   cfg.UpstreamDialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = cfg.UpstreamTLSHandshakeTimeout
+	transport.ResponseHeaderTimeout = cfg.UpstreamResponseHeaderTimeout
+	transport.IdleConnTimeout = cfg.UpstreamIdleTimeout
+	// Every request goes to the same host, so the per-host limit is the one
+	// that matters; Go's default of 2 would close most idle connections
+	transport.MaxIdleConns = cfg.UpstreamMaxIdleConns
+	transport.MaxIdleConnsPerHost = cfg.UpstreamMaxIdleConns
+	if cfg.UpstreamMaxIdleConns == 0 {
+		// For MaxIdleConnsPerHost, 0 means that default rather than no limit
+		transport.MaxIdleConnsPerHost = math.MaxInt
+	}
+	return transport, nil
+}
+
+func newUpstreamTLSConfig(cfg *Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.UpstreamInsecure, // #nosec G402 -- opt-in for upstreams with self-signed certificates
+	}
+
+	if cfg.UpstreamCA != "" {
+		pem, err := os.ReadFile(cfg.UpstreamCA) // #nosec G304 -- path is supplied by the operator
+		if err != nil {
+			return nil, fmt.Errorf("failed to read upstream CA: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in upstream CA %s", cfg.UpstreamCA)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.UpstreamClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.UpstreamClientCert, cfg.UpstreamClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load upstream client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
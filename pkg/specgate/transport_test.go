@@ -0,0 +1,173 @@
+package specgate
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestValidatingProxy_UpstreamTLS(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer upstream.Close()
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: upstream.Certificate().Raw})
+	if err := os.WriteFile(caPath, caPEM, 0o600); err != nil {
+		t.Fatalf("failed to write CA: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		configure      func(cfg *Config)
+		expectedStatus int
+	}{
+		{name: "verifies by default", configure: func(*Config) {}, expectedStatus: http.StatusBadGateway},
+		{name: "custom CA", configure: func(cfg *Config) { cfg.UpstreamCA = caPath }, expectedStatus: http.StatusOK},
+		{name: "skip verification", configure: func(cfg *Config) { cfg.UpstreamInsecure = true }, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			tt.configure(cfg)
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("ServeHTTP() status = %d, expected %d", rec.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestNewUpstreamTransport_InvalidCA(t *testing.T) {
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caPath, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("failed to write CA: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	for _, path := range []string{caPath, filepath.Join(t.TempDir(), "missing.pem")} {
+		cfg.UpstreamCA = path
+		if _, err := newUpstreamTransport(cfg); err == nil {
+			t.Errorf("newUpstreamTransport() expected an error for CA %s", path)
+		}
+	}
+}
+
+func TestValidatingProxy_UpstreamClientCert(t *testing.T) {
+	upstream := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	upstream.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	upstream.StartTLS()
+	defer upstream.Close()
+
+	certPath, keyPath := writeTestCert(t, t.TempDir(), "specgate")
+
+	tests := []struct {
+		name           string
+		clientCert     string
+		clientKey      string
+		expectedStatus int
+	}{
+		{name: "without client certificate", expectedStatus: http.StatusUnauthorized},
+		{name: "with client certificate", clientCert: certPath, clientKey: keyPath, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Upstream = upstream.URL
+			cfg.UpstreamInsecure = true
+			cfg.UpstreamClientCert = tt.clientCert
+			cfg.UpstreamClientKey = tt.clientKey
+			vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("ServeHTTP() status = %d, expected %d", rec.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
+
+func TestNewUpstreamTransport_Timeouts(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.UpstreamTLSHandshakeTimeout = 5 * time.Second
+	cfg.UpstreamResponseHeaderTimeout = 20 * time.Second
+	cfg.UpstreamMaxIdleConns = 500
+	cfg.UpstreamIdleTimeout = time.Minute
+
+	transport, err := newUpstreamTransport(cfg)
+	if err != nil {
+		t.Fatalf("newUpstreamTransport() unexpected error: %v", err)
+	}
+
+	if transport.TLSHandshakeTimeout != 5*time.Second {
+		t.Errorf("TLSHandshakeTimeout = %s, expected 5s", transport.TLSHandshakeTimeout)
+	}
+	if transport.ResponseHeaderTimeout != 20*time.Second {
+		t.Errorf("ResponseHeaderTimeout = %s, expected 20s", transport.ResponseHeaderTimeout)
+	}
+	if transport.MaxIdleConns != 500 || transport.MaxIdleConnsPerHost != 500 {
+		t.Errorf("MaxIdleConns = %d, MaxIdleConnsPerHost = %d, expected 500", transport.MaxIdleConns, transport.MaxIdleConnsPerHost)
+	}
+	if transport.IdleConnTimeout != time.Minute {
+		t.Errorf("IdleConnTimeout = %s, expected 1m", transport.IdleConnTimeout)
+	}
+}
+
+func TestNewUpstreamTransport_UnlimitedIdleConns(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.UpstreamMaxIdleConns = 0
+
+	transport, err := newUpstreamTransport(cfg)
+	if err != nil {
+		t.Fatalf("newUpstreamTransport() unexpected error: %v", err)
+	}
+	if transport.MaxIdleConns != 0 || transport.MaxIdleConnsPerHost != math.MaxInt {
+		t.Errorf("MaxIdleConns = %d, MaxIdleConnsPerHost = %d, expected no limit on either", transport.MaxIdleConns, transport.MaxIdleConnsPerHost)
+	}
+}
+
+func TestValidatingProxy_UpstreamResponseHeaderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+	defer close(release)
+
+	cfg := DefaultConfig()
+	cfg.Upstream = upstream.URL
+	cfg.UpstreamResponseHeaderTimeout = 50 * time.Millisecond
+	vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+	rec := httptest.NewRecorder()
+	vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("ServeHTTP() status = %d, expected %d for a slow upstream", rec.Code, http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,235 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// upstreamRoute sends requests whose path starts with prefix to its
+// replicas in turn. An empty prefix matches every path.
+type upstreamRoute struct {
+	prefix   string
+	replicas []*upstreamReplica
+	next     atomic.Uint64
+}
+
+// upstreamReplica is one backend serving a route. A replica that fails to
+// respond is ejected from rotation until ejectedUntil.
+type upstreamReplica struct {
+	target       *url.URL
+	ejectedUntil atomic.Int64 // Unix nanoseconds
+}
+
+func (r *upstreamReplica) healthy(now time.Time) bool {
+	return now.UnixNano() >= r.ejectedUntil.Load()
+}
+
+// upstreams is the set of backends SpecGate proxies to, ordered so that the
+// longest matching prefix wins.
+type upstreams []*upstreamRoute
+
+type upstreamSelectionKey struct{}
+
+// upstreamSelection is the replica a request is sent to, along with its
+// route and the client's URL, so that a retry can be sent to another.
+type upstreamSelection struct {
+	route     *upstreamRoute
+	replica   *upstreamReplica
+	clientURL *url.URL
+}
+
+// parseUpstreams parses a comma-separated list of upstream URLs, each
+// optionally preceded by a path prefix as in /users=http://users:8080. URLs
+// given for the same prefix are replicas of one backend.
+func parseUpstreams(value string) (upstreams, error) {
+	var routes upstreams
+	byPrefix := make(map[string]*upstreamRoute)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		var prefix string
+		rawURL := entry
+		if p, u, ok := strings.Cut(entry, "="); ok && strings.HasPrefix(p, "/") {
+			prefix, rawURL = strings.TrimSuffix(strings.TrimSpace(p), "/"), strings.TrimSpace(u)
+		}
+
+		target, err := url.Parse(rawURL)
+		if err != nil || target.Scheme == "" || target.Host == "" {
+			return nil, fmt.Errorf("must be an absolute URL, got %q", rawURL)
+		}
+
+		route, ok := byPrefix[prefix]
+		if !ok {
+			route = &upstreamRoute{prefix: prefix}
+			byPrefix[prefix] = route
+			routes = append(routes, route)
+		}
+		route.replicas = append(route.replicas, &upstreamReplica{target: target})
+	}
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("must not be empty")
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(routes[i].prefix) > len(routes[j].prefix)
+	})
+	return routes, nil
+}
+
+// match returns the route for a request path, or nil if no prefix covers
+// it. Prefixes match whole path segments, so /users does not match
+// /usersettings.
+func (u upstreams) match(path string) *upstreamRoute {
+	for _, route := range u {
+		if rest, ok := strings.CutPrefix(path, route.prefix); ok && (rest == "" || rest[0] == '/' || route.prefix == "") {
+			return route
+		}
+	}
+	return nil
+}
+
+func (u upstreams) urls() []string {
+	var urls []string
+	for _, route := range u {
+		for _, replica := range route.replicas {
+			urls = append(urls, replica.target.String())
+		}
+	}
+	return urls
+}
+
+// pick returns the next healthy replica in round-robin order. If every
+// replica has been ejected, it carries on round-robin regardless rather than
+// failing the request outright.
+func (route *upstreamRoute) pick(now time.Time) *upstreamReplica {
+	n := uint64(len(route.replicas))
+	start := route.next.Add(1) - 1
+	for i := range n {
+		if replica := route.replicas[(start+i)%n]; replica.healthy(now) {
+			return replica
+		}
+	}
+	return route.replicas[start%n]
+}
+
+// selectUpstream picks the replica r will be proxied to, so that every
+// rewrite of r targets the same one. Requests that no upstream prefix
+// covers, which can only happen when every upstream has a prefix, are
+// answered with a 502.
+func (vp *ValidatingProxy) selectUpstream(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	route := vp.upstreams.match(r.URL.Path)
+	if route == nil {
+		vp.log(r.Context()).Warn("No upstream for request path",
+			"method", r.Method,
+			"path", r.URL.Path)
+		vp.writeError(w, ErrorDetails{
+			Status: http.StatusBadGateway,
+			Title:  "No upstream",
+			Detail: "No upstream is configured for this path",
+			Method: r.Method,
+			Path:   r.URL.Path,
+		})
+		return r, false
+	}
+
+	clientURL := *r.URL
+	selection := &upstreamSelection{route: route, replica: route.pick(time.Now()), clientURL: &clientURL}
+	return r.WithContext(context.WithValue(r.Context(), upstreamSelectionKey{}, selection)), true
+}
+
+// reselectUpstream points a retry of req at the replica its route picks
+// now, so that it skips a replica the failed attempt just ejected.
+func reselectUpstream(req *http.Request) *http.Request {
+	selection, ok := req.Context().Value(upstreamSelectionKey{}).(*upstreamSelection)
+	if !ok {
+		return req
+	}
+	replica := selection.route.pick(time.Now())
+	if replica == selection.replica {
+		return req
+	}
+
+	previous := selection.replica.target
+	if req.Host == previous.Host {
+		req.Host = replica.target.Host
+	}
+	req.URL.Scheme = replica.target.Scheme
+	req.URL.Host = replica.target.Host
+	req.URL.Path, req.URL.RawPath = joinURLPath(replica.target, selection.clientURL)
+	reselected := &upstreamSelection{route: selection.route, replica: replica, clientURL: selection.clientURL}
+	return req.WithContext(context.WithValue(req.Context(), upstreamSelectionKey{}, reselected))
+}
+
+// upstreamFor returns the upstream req is sent to: the replica selected for
+// it, or before selection, the first replica of its route, which routes the
+// same way.
+func (vp *ValidatingProxy) upstreamFor(req *http.Request) *url.URL {
+	if selection, ok := req.Context().Value(upstreamSelectionKey{}).(*upstreamSelection); ok {
+		return selection.replica.target
+	}
+	if route := vp.upstreams.match(req.URL.Path); route != nil {
+		return route.replicas[0].target
+	}
+	return nil
+}
+
+// healthTransport ejects a replica from rotation for failTimeout when a
+// request to it gets no response, and restores it as soon as one does.
+type healthTransport struct {
+	next        http.RoundTripper
+	failTimeout time.Duration
+	logger      *slog.Logger
+}
+
+func newHealthTransport(next http.RoundTripper, failTimeout time.Duration, logger *slog.Logger) http.RoundTripper {
+	if failTimeout <= 0 {
+		return next
+	}
+	return &healthTransport{next: next, failTimeout: failTimeout, logger: logger}
+}
+
+func (t *healthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+
+	selection, ok := req.Context().Value(upstreamSelectionKey{}).(*upstreamSelection)
+	switch {
+	case !ok:
+	case err == nil:
+		selection.replica.ejectedUntil.Store(0)
+	case req.Context().Err() == nil: // the client going away says nothing about the replica
+		selection.replica.ejectedUntil.Store(time.Now().Add(t.failTimeout).UnixNano())
+		requestLogger(req.Context(), t.logger).Warn("Upstream replica failed, removing it from rotation",
+			"error", err,
+			"upstream", selection.replica.target.String(),
+			"fail_timeout", t.failTimeout)
+	}
+	return resp, err
+}
@@ -0,0 +1,303 @@
+package specgate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const multiServiceSpec = `openapi: 3.0.3
+info:
+  title: Shop API
+  version: 1.0.0
+paths:
+  /users/{id}:
+    get:
+      operationId: getUser
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: A user
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [name]
+  /orders/{id}:
+    get:
+      operationId: getOrder
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: An order
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [total]
+`
+
+func TestParseUpstreams(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		expected      map[string]string
+		expectedError string
+	}{
+		{
+			name:     "single url",
+			value:    "http://api:8080",
+			expected: map[string]string{"/users/1": "http://api:8080"},
+		},
+		{
+			name:  "prefix mappings",
+			value: "/users=http://users:8080, /orders/=http://orders:8080/api",
+			expected: map[string]string{
+				"/users":        "http://users:8080",
+				"/users/1":      "http://users:8080",
+				"/orders/1":     "http://orders:8080/api",
+				"/usersettings": "",
+				"/":             "",
+			},
+		},
+		{
+			name:  "longest prefix wins over default",
+			value: "http://default:8080,/users=http://users:8080,/users/admin=http://admin:8080",
+			expected: map[string]string{
+				"/users/1":       "http://users:8080",
+				"/users/admin/1": "http://admin:8080",
+				"/orders/1":      "http://default:8080",
+			},
+		},
+		{
+			name:     "query string is not a prefix",
+			value:    "http://api:8080/?tenant=a",
+			expected: map[string]string{"/users": "http://api:8080/?tenant=a"},
+		},
+		{name: "empty", value: " , ", expectedError: "must not be empty"},
+		{name: "relative url", value: "/users=users:8080", expectedError: "absolute URL"},
+		{
+			name:     "replicas",
+			value:    "http://a:8080, http://b:8080",
+			expected: map[string]string{"/users/1": "http://a:8080,http://b:8080"},
+		},
+		{
+			name:  "replicas of a prefix",
+			value: "/users=http://a:8080,http://default:8080,/users/=http://b:8080",
+			expected: map[string]string{
+				"/users/1":  "http://a:8080,http://b:8080",
+				"/orders/1": "http://default:8080",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes, err := parseUpstreams(tt.value)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("parseUpstreams() error = %v, expected error containing %q", err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseUpstreams() unexpected error: %v", err)
+			}
+
+			for path, expected := range tt.expected {
+				var replicas []string
+				if route := routes.match(path); route != nil {
+					for _, replica := range route.replicas {
+						replicas = append(replicas, replica.target.String())
+					}
+				}
+				got := strings.Join(replicas, ",")
+				if got != expected {
+					t.Errorf("match(%q) = %q, expected %q", path, got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestValidatingProxy_PrefixUpstreams(t *testing.T) {
+	newService := func(name, body string, hits map[string]string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits[r.URL.Path] = name
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(body))
+		}))
+	}
+
+	hits := make(map[string]string)
+	users := newService("users", `{"name": "test"}`, hits)
+	defer users.Close()
+	orders := newService("orders", `{"total": 10}`, hits)
+	defer orders.Close()
+
+	cfg := DefaultConfig()
+	cfg.Upstream = "/users=" + users.URL + ",/orders=" + orders.URL + "/api"
+	cfg.Mode = "strict"
+	vp := newTestProxyWithConfig(t, multiServiceSpec, cfg)
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+		expectedPath   string
+		expectedHit    string
+	}{
+		{path: "/users/1", expectedStatus: http.StatusOK, expectedPath: "/users/1", expectedHit: "users"},
+		{path: "/orders/1", expectedStatus: http.StatusOK, expectedPath: "/api/orders/1", expectedHit: "orders"},
+		{path: "/carts/1", expectedStatus: http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d: %s", rec.Code, tt.expectedStatus, rec.Body.String())
+			}
+			if tt.expectedHit != "" && hits[tt.expectedPath] != tt.expectedHit {
+				t.Errorf("%s was served by %q, expected %q", tt.expectedPath, hits[tt.expectedPath], tt.expectedHit)
+			}
+		})
+	}
+}
+
+func TestUpstreamRoute_Pick(t *testing.T) {
+	routes, err := parseUpstreams("http://a,http://b,http://c")
+	if err != nil {
+		t.Fatalf("parseUpstreams() unexpected error: %v", err)
+	}
+	route := routes[0]
+	now := time.Now()
+
+	pickHosts := func(n int) string {
+		hosts := make([]string, n)
+		for i := range hosts {
+			hosts[i] = route.pick(now).target.Host
+		}
+		return strings.Join(hosts, ",")
+	}
+
+	if got := pickHosts(4); got != "a,b,c,a" {
+		t.Errorf("round robin picked %s, expected a,b,c,a", got)
+	}
+
+	route.replicas[2].ejectedUntil.Store(now.Add(time.Minute).UnixNano())
+	if got := pickHosts(4); got != "b,a,a,b" {
+		t.Errorf("with c ejected picked %s, expected b,a,a,b", got)
+	}
+
+	if got := route.replicas[2].healthy(now.Add(2 * time.Minute)); !got {
+		t.Error("expected replica to return to rotation after its fail timeout")
+	}
+
+	for _, replica := range route.replicas {
+		replica.ejectedUntil.Store(now.Add(time.Minute).UnixNano())
+	}
+	if got := pickHosts(3); got != "c,a,b" {
+		t.Errorf("with every replica ejected picked %s, expected c,a,b", got)
+	}
+}
+
+func TestValidatingProxy_UpstreamReplicas(t *testing.T) {
+	hits := make(map[string]int)
+	newReplica := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits[name]++
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id": 1, "name": "test"}`))
+		}))
+	}
+	a := newReplica("a")
+	defer a.Close()
+	b := newReplica("b")
+	down := newReplica("down")
+	down.Close()
+
+	cfg := DefaultConfig()
+	cfg.Upstream = a.URL + "," + b.URL + "," + down.URL
+	vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+	serve := func() int {
+		rec := httptest.NewRecorder()
+		vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+		return rec.Code
+	}
+
+	statuses := []int{serve(), serve(), serve()}
+	if !reflect.DeepEqual(statuses, []int{http.StatusOK, http.StatusOK, http.StatusBadGateway}) {
+		t.Errorf("statuses = %v, expected the unreachable replica to fail once", statuses)
+	}
+	if hits["a"] != 1 || hits["b"] != 1 {
+		t.Errorf("hits = %v, expected one request to each live replica", hits)
+	}
+
+	for range 4 {
+		if status := serve(); status != http.StatusOK {
+			t.Fatalf("status = %d after the failed replica was ejected, expected %d", status, http.StatusOK)
+		}
+	}
+	if hits["a"]+hits["b"] != 6 || hits["b"] < 2 {
+		t.Errorf("hits = %v, expected requests to be shared between live replicas", hits)
+	}
+
+	b.Close()
+	for range 4 {
+		serve()
+	}
+	if status := serve(); status != http.StatusOK {
+		t.Errorf("status = %d with one replica left, expected %d", status, http.StatusOK)
+	}
+}
+
+func TestValidatingProxy_RetryEjectedReplica(t *testing.T) {
+	hits := 0
+	live := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.URL.Path != "/api/users/1" {
+			t.Errorf("live replica received path %q, expected /api/users/1", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": 1, "name": "test"}`))
+	}))
+	defer live.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	cfg := DefaultConfig()
+	cfg.Upstream = down.URL + "/v1," + live.URL + "/api"
+	cfg.Retries = 1
+	cfg.RetryBackoff = time.Millisecond
+	cfg.UpstreamFailTimeout = time.Minute
+	vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+	rec := httptest.NewRecorder()
+	vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, expected the retry to reach the live replica", rec.Code)
+	}
+	if hits != 1 {
+		t.Errorf("live replica hits = %d, expected 1", hits)
+	}
+	if vp.upstreams[0].replicas[0].healthy(time.Now()) {
+		t.Error("expected the unreachable replica to be ejected")
+	}
+}
@@ -0,0 +1,78 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// customValidators holds the validators registered with RegisterValidator and
+// those built from configured assertions, by operation ID.
+type customValidators struct {
+	mu          sync.RWMutex
+	byOperation map[string][]func(body any) error
+}
+
+// RegisterValidator adds fn to the checks run on responses of the operation
+// with operationID once they pass schema validation, for invariants a schema
+// can't express. fn receives the body decoded as for validation, with JSON
+// objects as map[string]any and numbers as json.Number, and an error from it
+// fails the response like a schema violation.
+func (vp *ValidatingProxy) RegisterValidator(operationID string, fn func(body any) error) {
+	vp.validators.add(operationID, fn)
+}
+
+func (v *customValidators) add(operationID string, fn func(body any) error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.byOperation == nil {
+		v.byOperation = make(map[string][]func(body any) error)
+	}
+	v.byOperation[operationID] = append(v.byOperation[operationID], fn)
+}
+
+func (v *customValidators) check(decoders *bodyDecoders, route *routers.Route, resp *http.Response, body []byte) error {
+	id := operationID(route)
+	v.mu.RLock()
+	validators := v.byOperation[id]
+	v.mu.RUnlock()
+	if id == "" || len(validators) == 0 {
+		return nil
+	}
+
+	_, value, ok := decoders.decodeDocumentedBody(route, resp, body)
+	if !ok {
+		return nil
+	}
+	var errs []error
+	for _, fn := range validators {
+		if err := fn(value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("custom validation of %s failed: %w", id, errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package specgate
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidatingProxy_RegisterValidator(t *testing.T) {
+	nameMatchesID := func(body any) error {
+		user, _ := body.(map[string]any)
+		if user["name"] != "user-1" {
+			return errors.New("name must be derived from id")
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name           string
+		mode           string
+		operationID    string
+		body           string
+		expectedStatus int
+		expectedLog    string
+	}{
+		{name: "passes", mode: "strict", operationID: "getUser", body: `{"id": 1, "name": "user-1"}`, expectedStatus: http.StatusOK},
+		{
+			name:           "fails in strict mode",
+			mode:           "strict",
+			operationID:    "getUser",
+			body:           `{"id": 1, "name": "Ann"}`,
+			expectedStatus: http.StatusInternalServerError,
+			expectedLog:    "custom validation of getUser failed: name must be derived from id",
+		},
+		{name: "fails in warn mode", mode: "warn", operationID: "getUser", body: `{"id": 1, "name": "Ann"}`, expectedStatus: http.StatusOK, expectedLog: "name must be derived from id"},
+		{name: "other operation", mode: "strict", operationID: "listUsers", body: `{"id": 1, "name": "Ann"}`, expectedStatus: http.StatusOK},
+		{name: "schema failure takes precedence", mode: "strict", operationID: "getUser", body: `{"id": "one", "name": "Ann"}`, expectedStatus: http.StatusInternalServerError, expectedLog: "must be an integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer upstream.Close()
+
+			vp := newTestProxy(t, testSpec, upstream.URL, tt.mode)
+			var logs bytes.Buffer
+			vp.logger = slog.New(slog.NewTextHandler(&logs, nil))
+			vp.RegisterValidator(tt.operationID, nameMatchesID)
+
+			rec := httptest.NewRecorder()
+			vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("status = %d, expected %d, logs: %s", rec.Code, tt.expectedStatus, logs.String())
+			}
+			if !strings.Contains(logs.String(), tt.expectedLog) {
+				t.Errorf("logs should contain %q, got: %s", tt.expectedLog, logs.String())
+			}
+			if tt.expectedLog == "" && strings.Contains(logs.String(), "validation failed") {
+				t.Errorf("response should pass, got logs: %s", logs.String())
+			}
+		})
+	}
+}
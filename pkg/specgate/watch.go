@@ -0,0 +1,113 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const reloadDebounce = 200 * time.Millisecond
+
+// WatchSpec reloads the spec whenever a local spec file changes, until ctx
+// is cancelled. A spec that fails to load is logged and the previous version
+// stays in use.
+func (vp *ValidatingProxy) WatchSpec(ctx context.Context) error {
+	specPaths := make(map[string]bool)
+	for _, path := range splitSpecPaths(vp.specPath) {
+		if isRemoteSpec(path) {
+			return errors.New("only local spec files can be watched")
+		}
+		specPath, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("failed to resolve spec path: %w", err)
+		}
+		specPaths[specPath] = true
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+
+	// Watch the directories rather than the files, since editors often save by replacing the file
+	for specPath := range specPaths {
+		if err := watcher.Add(filepath.Dir(specPath)); err != nil {
+			watcher.Close()
+			return fmt.Errorf("failed to watch %s: %w", filepath.Dir(specPath), err)
+		}
+	}
+
+	go vp.watchLoop(ctx, watcher, specPaths)
+	return nil
+}
+
+func (vp *ValidatingProxy) watchLoop(ctx context.Context, watcher *fsnotify.Watcher, specPaths map[string]bool) {
+	defer watcher.Close()
+
+	var debounce *time.Timer
+	defer func() {
+		if debounce != nil {
+			debounce.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if !specPaths[filepath.Clean(event.Name)] || !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+			if debounce == nil {
+				debounce = time.AfterFunc(reloadDebounce, vp.reloadSpec)
+			} else {
+				debounce.Reset(reloadDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			vp.logger.Error("Spec watcher error", "error", err)
+		}
+	}
+}
+
+func (vp *ValidatingProxy) reloadSpec() {
+	spec, router, err := vp.specLoader.load(vp.specPath)
+	if err != nil {
+		vp.logger.Error("Failed to reload spec, keeping previous version",
+			"error", err,
+			"spec", vp.specPath)
+		return
+	}
+
+	vp.swapSpec(spec, router)
+	vp.logger.Info("Reloaded spec", "spec", vp.specPath)
+	vp.warnSpecIssues(spec)
+}
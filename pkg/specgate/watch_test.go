@@ -0,0 +1,146 @@
+package specgate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func routeExists(vp *ValidatingProxy, path string) bool {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	vp.rewriteRequest(req)
+	_, _, err := vp.currentRouter().FindRoute(req)
+	return err == nil
+}
+
+func waitFor(t *testing.T, condition func() bool) bool {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return false
+}
+
+func TestValidatingProxy_WatchSpec(t *testing.T) {
+	vp := newTestProxy(t, testSpec, "http://localhost:3000", "warn")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := vp.WatchSpec(ctx); err != nil {
+		t.Fatalf("WatchSpec() unexpected error: %v", err)
+	}
+
+	if routeExists(vp, "/orders") {
+		t.Fatal("/orders should not be documented before the reload")
+	}
+
+	updated := testSpec + `  /orders:
+    get:
+      responses:
+        "200":
+          description: Orders
+`
+	if err := os.WriteFile(vp.specPath, []byte(updated), 0o600); err != nil {
+		t.Fatalf("failed to update spec: %v", err)
+	}
+
+	if !waitFor(t, func() bool { return routeExists(vp, "/orders") }) {
+		t.Fatal("spec was not reloaded after the file changed")
+	}
+
+	if err := os.WriteFile(vp.specPath, []byte("openapi: [not valid"), 0o600); err != nil {
+		t.Fatalf("failed to break spec: %v", err)
+	}
+	time.Sleep(3 * reloadDebounce)
+
+	if !routeExists(vp, "/orders") || !routeExists(vp, "/users/1") {
+		t.Error("a failed reload should keep serving the last good spec")
+	}
+}
+
+func TestValidatingProxy_WatchSpecRejectsRemoteSpecs(t *testing.T) {
+	vp := &ValidatingProxy{specPath: "https://api.example.com/openapi.yaml"}
+
+	err := vp.WatchSpec(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "local") {
+		t.Errorf("WatchSpec() error = %v, expected an error for remote specs", err)
+	}
+}
+
+func TestValidatingProxy_ReloadSpecWhileServing(t *testing.T) {
+	const served = "application/vnd.specgate.served+json"
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", served)
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	}))
+	defer upstream.Close()
+
+	// Each version documents a media type no earlier one did, so reloading
+	// can't reuse decoders resolved for a previous version
+	specVersion := func(version int) string {
+		return fmt.Sprintf(`openapi: 3.0.3
+info:
+  title: Test API
+  version: 1.0.0
+paths:
+  /users/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        "200":
+          description: A user
+          content:
+            %s:
+              schema:
+                type: object
+                required: [id]
+            application/vnd.specgate.v%d+json:
+              schema:
+                type: object
+`, served, version)
+	}
+	vp := newTestProxy(t, specVersion(0), upstream.URL, "strict")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for version := 1; version <= 200; version++ {
+			if err := os.WriteFile(vp.specPath, []byte(specVersion(version)), 0o600); err != nil {
+				t.Errorf("failed to update spec: %v", err)
+				return
+			}
+			vp.reloadSpec()
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		rec := httptest.NewRecorder()
+		vp.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("status = %d during a reload, expected %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+			<-done
+			return
+		}
+	}
+}
@@ -0,0 +1,213 @@
+/**
+    SpecGate - A lightweight OpenAPI validation proxy for real-time API response validation.
+    Copyright (C) 2025 Søren Johanson
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+**/
+
+package specgate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const webhookQueueSize = 64
+
+// HeaderMap holds extra HTTP headers, set on the command line as repeated
+// "Name: value" flags.
+type HeaderMap map[string]string
+
+func (h HeaderMap) String() string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	headers := make([]string, 0, len(names))
+	for _, name := range names {
+		headers = append(headers, name+": "+h[name])
+	}
+	return strings.Join(headers, ", ")
+}
+
+func (h *HeaderMap) Set(value string) error {
+	name, headerValue, ok := strings.Cut(value, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("invalid header %q: must be \"Name: value\"", value)
+	}
+	if *h == nil {
+		*h = make(HeaderMap)
+	}
+	(*h)[strings.TrimSpace(name)] = strings.TrimSpace(headerValue)
+	return nil
+}
+
+// failureNotifier is told about validation failures, typically to pass them
+// on to an external service.
+type failureNotifier interface {
+	Notify(failure notifiedFailure)
+	Close()
+}
+
+// notifiedFailure is a validation failure along with the context notifiers
+// need to group and present it.
+type notifiedFailure struct {
+	FailureRecord
+	Operation string
+	Mode      Mode
+}
+
+func newNotifiers(cfg *Config, logger *slog.Logger) []failureNotifier {
+	var notifiers []failureNotifier
+	if cfg.WebhookURL != "" {
+		notifiers = append(notifiers, newWebhookNotifier(cfg, logger))
+	}
+	if cfg.SlackWebhook != "" {
+		notifiers = append(notifiers, newSlackNotifier(cfg, logger))
+	}
+	return notifiers
+}
+
+// WebhookPayload is the JSON body posted to -webhook-url for a validation
+// failure. Suppressed counts the failures of the same operation that were
+// held back since the previous notification.
+type WebhookPayload struct {
+	FailureRecord
+	Suppressed int `json:"suppressed,omitempty"`
+}
+
+// webhookNotifier posts validation failures to a webhook from a background
+// goroutine, so a slow webhook never holds up the proxy. Each operation is
+// notified at most once per interval; failures in between are counted and
+// reported with the next notification.
+type webhookNotifier struct {
+	url      string
+	headers  HeaderMap
+	interval time.Duration
+	client   *http.Client
+	logger   *slog.Logger
+
+	mu         sync.Mutex
+	lastSent   map[string]time.Time
+	suppressed map[string]int
+	closed     bool
+
+	queue chan WebhookPayload
+	done  chan struct{}
+}
+
+func newWebhookNotifier(cfg *Config, logger *slog.Logger) *webhookNotifier {
+	n := &webhookNotifier{
+		url:        cfg.WebhookURL,
+		headers:    cfg.WebhookHeaders,
+		interval:   cfg.WebhookInterval,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		logger:     logger,
+		lastSent:   make(map[string]time.Time),
+		suppressed: make(map[string]int),
+		queue:      make(chan WebhookPayload, webhookQueueSize),
+		done:       make(chan struct{}),
+	}
+	go n.run()
+	return n
+}
+
+// Notify queues a notification for the failure unless its operation was
+// notified within the interval. It never blocks: when the queue is full the
+// failure is dropped, as are failures after the notifier is closed.
+func (n *webhookNotifier) Notify(failure notifiedFailure) {
+	operation := failure.Operation
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		return
+	}
+	now := time.Now()
+	if last, ok := n.lastSent[operation]; ok && now.Sub(last) < n.interval {
+		n.suppressed[operation]++
+		return
+	}
+	payload := WebhookPayload{FailureRecord: failure.FailureRecord, Suppressed: n.suppressed[operation]}
+	n.lastSent[operation] = now
+	delete(n.suppressed, operation)
+
+	select {
+	case n.queue <- payload:
+	default:
+		n.logger.Warn("Webhook queue full, dropping notification", "operation", operation)
+	}
+}
+
+func (n *webhookNotifier) run() {
+	defer close(n.done)
+	for payload := range n.queue {
+		if err := n.send(payload); err != nil {
+			n.logger.Error("Failed to send webhook notification", "error", err)
+		}
+	}
+}
+
+func (n *webhookNotifier) send(payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range n.headers {
+		// Secrets can be kept out of the config file as ${VAR} references
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Close sends the notifications still queued and stops the notifier. Later
+// calls do nothing.
+func (n *webhookNotifier) Close() {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.queue)
+	}
+	n.mu.Unlock()
+
+	<-n.done
+}
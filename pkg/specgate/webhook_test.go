@@ -0,0 +1,165 @@
+package specgate
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type webhookReceiver struct {
+	mu       sync.Mutex
+	payloads []WebhookPayload
+	headers  []http.Header
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var payload WebhookPayload
+	_ = json.NewDecoder(r.Body).Decode(&payload)
+
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	rcv.payloads = append(rcv.payloads, payload)
+	rcv.headers = append(rcv.headers, r.Header.Clone())
+}
+
+func TestValidatingProxy_Webhook(t *testing.T) {
+	t.Setenv("SPECGATE_TEST_WEBHOOK_TOKEN", "s3cret")
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": "wrong"}`))
+	}))
+	defer upstream.Close()
+
+	receiver := &webhookReceiver{}
+	webhook := httptest.NewServer(receiver)
+	defer webhook.Close()
+
+	cfg := DefaultConfig()
+	cfg.Upstream = upstream.URL
+	cfg.WebhookURL = webhook.URL
+	cfg.WebhookHeaders = HeaderMap{"Authorization": "Bearer ${SPECGATE_TEST_WEBHOOK_TOKEN}"}
+	cfg.WebhookInterval = time.Hour
+	vp := newTestProxyWithConfig(t, testSpec, cfg)
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/3"} {
+		vp.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if err := vp.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+
+	if len(receiver.payloads) != 1 {
+		t.Fatalf("expected 1 notification within the interval, got %d", len(receiver.payloads))
+	}
+	payload := receiver.payloads[0]
+	if payload.OperationID != "getUser" || payload.Path != "/users/{id}" || payload.Status != http.StatusOK ||
+		payload.Error == "" || payload.RequestID == "" {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+	if got := receiver.headers[0].Get("Authorization"); got != "Bearer s3cret" {
+		t.Errorf("Authorization header = %q, expected the expanded secret", got)
+	}
+}
+
+func TestWebhookNotifier_Interval(t *testing.T) {
+	receiver := &webhookReceiver{}
+	webhook := httptest.NewServer(receiver)
+	defer webhook.Close()
+
+	cfg := DefaultConfig()
+	cfg.WebhookURL = webhook.URL
+	cfg.WebhookInterval = 50 * time.Millisecond
+	notifier := newWebhookNotifier(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	getUser := notifiedFailure{FailureRecord: FailureRecord{OperationID: "getUser"}, Operation: "getUser"}
+	listUsers := notifiedFailure{FailureRecord: FailureRecord{OperationID: "listUsers"}, Operation: "listUsers"}
+	for range 3 {
+		notifier.Notify(getUser)
+	}
+	notifier.Notify(listUsers)
+	time.Sleep(60 * time.Millisecond)
+	notifier.Notify(getUser)
+	notifier.Close()
+
+	var suppressed []int
+	for _, payload := range receiver.payloads {
+		if payload.OperationID == "getUser" {
+			suppressed = append(suppressed, payload.Suppressed)
+		}
+	}
+	if len(receiver.payloads) != 3 || len(suppressed) != 2 || suppressed[0] != 0 || suppressed[1] != 2 {
+		t.Errorf("expected getUser notifications with 0 then 2 suppressed plus one for listUsers, got %+v", receiver.payloads)
+	}
+}
+
+func TestWebhookNotifier_AfterClose(t *testing.T) {
+	receiver := &webhookReceiver{}
+	webhook := httptest.NewServer(receiver)
+	defer webhook.Close()
+
+	cfg := DefaultConfig()
+	cfg.WebhookURL = webhook.URL
+	notifier := newWebhookNotifier(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	notifier.Close()
+
+	notifier.Notify(notifiedFailure{FailureRecord: FailureRecord{OperationID: "getUser"}, Operation: "getUser"})
+	notifier.Close()
+
+	if len(receiver.payloads) != 0 {
+		t.Errorf("expected notifications after Close to be dropped, got %+v", receiver.payloads)
+	}
+}
+
+func TestHeaderMap_Set(t *testing.T) {
+	tests := []struct {
+		name        string
+		values      []string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "single header",
+			values:   []string{"Authorization: Bearer token"},
+			expected: "Authorization: Bearer token",
+		},
+		{
+			name:     "repeated headers",
+			values:   []string{"X-Token: abc", "Authorization: Basic xyz"},
+			expected: "Authorization: Basic xyz, X-Token: abc",
+		},
+		{
+			name:        "missing colon",
+			values:      []string{"Authorization"},
+			expectError: true,
+		},
+		{
+			name:        "missing name",
+			values:      []string{": value"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers HeaderMap
+			var err error
+			for _, value := range tt.values {
+				if err = headers.Set(value); err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Set() error = %v, expectError %v", err, tt.expectError)
+			}
+			if !tt.expectError && headers.String() != tt.expected {
+				t.Errorf("String() = %q, expected %q", headers.String(), tt.expected)
+			}
+		})
+	}
+}